@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/disintegration/imaging"
+	"go.coaster.io/server/common/database"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/images"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/repositories/users"
+	"gorm.io/gorm"
+)
+
+// Computes blurhash placeholders and dominant colors for listing images and profile pictures that
+// were uploaded before they were generated at upload time. Safe to re-run, since anything that
+// already has a placeholder is skipped.
+func main() {
+	batchSize := flag.Int("batch-size", 100, "number of images to load at a time")
+	dryRun := flag.Bool("dry-run", false, "compute placeholders without saving them")
+	flag.Parse()
+
+	db, err := database.InitDatabase()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	updated, failed := backfillListingImages(db, client, *batchSize, *dryRun)
+	log.Printf("listing images: %d updated, %d failed", updated, failed)
+
+	updated, failed = backfillProfilePictures(db, client, *batchSize, *dryRun)
+	log.Printf("profile pictures: %d updated, %d failed", updated, failed)
+}
+
+func backfillListingImages(db *gorm.DB, client *http.Client, batchSize int, dryRun bool) (int, int) {
+	updated, failed := 0, 0
+	var afterID int64
+	for {
+		listingImages, err := listings.LoadImagesMissingPlaceholder(db, afterID, batchSize)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(listingImages) == 0 {
+			return updated, failed
+		}
+
+		for i := range listingImages {
+			listingImage := &listingImages[i]
+			afterID = listingImage.ID
+
+			// The card variant is plenty for a blurry placeholder and much cheaper to download
+			url := images.GetVariantUrl(*listingImage, models.ImageVariantCard, models.ImageFormatJPEG)
+			placeholder, err := fetchPlaceholder(client, url)
+			if err != nil {
+				log.Printf("listing image %d: %v", listingImage.ID, err)
+				failed++
+				continue
+			}
+
+			if !dryRun {
+				err = listings.UpdateImagePlaceholder(db, listingImage, *placeholder)
+				if err != nil {
+					log.Printf("listing image %d: %v", listingImage.ID, err)
+					failed++
+					continue
+				}
+			}
+
+			log.Printf("listing image %d: %s %s", listingImage.ID, placeholder.Blurhash, placeholder.DominantColor)
+			updated++
+		}
+	}
+}
+
+func backfillProfilePictures(db *gorm.DB, client *http.Client, batchSize int, dryRun bool) (int, int) {
+	updated, failed := 0, 0
+	var afterID int64
+	for {
+		usersToUpdate, err := users.LoadMissingProfilePicturePlaceholder(db, afterID, batchSize)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(usersToUpdate) == 0 {
+			return updated, failed
+		}
+
+		for i := range usersToUpdate {
+			user := &usersToUpdate[i]
+			afterID = user.ID
+
+			placeholder, err := fetchPlaceholder(client, *user.ProfilePictureURL)
+			if err != nil {
+				log.Printf("user %d: %v", user.ID, err)
+				failed++
+				continue
+			}
+
+			if !dryRun {
+				err = users.UpdateProfilePicturePlaceholder(db, user, *placeholder)
+				if err != nil {
+					log.Printf("user %d: %v", user.ID, err)
+					failed++
+					continue
+				}
+			}
+
+			log.Printf("user %d: %s %s", user.ID, placeholder.Blurhash, placeholder.DominantColor)
+			updated++
+		}
+	}
+}
+
+func fetchPlaceholder(client *http.Client, url string) (*images.Placeholder, error) {
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "(main.fetchPlaceholder) fetching %s", url)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, errors.Newf("(main.fetchPlaceholder) fetching %s: status %d", url, response.StatusCode)
+	}
+
+	img, err := imaging.Decode(response.Body, imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, errors.Wrapf(err, "(main.fetchPlaceholder) decoding %s", url)
+	}
+
+	placeholder := images.GetPlaceholder(img)
+	return &placeholder, nil
+}
@@ -14,7 +14,9 @@ import (
 
 	"go.coaster.io/server/common/application"
 	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/blobs"
 	"go.coaster.io/server/common/database"
+	"go.coaster.io/server/common/maps"
 	"go.coaster.io/server/internal/api"
 	"go.coaster.io/server/internal/router"
 
@@ -41,8 +43,20 @@ func main() {
 		defer highlight.Stop()
 	}
 
+	geocoder, err := maps.NewGeocoder(db)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	blobStore, err := blobs.NewStore()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
 	authService := auth.NewAuthService(db)
-	apiService := api.NewApiService(db, authService)
+	apiService := api.NewApiService(db, authService, geocoder, blobStore)
 
 	router := router.NewRouter(authService)
 	router.RunService(apiService)
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"go.coaster.io/server/common/blobs"
+	"go.coaster.io/server/common/database"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/repositories/users"
+)
+
+// Cleans up after uploads that failed halfway. Storage objects that no live row points at are
+// deleted once they're past the grace period, and listing images whose objects are missing are
+// flagged. Defaults to a dry run that only prints the report, pass -dry-run=false to apply it.
+func main() {
+	dryRun := flag.Bool("dry-run", true, "report what would change without deleting or flagging anything")
+	gracePeriod := flag.Duration("grace-period", blobs.DEFAULT_ORPHAN_GRACE_PERIOD, "minimum age of an orphaned object before it's deleted")
+	flag.Parse()
+
+	db, err := database.InitDatabase()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := blobs.NewStore()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	listingImages, err := listings.LoadAllActiveImages(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	usersWithPictures, err := users.LoadAllWithProfilePicture(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Maps each referenced object back to the listing images that need it, so missing ones can be flagged
+	referenced := make(map[string]bool)
+	imageIDsByStorageID := make(map[string][]int64)
+	for _, listingImage := range listingImages {
+		storageIDs := []string{listingImage.StorageID}
+		if listingImage.PosterStorageID != nil {
+			storageIDs = append(storageIDs, *listingImage.PosterStorageID)
+		}
+		for _, variant := range listingImage.Variants {
+			storageIDs = append(storageIDs, variant.StorageID)
+		}
+
+		for _, storageID := range storageIDs {
+			referenced[storageID] = true
+			imageIDsByStorageID[storageID] = append(imageIDsByStorageID[storageID], listingImage.ID)
+		}
+	}
+
+	userIDsByStorageID := make(map[string]int64)
+	for _, user := range usersWithPictures {
+		storageID, err := blobs.GetStorageIDFromURL(*user.ProfilePictureURL)
+		if err != nil {
+			log.Printf("user %d: %v", user.ID, err)
+			continue
+		}
+
+		referenced[storageID] = true
+		userIDsByStorageID[storageID] = user.ID
+	}
+
+	report, err := blobs.Reconcile(context.Background(), store, referenced, *gracePeriod, *dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	missingImages := make(map[int64]bool)
+	for _, storageID := range report.Missing {
+		for _, imageID := range imageIDsByStorageID[storageID] {
+			missingImages[imageID] = true
+		}
+
+		if userID, ok := userIDsByStorageID[storageID]; ok {
+			log.Printf("missing: %s (profile picture for user %d)", storageID, userID)
+		} else {
+			log.Printf("missing: %s (listing images %v)", storageID, imageIDsByStorageID[storageID])
+		}
+	}
+
+	missingImageIDs := []int64{}
+	foundImageIDs := []int64{}
+	for _, listingImage := range listingImages {
+		if missingImages[listingImage.ID] {
+			missingImageIDs = append(missingImageIDs, listingImage.ID)
+		} else {
+			foundImageIDs = append(foundImageIDs, listingImage.ID)
+		}
+	}
+
+	for _, object := range report.Orphaned {
+		if err, failed := report.DeleteErrors[object.StorageID]; failed {
+			log.Printf("orphaned: %s (created %s, delete failed: %v)", object.StorageID, object.CreatedAt, err)
+		} else {
+			log.Printf("orphaned: %s (created %s)", object.StorageID, object.CreatedAt)
+		}
+	}
+
+	for _, object := range report.Pending {
+		log.Printf("pending: %s (created %s, within grace period)", object.StorageID, object.CreatedAt)
+	}
+
+	if !*dryRun {
+		err = listings.UpdateImagesMissing(db, missingImageIDs, foundImageIDs)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	verb := "deleted"
+	if *dryRun {
+		verb = "would delete"
+	}
+
+	log.Printf(
+		"%d orphaned objects (%s %d), %d within grace period, %d missing objects across %d listing images",
+		len(report.Orphaned),
+		verb,
+		len(report.Orphaned)-len(report.DeleteErrors),
+		len(report.Pending),
+		len(report.Missing),
+		len(missingImageIDs),
+	)
+}
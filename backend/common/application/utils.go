@@ -11,3 +11,22 @@ func IsCloudBuild() bool {
 	_, isSet := os.LookupEnv("IS_CLOUD_BUILD")
 	return isSet
 }
+
+// Unverified accounts can browse and build listings, but these can require a verified email before
+// booking or before a host submits a listing for review
+func RequireVerifiedEmailForCheckout() bool {
+	_, isSet := os.LookupEnv("REQUIRE_VERIFIED_EMAIL_FOR_CHECKOUT")
+	return isSet
+}
+
+func RequireVerifiedEmailForHosting() bool {
+	_, isSet := os.LookupEnv("REQUIRE_VERIFIED_EMAIL_FOR_HOSTING")
+	return isSet
+}
+
+// Admins can't use anything but the two-factor setup routes until they've entered a code. This lets
+// them in without one while the first admins enroll, and should be removed once they all have.
+func AllowAdminsWithoutTwoFactor() bool {
+	_, isSet := os.LookupEnv("ALLOW_ADMINS_WITHOUT_TWO_FACTOR")
+	return isSet
+}
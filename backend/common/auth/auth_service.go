@@ -13,6 +13,9 @@ import (
 
 const SESSION_COOKIE_NAME = "X-Session-Token"
 
+// Set when a login link is requested, so the link only works in the same browser
+const LOGIN_NONCE_COOKIE_NAME = "X-Login-Nonce"
+
 type AuthService interface {
 	GetAuthentication(r *http.Request) (*Authentication, error)
 }
@@ -68,6 +71,14 @@ func DeleteSessionCookie(w http.ResponseWriter) {
 	deleteCookie(w, SESSION_COOKIE_NAME)
 }
 
+func AddLoginNonceCookie(w http.ResponseWriter, nonce string) {
+	addCookie(w, LOGIN_NONCE_COOKIE_NAME, nonce)
+}
+
+func DeleteLoginNonceCookie(w http.ResponseWriter) {
+	deleteCookie(w, LOGIN_NONCE_COOKIE_NAME)
+}
+
 func (as AuthServiceImpl) authenticateCookie(r *http.Request) (*Authentication, error) {
 	cookie, err := r.Cookie(SESSION_COOKIE_NAME)
 	if err != nil {
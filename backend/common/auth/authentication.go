@@ -1,11 +1,28 @@
 package auth
 
 import (
+	"time"
+
+	"go.coaster.io/server/common/application"
 	"go.coaster.io/server/common/models"
 )
 
+// How long after logging in or reauthenticating the user can do sensitive things like changing
+// where payouts go
+const REAUTHENTICATION_WINDOW = 10 * time.Minute
+
 type Authentication struct {
 	Session         *models.Session
 	User            *models.User
 	IsAuthenticated bool
 }
+
+// Admins have to use two-factor. Until they have in this session, only the routes for setting it
+// up are allowed.
+func (a Authentication) NeedsTwoFactor() bool {
+	return !application.AllowAdminsWithoutTwoFactor() && a.User != nil && a.User.IsAdmin && (a.Session == nil || a.Session.SecondFactorAt == nil)
+}
+
+func (a Authentication) IsRecentlyAuthenticated() bool {
+	return a.Session != nil && time.Since(a.Session.AuthenticatedAt) < REAUTHENTICATION_WINDOW
+}
@@ -0,0 +1,99 @@
+package blobs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"time"
+
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/errors"
+)
+
+// Object storage for user uploads like listing images and profile pictures. Storage IDs are
+// generated by the caller and objects are never overwritten, so they can be cached forever.
+type Store interface {
+	// Fails if an object already exists with the storage ID
+	Upload(ctx context.Context, storageID string, contentType string, data io.Reader) error
+	// Returns ErrNotFound if there's no object with the storage ID
+	Download(ctx context.Context, storageID string) (io.ReadCloser, error)
+	Copy(ctx context.Context, sourceID string, destinationID string) error
+	Delete(ctx context.Context, storageID string) error
+	// Every object in the store, in no particular order
+	List(ctx context.Context) ([]ObjectInfo, error)
+}
+
+type ObjectInfo struct {
+	StorageID string
+	CreatedAt time.Time
+}
+
+var ErrNotFound = errors.New("blob not found")
+
+// Production uses GCS. Development stores files on disk and serves them from the API unless
+// USE_GCS_STORAGE is set, so it works without GCP credentials.
+func NewStore() (Store, error) {
+	if UseLocalStore() {
+		return NewLocalStore(getLocalStoreDir())
+	}
+
+	gcsStore, err := NewGcsStore(getBucketName())
+	if err != nil {
+		return nil, errors.Wrap(err, "(blobs.NewStore) creating GCS store")
+	}
+
+	return gcsStore, nil
+}
+
+func UseLocalStore() bool {
+	_, useGcs := os.LookupEnv("USE_GCS_STORAGE")
+	return !application.IsProd() && !useGcs
+}
+
+// Where browsers can load an object from. IMAGES_CDN_HOST puts a CDN in front of whichever store
+// is in use, e.g. images.trycoaster.com in production.
+func GetPublicURL(storageID string) string {
+	if cdnHost, ok := os.LookupEnv("IMAGES_CDN_HOST"); ok {
+		return fmt.Sprintf("https://%s/%s", cdnHost, storageID)
+	}
+
+	if UseLocalStore() {
+		return fmt.Sprintf("http://localhost:8080/blobs/%s", storageID)
+	}
+
+	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", getBucketName(), storageID)
+}
+
+// Reverses GetPublicURL for places that store the full URL, like profile pictures
+func GetStorageIDFromURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "(blobs.GetStorageIDFromURL) parsing %s", rawURL)
+	}
+
+	storageID := path.Base(parsed.Path)
+	if storageID == "/" || storageID == "." {
+		return "", errors.Newf("(blobs.GetStorageIDFromURL) no storage ID in %s", rawURL)
+	}
+
+	return storageID, nil
+}
+
+func getBucketName() string {
+	if application.IsProd() {
+		return "user-images-bucket-us"
+	} else {
+		return "dev-user-images-bucket"
+	}
+}
+
+func getLocalStoreDir() string {
+	if dir, ok := os.LookupEnv("LOCAL_STORAGE_DIR"); ok {
+		return dir
+	}
+
+	return "dev/blobs"
+}
@@ -0,0 +1,97 @@
+package blobs
+
+import (
+	"context"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"go.coaster.io/server/common/errors"
+	"google.golang.org/api/iterator"
+)
+
+type GcsStore struct {
+	client *storage.Client
+	bucket string
+}
+
+func NewGcsStore(bucket string) (Store, error) {
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, errors.Wrap(err, "(blobs.NewGcsStore) opening storage client")
+	}
+
+	return GcsStore{
+		client: client,
+		bucket: bucket,
+	}, nil
+}
+
+func (g GcsStore) Upload(ctx context.Context, storageID string, contentType string, data io.Reader) error {
+	o := g.client.Bucket(g.bucket).Object(storageID).If(storage.Conditions{DoesNotExist: true})
+	wc := o.NewWriter(ctx)
+	wc.ContentType = contentType
+	wc.CacheControl = "public, max-age=31536000, immutable"
+	if _, err := io.Copy(wc, data); err != nil {
+		wc.Close()
+		return errors.Wrapf(err, "(blobs.GcsStore.Upload) copying %s to storage", storageID)
+	}
+	if err := wc.Close(); err != nil {
+		return errors.Wrapf(err, "(blobs.GcsStore.Upload) closing %s", storageID)
+	}
+
+	return nil
+}
+
+func (g GcsStore) Download(ctx context.Context, storageID string) (io.ReadCloser, error) {
+	reader, err := g.client.Bucket(g.bucket).Object(storageID).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrapf(err, "(blobs.GcsStore.Download) opening %s", storageID)
+	}
+
+	return reader, nil
+}
+
+func (g GcsStore) Copy(ctx context.Context, sourceID string, destinationID string) error {
+	bucket := g.client.Bucket(g.bucket)
+	dst := bucket.Object(destinationID).If(storage.Conditions{DoesNotExist: true})
+	_, err := dst.CopierFrom(bucket.Object(sourceID)).Run(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "(blobs.GcsStore.Copy) copying %s to %s", sourceID, destinationID)
+	}
+
+	return nil
+}
+
+func (g GcsStore) Delete(ctx context.Context, storageID string) error {
+	err := g.client.Bucket(g.bucket).Object(storageID).Delete(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "(blobs.GcsStore.Delete) deleting %s", storageID)
+	}
+
+	return nil
+}
+
+func (g GcsStore) List(ctx context.Context) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	it := g.client.Bucket(g.bucket).Objects(ctx, nil)
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "(blobs.GcsStore.List) listing objects")
+		}
+
+		objects = append(objects, ObjectInfo{
+			StorageID: attrs.Name,
+			CreatedAt: attrs.Created,
+		})
+	}
+
+	return objects, nil
+}
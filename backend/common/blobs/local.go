@@ -0,0 +1,136 @@
+package blobs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.coaster.io/server/common/errors"
+)
+
+// Keeps objects as files in a single directory, for tests and local development
+type LocalStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) (Store, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, errors.Wrapf(err, "(blobs.NewLocalStore) creating %s", dir)
+	}
+
+	return LocalStore{
+		dir: dir,
+	}, nil
+}
+
+// Content types aren't stored, since they can be sniffed from the data when serving
+func (l LocalStore) Upload(ctx context.Context, storageID string, contentType string, data io.Reader) error {
+	path, err := l.getPath(storageID)
+	if err != nil {
+		return errors.Wrap(err, "(blobs.LocalStore.Upload)")
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "(blobs.LocalStore.Upload) creating %s", storageID)
+	}
+
+	if _, err := io.Copy(file, data); err != nil {
+		file.Close()
+		os.Remove(path)
+		return errors.Wrapf(err, "(blobs.LocalStore.Upload) writing %s", storageID)
+	}
+
+	if err := file.Close(); err != nil {
+		return errors.Wrapf(err, "(blobs.LocalStore.Upload) closing %s", storageID)
+	}
+
+	return nil
+}
+
+func (l LocalStore) Download(ctx context.Context, storageID string) (io.ReadCloser, error) {
+	path, err := l.getPath(storageID)
+	if err != nil {
+		return nil, errors.Wrap(err, "(blobs.LocalStore.Download)")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+
+		return nil, errors.Wrapf(err, "(blobs.LocalStore.Download) opening %s", storageID)
+	}
+
+	return file, nil
+}
+
+func (l LocalStore) Copy(ctx context.Context, sourceID string, destinationID string) error {
+	source, err := l.Download(ctx, sourceID)
+	if err != nil {
+		return errors.Wrapf(err, "(blobs.LocalStore.Copy) opening %s", sourceID)
+	}
+	defer source.Close()
+
+	err = l.Upload(ctx, destinationID, "", source)
+	if err != nil {
+		return errors.Wrapf(err, "(blobs.LocalStore.Copy) copying %s to %s", sourceID, destinationID)
+	}
+
+	return nil
+}
+
+func (l LocalStore) Delete(ctx context.Context, storageID string) error {
+	path, err := l.getPath(storageID)
+	if err != nil {
+		return errors.Wrap(err, "(blobs.LocalStore.Delete)")
+	}
+
+	err = os.Remove(path)
+	if err != nil {
+		return errors.Wrapf(err, "(blobs.LocalStore.Delete) deleting %s", storageID)
+	}
+
+	return nil
+}
+
+// Files don't record when they were created, so this uses the last modified time instead. Objects
+// are never modified after they're written, so it's the same thing.
+func (l LocalStore) List(ctx context.Context) ([]ObjectInfo, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "(blobs.LocalStore.List) reading %s", l.dir)
+	}
+
+	objects := []ObjectInfo{}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, errors.Wrapf(err, "(blobs.LocalStore.List) reading %s", entry.Name())
+		}
+
+		objects = append(objects, ObjectInfo{
+			StorageID: entry.Name(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+
+	return objects, nil
+}
+
+// Storage IDs can come from request URLs, so make sure they can't point outside the directory
+func (l LocalStore) getPath(storageID string) (string, error) {
+	if storageID == "" || storageID == "." || storageID == ".." || filepath.Base(storageID) != storageID {
+		return "", errors.Newf("invalid storage ID %q", storageID)
+	}
+
+	return filepath.Join(l.dir, storageID), nil
+}
@@ -0,0 +1,81 @@
+package blobs
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"go.coaster.io/server/common/errors"
+)
+
+// Uploads happen before the row pointing at them is saved, so recent objects without a row may
+// still be in flight and are left alone
+const DEFAULT_ORPHAN_GRACE_PERIOD = 24 * time.Hour
+
+type ReconcileReport struct {
+	// Objects with no live reference that are old enough to delete, sorted by storage ID
+	Orphaned []ObjectInfo
+	// Objects with no live reference that are still within the grace period
+	Pending []ObjectInfo
+	// Referenced storage IDs with no object in the store, sorted
+	Missing []string
+	// Orphans that couldn't be deleted, keyed by storage ID
+	DeleteErrors map[string]error
+}
+
+// Compares the store against the storage IDs that live rows point at. Orphans past the grace period
+// are deleted unless this is a dry run. Missing objects are only reported, since the caller knows
+// which rows they belong to.
+func Reconcile(ctx context.Context, store Store, referenced map[string]bool, gracePeriod time.Duration, dryRun bool) (*ReconcileReport, error) {
+	objects, err := store.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "(blobs.Reconcile) listing objects")
+	}
+
+	report := ReconcileReport{
+		Orphaned:     []ObjectInfo{},
+		Pending:      []ObjectInfo{},
+		Missing:      []string{},
+		DeleteErrors: make(map[string]error),
+	}
+
+	cutoff := time.Now().Add(-gracePeriod)
+	stored := make(map[string]bool)
+	for _, object := range objects {
+		stored[object.StorageID] = true
+		if referenced[object.StorageID] {
+			continue
+		}
+
+		if object.CreatedAt.After(cutoff) {
+			report.Pending = append(report.Pending, object)
+		} else {
+			report.Orphaned = append(report.Orphaned, object)
+		}
+	}
+
+	for storageID := range referenced {
+		if !stored[storageID] {
+			report.Missing = append(report.Missing, storageID)
+		}
+	}
+
+	sort.Slice(report.Orphaned, func(i, j int) bool { return report.Orphaned[i].StorageID < report.Orphaned[j].StorageID })
+	sort.Slice(report.Pending, func(i, j int) bool { return report.Pending[i].StorageID < report.Pending[j].StorageID })
+	sort.Strings(report.Missing)
+
+	if dryRun {
+		return &report, nil
+	}
+
+	for _, object := range report.Orphaned {
+		err := store.Delete(ctx, object.StorageID)
+		if err != nil {
+			log.Printf("(blobs.Reconcile) failed to delete orphan %s: %v", object.StorageID, err)
+			report.DeleteErrors[object.StorageID] = err
+		}
+	}
+
+	return &report, nil
+}
@@ -54,6 +54,39 @@ var Forbidden = &HttpError{
 	},
 }
 
+// Clients check for these messages to send the user to set up two-factor or to reauthenticate
+var TwoFactorRequired = &HttpError{
+	code: http.StatusForbidden,
+	CustomerVisibleError: CustomerVisibleError{
+		message: "Two-factor authentication required",
+	},
+}
+
+var ReauthenticationRequired = &HttpError{
+	code: http.StatusForbidden,
+	CustomerVisibleError: CustomerVisibleError{
+		message: "Reauthentication required",
+	},
+}
+
+func NewForbidden(customerVisibleError string) error {
+	return &HttpError{
+		code: http.StatusForbidden,
+		CustomerVisibleError: CustomerVisibleError{
+			message: customerVisibleError,
+		},
+	}
+}
+
+func NewTooManyRequests(customerVisibleError string) error {
+	return &HttpError{
+		code: http.StatusTooManyRequests,
+		CustomerVisibleError: CustomerVisibleError{
+			message: customerVisibleError,
+		},
+	}
+}
+
 func NewCustomerVisibleError(message string) error {
 	return &CustomerVisibleError{
 		message: message,
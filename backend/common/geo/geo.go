@@ -54,3 +54,43 @@ func (p *Point) Scan(val interface{}) error {
 func (p Point) Value() (driver.Value, error) {
 	return p.String(), nil
 }
+
+func (p Point) Validate() error {
+	if p.Latitude < -90 || p.Latitude > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90")
+	}
+
+	if p.Longitude < -180 || p.Longitude > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180")
+	}
+
+	return nil
+}
+
+// Longitudes are in the range [-180, 180]. West is greater than East when the box crosses the antimeridian.
+type BoundingBox struct {
+	West  float64 `json:"west"`
+	South float64 `json:"south"`
+	East  float64 `json:"east"`
+	North float64 `json:"north"`
+}
+
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.West > b.East
+}
+
+func (b BoundingBox) Validate() error {
+	if b.South < -90 || b.South > 90 || b.North < -90 || b.North > 90 {
+		return fmt.Errorf("latitudes must be between -90 and 90")
+	}
+
+	if b.West < -180 || b.West > 180 || b.East < -180 || b.East > 180 {
+		return fmt.Errorf("longitudes must be between -180 and 180")
+	}
+
+	if b.South > b.North {
+		return fmt.Errorf("south must be less than or equal to north")
+	}
+
+	return nil
+}
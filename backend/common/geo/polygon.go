@@ -0,0 +1,122 @@
+package geo
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const wkbPolygonType = 3
+const ewkbSRIDFlag = 0x20000000
+
+// The first ring is the exterior and any others are holes. Each ring must be closed, so its first and
+// last points are the same.
+type Polygon [][]Point
+
+func (p Polygon) String() string {
+	rings := make([]string, len(p))
+	for i, ring := range p {
+		points := make([]string, len(ring))
+		for j, point := range ring {
+			points[j] = fmt.Sprintf("%v %v", point.Longitude, point.Latitude)
+		}
+
+		rings[i] = "(" + strings.Join(points, ", ") + ")"
+	}
+
+	return fmt.Sprintf("SRID=4326;POLYGON(%s)", strings.Join(rings, ", "))
+}
+
+func (p Polygon) Validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("polygon must have at least one ring")
+	}
+
+	for _, ring := range p {
+		if len(ring) < 4 {
+			return fmt.Errorf("polygon rings must have at least 4 points")
+		}
+
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("polygon rings must start and end with the same point")
+		}
+
+		for _, point := range ring {
+			if point.Validate() != nil {
+				return fmt.Errorf("polygon points must be valid coordinates")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Polygon) Scan(val interface{}) error {
+	b, err := hex.DecodeString(val.(string))
+	if err != nil {
+		return err
+	}
+
+	r := bytes.NewReader(b)
+	var wkbByteOrder uint8
+	if err := binary.Read(r, binary.LittleEndian, &wkbByteOrder); err != nil {
+		return err
+	}
+
+	var byteOrder binary.ByteOrder
+	switch wkbByteOrder {
+	case 0:
+		byteOrder = binary.BigEndian
+	case 1:
+		byteOrder = binary.LittleEndian
+	default:
+		return fmt.Errorf("invalid byte order %d", wkbByteOrder)
+	}
+
+	var wkbGeometryType uint32
+	if err := binary.Read(r, byteOrder, &wkbGeometryType); err != nil {
+		return err
+	}
+
+	// PostGIS returns extended WKB, which includes the SRID after the type when the flag is set
+	if wkbGeometryType&ewkbSRIDFlag != 0 {
+		var srid uint32
+		if err := binary.Read(r, byteOrder, &srid); err != nil {
+			return err
+		}
+	}
+
+	if wkbGeometryType&0xFF != wkbPolygonType {
+		return fmt.Errorf("invalid geometry type %d, expected polygon", wkbGeometryType&0xFF)
+	}
+
+	var numRings uint32
+	if err := binary.Read(r, byteOrder, &numRings); err != nil {
+		return err
+	}
+
+	polygon := make(Polygon, numRings)
+	for i := range polygon {
+		var numPoints uint32
+		if err := binary.Read(r, byteOrder, &numPoints); err != nil {
+			return err
+		}
+
+		ring := make([]Point, numPoints)
+		if err := binary.Read(r, byteOrder, ring); err != nil {
+			return err
+		}
+
+		polygon[i] = ring
+	}
+
+	*p = polygon
+	return nil
+}
+
+func (p Polygon) Value() (driver.Value, error) {
+	return p.String(), nil
+}
@@ -1,19 +1,51 @@
 package images
 
 import (
-	"fmt"
-
-	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/blobs"
+	"go.coaster.io/server/common/models"
 )
 
-func GetGcsImageUrl(storageID string) string {
-	var bucketName string
-	if application.IsProd() {
-		bucketName = "user-images-bucket-us"
-	} else {
-		bucketName = "dev-user-images-bucket"
+func GetImageUrl(storageID string) string {
+	return blobs.GetPublicURL(storageID)
+}
+
+// Returns the variant with the given name and format, if the image has one
+func FindVariant(variants models.ImageVariants, name models.ImageVariantName, format models.ImageFormat) *models.ImageVariant {
+	for _, variant := range variants {
+		if variant.Name == name && variant.Format == format {
+			return &variant
+		}
+	}
+
+	return nil
+}
+
+// Images uploaded before variants existed only have the original, so fall back to it
+func GetVariantUrl(image models.ListingImage, name models.ImageVariantName, format models.ImageFormat) string {
+	variant := FindVariant(image.Variants, name, format)
+	if variant == nil {
+		return GetImageUrl(GetStillStorageID(image))
+	}
+
+	return GetImageUrl(variant.StorageID)
+}
+
+// The full size still for a gallery item, which is the poster frame for videos
+func GetStillStorageID(image models.ListingImage) string {
+	if image.PosterStorageID != nil {
+		return *image.PosterStorageID
+	}
+
+	return image.StorageID
+}
+
+// Points the variants at the storage objects for a copy of the original stored under a new ID
+func RenameVariants(variants models.ImageVariants, storageID string) models.ImageVariants {
+	renamed := make(models.ImageVariants, len(variants))
+	for i, variant := range variants {
+		renamed[i] = variant
+		renamed[i].StorageID = GetVariantStorageID(storageID, variant.Name, variant.Format)
 	}
 
-	// TODO: put images.trycoaster.com here for Prod to get CDN
-	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucketName, storageID)
+	return renamed
 }
@@ -0,0 +1,177 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// Shown while the real image loads. The blurhash is decoded into a blurry preview by the frontend,
+// and the dominant color fills the space until that's ready.
+type Placeholder struct {
+	Blurhash      string
+	DominantColor string // Hex color like #a1b2c3
+}
+
+// 4x3 components is the blurhash default and looks right for the landscape photos most listings use
+const BLURHASH_X_COMPONENTS = 4
+const BLURHASH_Y_COMPONENTS = 3
+
+// Placeholders are blurry anyway, so they're computed from a small copy to keep this cheap
+const PLACEHOLDER_SAMPLE_SIZE = 64
+
+const BASE83_CHARACTERS = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+func GetPlaceholder(img image.Image) Placeholder {
+	sample := imaging.Fit(img, PLACEHOLDER_SAMPLE_SIZE, PLACEHOLDER_SAMPLE_SIZE, imaging.Box)
+	return Placeholder{
+		Blurhash:      encodeBlurhash(sample, BLURHASH_X_COMPONENTS, BLURHASH_Y_COMPONENTS),
+		DominantColor: getDominantColor(sample),
+	}
+}
+
+// Follows the reference implementation at https://github.com/woltapp/blurhash
+func encodeBlurhash(img *image.NRGBA, xComponents int, yComponents int) string {
+	width := img.Bounds().Dx()
+	height := img.Bounds().Dy()
+
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalization := 2.0
+			if i == 0 && j == 0 {
+				normalization = 1.0
+			}
+
+			var r, g, b float64
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(width)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(height))
+					pixel := img.NRGBAAt(x, y)
+					r += basis * srgbToLinear(pixel.R)
+					g += basis * srgbToLinear(pixel.G)
+					b += basis * srgbToLinear(pixel.B)
+				}
+			}
+
+			scale := normalization / float64(width*height)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encodeBase83((xComponents-1)+(yComponents-1)*9, 1))
+
+	dc := factors[0]
+	ac := factors[1:]
+
+	maximumValue := 1.0
+	if len(ac) > 0 {
+		actualMaximum := 0.0
+		for _, factor := range ac {
+			for _, component := range factor {
+				actualMaximum = math.Max(actualMaximum, math.Abs(component))
+			}
+		}
+
+		quantizedMaximum := int(math.Max(0, math.Min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantizedMaximum+1) / 166
+		hash.WriteString(encodeBase83(quantizedMaximum, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	hash.WriteString(encodeBase83(encodeDC(dc), 4))
+	for _, factor := range ac {
+		hash.WriteString(encodeBase83(encodeAC(factor, maximumValue), 2))
+	}
+
+	return hash.String()
+}
+
+func encodeDC(value [3]float64) int {
+	return linearToSrgb(value[0])<<16 + linearToSrgb(value[1])<<8 + linearToSrgb(value[2])
+}
+
+func encodeAC(value [3]float64, maximumValue float64) int {
+	quantize := func(component float64) int {
+		return int(math.Max(0, math.Min(18, math.Floor(signPow(component/maximumValue, 0.5)*9+9.5))))
+	}
+
+	return quantize(value[0])*19*19 + quantize(value[1])*19 + quantize(value[2])
+}
+
+func encodeBase83(value int, length int) string {
+	var encoded strings.Builder
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		encoded.WriteByte(BASE83_CHARACTERS[digit])
+	}
+
+	return encoded.String()
+}
+
+func srgbToLinear(value uint8) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(value float64, exponent float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exponent), value)
+}
+
+// Buckets pixels into a coarse palette and averages the most common bucket. A plain average of
+// every pixel tends to come out as a muddy brown for photos with a few strong colors.
+func getDominantColor(img *image.NRGBA) string {
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+
+	buckets := make(map[int]*bucket)
+	var dominant *bucket
+	for y := 0; y < img.Bounds().Dy(); y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			pixel := img.NRGBAAt(x, y)
+			key := int(pixel.R>>4)<<8 | int(pixel.G>>4)<<4 | int(pixel.B>>4)
+
+			current, ok := buckets[key]
+			if !ok {
+				current = &bucket{}
+				buckets[key] = current
+			}
+
+			current.count++
+			current.r += int(pixel.R)
+			current.g += int(pixel.G)
+			current.b += int(pixel.B)
+
+			if dominant == nil || current.count > dominant.count {
+				dominant = current
+			}
+		}
+	}
+
+	if dominant == nil {
+		return "#000000"
+	}
+
+	return fmt.Sprintf("#%02x%02x%02x", dominant.r/dominant.count, dominant.g/dominant.count, dominant.b/dominant.count)
+}
@@ -0,0 +1,80 @@
+package images_test
+
+import (
+	"image"
+	"image/color"
+
+	"go.coaster.io/server/common/images"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Images at or under the sample size are encoded as is, so these hashes don't depend on resampling
+func newImage(width int, height int, pixel func(x int, y int) color.NRGBA) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, pixel(x, y))
+		}
+	}
+
+	return img
+}
+
+var _ = Describe("Placeholders", func() {
+	// Expected hashes come from a port of the reference encoder at https://github.com/woltapp/blurhash
+	DescribeTable("blurhash matches the reference encoder",
+		func(width int, height int, pixel func(x int, y int) color.NRGBA, expected string) {
+			Expect(images.GetPlaceholder(newImage(width, height, pixel)).Blurhash).To(Equal(expected))
+		},
+		Entry("solid black", 8, 6, func(x int, y int) color.NRGBA {
+			return color.NRGBA{0, 0, 0, 255}
+		}, "L00000fQfQfQfQfQfQfQfQfQfQfQ"),
+		Entry("horizontal gradient", 32, 32, func(x int, y int) color.NRGBA {
+			v := uint8(x * 255 / 31)
+			return color.NRGBA{v, v, v, 255}
+		}, "L$HetW00xuWBofWBj[fQfQfQfQfQ"),
+		Entry("red over blue", 32, 32, func(x int, y int) color.NRGBA {
+			if y < 16 {
+				return color.NRGBA{255, 0, 0, 255}
+			}
+			return color.NRGBA{0, 0, 255, 255}
+		}, "L~LjfLo3fQo3|Tn~fQn~sRjsfQjs"),
+		Entry("two dimensional gradient", 32, 32, func(x int, y int) color.NRGBA {
+			return color.NRGBA{uint8(x * 8), uint8(y * 8), 128, 255}
+		}, "LxH2cX2swxX8l}WDjte;gJfjfQfj"),
+	)
+
+	// The AC components of a solid color are only rounding noise, so just check the size flag and DC
+	// component, which is the average color as base 83 encoded sRGB
+	DescribeTable("blurhash encodes the average color",
+		func(c color.NRGBA, expectedDC string) {
+			blurhash := images.GetPlaceholder(newImage(8, 6, func(x int, y int) color.NRGBA { return c })).Blurhash
+			Expect(blurhash).To(HaveLen(28))
+			Expect(blurhash[0:1]).To(Equal("L"))
+			Expect(blurhash[2:6]).To(Equal(expectedDC))
+		},
+		Entry("white", color.NRGBA{255, 255, 255, 255}, "TSUA"),
+		Entry("purple", color.NRGBA{0x7f, 0x3f, 0xbf, 255}, "Emin"),
+	)
+
+	It("uses the most common color as the dominant color", func() {
+		img := newImage(10, 10, func(x int, y int) color.NRGBA {
+			if x < 3 {
+				return color.NRGBA{255, 255, 255, 255}
+			}
+			return color.NRGBA{0x12, 0x34, 0x56, 255}
+		})
+
+		Expect(images.GetPlaceholder(img).DominantColor).To(Equal("#123456"))
+	})
+
+	It("computes placeholders for images larger than the sample size", func() {
+		img := newImage(640, 480, func(x int, y int) color.NRGBA {
+			return color.NRGBA{0, 0, 0, 255}
+		})
+
+		Expect(images.GetPlaceholder(img).Blurhash).To(Equal("L00000fQfQfQfQfQfQfQfQfQfQfQ"))
+	})
+})
@@ -0,0 +1,243 @@
+package images
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+
+	"github.com/disintegration/imaging"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+)
+
+type VariantSpec struct {
+	Name     models.ImageVariantName
+	MaxWidth int
+}
+
+// Sized for search result thumbnails, listing cards and full width hero images on high density screens
+var VARIANT_SPECS = []VariantSpec{
+	{Name: models.ImageVariantThumbnail, MaxWidth: 320},
+	{Name: models.ImageVariantCard, MaxWidth: 800},
+	{Name: models.ImageVariantHero, MaxWidth: 1920},
+}
+
+// Originals are kept as a full quality fallback, but there's no use for anything larger than this
+const MAX_ORIGINAL_DIMENSION = 4096
+
+// Uploads are decoded in full before they're resized, so this bounds the memory a single image can
+// use (about 200MB at 4 bytes per pixel). Big enough for photos from 48 megapixel phone cameras.
+const MAX_DECODED_PIXELS = 50_000_000
+
+const JPEG_QUALITY = 82
+const WEBP_QUALITY = 78
+
+// Hard limit on each cwebp run, so a stuck encode can't hold up a request for good. The largest
+// input is the hero variant, which takes well under a second normally.
+const ENCODE_WEBP_TIMEOUT = 30 * time.Second
+
+var CONTENT_TYPES = map[models.ImageFormat]string{
+	models.ImageFormatJPEG: "image/jpeg",
+	models.ImageFormatWebP: "image/webp",
+}
+
+type EncodedImage struct {
+	Format models.ImageFormat
+	Width  int
+	Height int
+	Data   []byte
+}
+
+type EncodedVariant struct {
+	Name models.ImageVariantName
+	EncodedImage
+}
+
+type ProcessedImage struct {
+	Original    EncodedImage
+	Variants    []EncodedVariant
+	Placeholder Placeholder
+}
+
+// Decodes an upload, rotates it upright based on its EXIF orientation and re-encodes it along with
+// the resized variants. Re-encoding drops all metadata, including any GPS location from the camera.
+func Process(ctx context.Context, reader io.Reader) (*ProcessedImage, error) {
+	img, err := Decode(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "(images.Process)")
+	}
+
+	return ProcessDecoded(ctx, img)
+}
+
+// Decodes an upload and rotates it upright based on its EXIF orientation. The dimensions in the header
+// are checked first so a small file that claims to be enormous is rejected before anything is allocated.
+func Decode(reader io.Reader) (image.Image, error) {
+	// Keep the bytes read for the header so the full decode can start from the beginning again
+	var header bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(reader, &header))
+	if err != nil {
+		return nil, errors.NewBadRequest("The image could not be read")
+	}
+
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > MAX_DECODED_PIXELS {
+		return nil, errors.NewBadRequestf("Images can be at most %d megapixels", MAX_DECODED_PIXELS/1_000_000)
+	}
+
+	img, err := imaging.Decode(io.MultiReader(&header, reader), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, errors.NewBadRequest("The image could not be read")
+	}
+
+	return img, nil
+}
+
+// Same as Process, for images that are already decoded like video poster frames
+func ProcessDecoded(ctx context.Context, img image.Image) (*ProcessedImage, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() > MAX_ORIGINAL_DIMENSION || bounds.Dy() > MAX_ORIGINAL_DIMENSION {
+		img = imaging.Fit(img, MAX_ORIGINAL_DIMENSION, MAX_ORIGINAL_DIMENSION, imaging.Lanczos)
+	}
+
+	// JPEG has no transparency, so flatten images like PNG logos onto white instead of black
+	img = imaging.OverlayCenter(imaging.New(img.Bounds().Dx(), img.Bounds().Dy(), color.White), img, 1.0)
+
+	original, err := encode(ctx, img, models.ImageFormatJPEG)
+	if err != nil {
+		return nil, errors.Wrap(err, "(images.ProcessDecoded) encoding original")
+	}
+
+	formats := []models.ImageFormat{models.ImageFormatJPEG}
+	if isWebPSupported() {
+		formats = append(formats, models.ImageFormatWebP)
+	}
+
+	var variants []EncodedVariant
+	for _, spec := range VARIANT_SPECS {
+		// Never upscale, small uploads just get variants at their original size
+		resized := img
+		if img.Bounds().Dx() > spec.MaxWidth {
+			resized = imaging.Resize(img, spec.MaxWidth, 0, imaging.Lanczos)
+		}
+
+		for _, format := range formats {
+			encoded, err := encode(ctx, resized, format)
+			if err != nil {
+				return nil, errors.Wrapf(err, "(images.ProcessDecoded) encoding %s %s variant", spec.Name, format)
+			}
+
+			variants = append(variants, EncodedVariant{Name: spec.Name, EncodedImage: *encoded})
+		}
+	}
+
+	return &ProcessedImage{
+		Original:    *original,
+		Variants:    variants,
+		Placeholder: GetPlaceholder(img),
+	}, nil
+}
+
+// Variants are stored next to the original so they can be found from its storage ID
+func GetVariantStorageID(storageID string, name models.ImageVariantName, format models.ImageFormat) string {
+	return fmt.Sprintf("%s_%s.%s", storageID, name, format)
+}
+
+func encode(ctx context.Context, img image.Image, format models.ImageFormat) (*EncodedImage, error) {
+	var data []byte
+	var err error
+	switch format {
+	case models.ImageFormatJPEG:
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEG_QUALITY})
+		data = buf.Bytes()
+	case models.ImageFormatWebP:
+		data, err = encodeWebP(ctx, img)
+	default:
+		err = errors.Newf("(images.encode) unsupported format %s", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &EncodedImage{
+		Format: format,
+		Width:  img.Bounds().Dx(),
+		Height: img.Bounds().Dy(),
+		Data:   data,
+	}, nil
+}
+
+var webPSupported bool
+var checkWebPSupport sync.Once
+
+// There's no pure Go WebP encoder, so WebP variants depend on cwebp being installed. Without it
+// (e.g. local development) only JPEG variants are generated.
+func isWebPSupported() bool {
+	checkWebPSupport.Do(func() {
+		_, err := exec.LookPath("cwebp")
+		webPSupported = err == nil
+		if !webPSupported {
+			log.Printf("(images.isWebPSupported) cwebp not found, skipping WebP variants")
+		}
+	})
+
+	return webPSupported
+}
+
+func encodeWebP(ctx context.Context, img image.Image) ([]byte, error) {
+	input, err := os.CreateTemp("", "image-*.png")
+	if err != nil {
+		return nil, errors.Wrap(err, "(images.encodeWebP) creating input file")
+	}
+	defer os.Remove(input.Name())
+	defer input.Close()
+
+	// PNG is lossless, so the only lossy step is the WebP encoding itself
+	err = (&png.Encoder{CompressionLevel: png.NoCompression}).Encode(input, img)
+	if err != nil {
+		return nil, errors.Wrap(err, "(images.encodeWebP) writing input file")
+	}
+
+	output, err := os.CreateTemp("", "image-*.webp")
+	if err != nil {
+		return nil, errors.Wrap(err, "(images.encodeWebP) creating output file")
+	}
+	output.Close()
+	defer os.Remove(output.Name())
+
+	ctx, cancel := context.WithTimeout(ctx, ENCODE_WEBP_TIMEOUT)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "cwebp", "-quiet", "-metadata", "none", "-q", strconv.Itoa(WEBP_QUALITY), input.Name(), "-o", output.Name())
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(ctx.Err(), "(images.encodeWebP) running cwebp")
+		}
+
+		return nil, errors.Wrapf(err, "(images.encodeWebP) running cwebp: %s", string(out))
+	}
+
+	data, err := os.ReadFile(output.Name())
+	if err != nil {
+		return nil, errors.Wrap(err, "(images.encodeWebP) reading output file")
+	}
+
+	return data, nil
+}
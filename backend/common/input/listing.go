@@ -11,6 +11,7 @@ type Listing struct {
 	Price               *int64                      `json:"price"`
 	Location            *string                     `json:"location"`
 	Coordinates         *geo.Point                  `json:"coordinates"`
+	ServiceArea         *geo.Polygon                `json:"service_area"` // An empty polygon removes the service area
 	PlaceID             *string                     `json:"place_id"`
 	City                *string                     `json:"city"`
 	Region              *string                     `json:"region"`
@@ -18,14 +19,15 @@ type Listing struct {
 	PostalCode          *string                     `json:"postal_code"`
 	Status              *models.ListingStatus       `json:"status"`
 	ShortDescription    *string                     `json:"short_description"`
-	Cancellation        *models.ListingCancellation `json:"cancellation"`
+	Cancellation        *models.ListingCancellation `json:"cancellation" validate:"omitempty,oneof=flexible moderate strict"`
 	DurationMinutes     *int64                      `json:"duration_minutes"`
 	MaxGuests           *int64                      `json:"max_guests"`
 	Highlights          []string                    `json:"highlights"`
 	Includes            []string                    `json:"includes"`
 	NotIncluded         []string                    `json:"not_included"`
-	AvailabilityType    *models.AvailabilityType    `json:"availability_type"`
-	AvailabilityDisplay *models.AvailabilityDisplay `json:"availability_display"`
+	AvailabilityType    *models.AvailabilityType    `json:"availability_type" validate:"omitempty,oneof=date datetime"`
+	AvailabilityDisplay *models.AvailabilityDisplay `json:"availability_display" validate:"omitempty,oneof=calendar list"`
+	SourceLocale        *string                     `json:"source_locale"`
 
 	Categories []models.ListingCategoryType `json:"categories"`
 }
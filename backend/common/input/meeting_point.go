@@ -0,0 +1,10 @@
+package input
+
+import "go.coaster.io/server/common/geo"
+
+type MeetingPoint struct {
+	ID           *int64     `json:"id"`
+	Name         *string    `json:"name" validate:"omitempty,min=1,max=128"`
+	Instructions *string    `json:"instructions"`
+	Coordinates  *geo.Point `json:"coordinates"`
+}
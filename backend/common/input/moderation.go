@@ -0,0 +1,8 @@
+package input
+
+import "go.coaster.io/server/common/models"
+
+type ListingRejection struct {
+	Reasons  []models.RejectionReason `json:"reasons" validate:"required,min=1,dive,oneof=incomplete_information low_quality_images inaccurate_description pricing policy_violation duplicate other"`
+	Feedback *string                  `json:"feedback" validate:"omitempty,max=4096"`
+}
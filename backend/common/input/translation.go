@@ -0,0 +1,16 @@
+package input
+
+type ListingTranslation struct {
+	Name             *string                    `json:"name" validate:"omitempty,max=255"`
+	Description      *string                    `json:"description"`
+	ShortDescription *string                    `json:"short_description" validate:"omitempty,max=1024"`
+	Highlights       []string                   `json:"highlights" validate:"omitempty,dive,max=160"`
+	ItinerarySteps   []ItineraryStepTranslation `json:"itinerary_steps" validate:"dive"`
+}
+
+type ItineraryStepTranslation struct {
+	ID          int64   `json:"id" validate:"required"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	StepLabel   *string `json:"step_label"`
+}
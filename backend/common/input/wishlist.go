@@ -0,0 +1,14 @@
+package input
+
+type Wishlist struct {
+	Name string `json:"name" validate:"required,min=1,max=128"`
+}
+
+type WishlistUpdates struct {
+	Name   *string `json:"name,omitempty" validate:"omitempty,min=1,max=128"`
+	Shared *bool   `json:"shared,omitempty"`
+}
+
+type WishlistListing struct {
+	ListingID int64 `json:"listing_id" validate:"required"`
+}
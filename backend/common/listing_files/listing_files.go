@@ -0,0 +1,334 @@
+package listing_files
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatJSON Format = "json"
+)
+
+// Columns for CSV files, in the order they're exported. Imports can use any subset in any order.
+var CSV_COLUMNS = []string{
+	"name",
+	"short_description",
+	"description",
+	"price",
+	"location",
+	"cancellation",
+	"duration_minutes",
+	"max_guests",
+	"highlights",
+	"includes",
+	"not_included",
+	"categories",
+	"availability_type",
+	"availability_display",
+	"source_locale",
+}
+
+// Separates the items of list columns like highlights within a single CSV cell
+const CSV_LIST_SEPARATOR = "|"
+
+// A listing parsed from an import file. Rows that couldn't be parsed have an error instead.
+type Row struct {
+	Number  int // 1-based, not counting the CSV header
+	Listing input.Listing
+	Error   error
+}
+
+// Listing exported in the same shape that's accepted for imports, so files can be round tripped
+type ExportedListing struct {
+	Name                *string                      `json:"name"`
+	ShortDescription    *string                      `json:"short_description"`
+	Description         *string                      `json:"description"`
+	Price               *int64                       `json:"price"`
+	Location            *string                      `json:"location"`
+	Cancellation        models.ListingCancellation   `json:"cancellation"`
+	DurationMinutes     *int64                       `json:"duration_minutes"`
+	MaxGuests           *int64                       `json:"max_guests"`
+	Highlights          []string                     `json:"highlights"`
+	Includes            []string                     `json:"includes"`
+	NotIncluded         []string                     `json:"not_included"`
+	Categories          []models.ListingCategoryType `json:"categories"`
+	AvailabilityType    models.AvailabilityType      `json:"availability_type"`
+	AvailabilityDisplay models.AvailabilityDisplay   `json:"availability_display"`
+	SourceLocale        string                       `json:"source_locale"`
+}
+
+func ParseFormat(format string) (Format, error) {
+	switch Format(strings.ToLower(format)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", errors.NewBadRequestf("Unsupported file format: %s", format)
+	}
+}
+
+// Parses every row in the file. Problems with the file as a whole are returned as an error, while
+// problems with individual rows are set on the row so the rest of the file can still be checked.
+func Parse(format Format, reader io.Reader) ([]Row, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(reader)
+	case FormatJSON:
+		return parseJSON(reader)
+	default:
+		return nil, errors.Newf("(listing_files.Parse) unsupported format %s", format)
+	}
+}
+
+func Write(format Format, writer io.Writer, listingDetails []listings.ListingDetails) error {
+	exportedListings := make([]ExportedListing, len(listingDetails))
+	for i, listing := range listingDetails {
+		exportedListings[i] = convertListing(listing)
+	}
+
+	switch format {
+	case FormatCSV:
+		return writeCSV(writer, exportedListings)
+	case FormatJSON:
+		return json.NewEncoder(writer).Encode(exportedListings)
+	default:
+		return errors.Newf("(listing_files.Write) unsupported format %s", format)
+	}
+}
+
+func parseCSV(reader io.Reader) ([]Row, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.NewBadRequest("The file is empty")
+		}
+
+		return nil, errors.NewBadRequest("The file is not a valid CSV")
+	}
+
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(CSV_COLUMNS, header[i]) {
+			return nil, errors.NewBadRequestf("Unknown column: %s", column)
+		}
+	}
+
+	rows := []Row{}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		row := Row{Number: len(rows) + 1}
+		if err != nil {
+			row.Error = errors.NewBadRequest("The row is not valid CSV")
+		} else if len(record) != len(header) {
+			row.Error = errors.NewBadRequestf("Expected %d columns but found %d", len(header), len(record))
+		} else {
+			row.Listing, row.Error = parseCSVRecord(header, record)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func parseCSVRecord(header []string, record []string) (input.Listing, error) {
+	var listing input.Listing
+	for i, column := range header {
+		value := strings.TrimSpace(record[i])
+		if len(value) == 0 {
+			continue
+		}
+
+		var err error
+		switch column {
+		case "name":
+			listing.Name = &value
+		case "short_description":
+			listing.ShortDescription = &value
+		case "description":
+			listing.Description = &value
+		case "price":
+			listing.Price, err = parseCSVInt(column, value)
+		case "location":
+			listing.Location = &value
+		case "cancellation":
+			cancellation := models.ListingCancellation(value)
+			listing.Cancellation = &cancellation
+		case "duration_minutes":
+			listing.DurationMinutes, err = parseCSVInt(column, value)
+		case "max_guests":
+			listing.MaxGuests, err = parseCSVInt(column, value)
+		case "highlights":
+			listing.Highlights = parseCSVList(value)
+		case "includes":
+			listing.Includes = parseCSVList(value)
+		case "not_included":
+			listing.NotIncluded = parseCSVList(value)
+		case "categories":
+			for _, category := range parseCSVList(value) {
+				listing.Categories = append(listing.Categories, models.ListingCategoryType(category))
+			}
+		case "availability_type":
+			availabilityType := models.AvailabilityType(value)
+			listing.AvailabilityType = &availabilityType
+		case "availability_display":
+			availabilityDisplay := models.AvailabilityDisplay(value)
+			listing.AvailabilityDisplay = &availabilityDisplay
+		case "source_locale":
+			listing.SourceLocale = &value
+		}
+
+		if err != nil {
+			return input.Listing{}, err
+		}
+	}
+
+	return listing, nil
+}
+
+func parseCSVInt(column string, value string) (*int64, error) {
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, errors.NewBadRequestf("%s must be a whole number", column)
+	}
+
+	return &parsed, nil
+}
+
+func parseCSVList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, CSV_LIST_SEPARATOR) {
+		item = strings.TrimSpace(item)
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func parseJSON(reader io.Reader) ([]Row, error) {
+	// Decode each row separately so one malformed listing doesn't hide problems with the rest
+	var records []json.RawMessage
+	err := json.NewDecoder(reader).Decode(&records)
+	if err != nil {
+		return nil, errors.NewBadRequest("The file must contain a JSON array of listings")
+	}
+
+	rows := make([]Row, len(records))
+	for i, record := range records {
+		rows[i] = Row{Number: i + 1}
+
+		decoder := json.NewDecoder(strings.NewReader(string(record)))
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&rows[i].Listing)
+		if err != nil {
+			rows[i].Error = errors.NewBadRequestf("The listing is not valid: %s", err.Error())
+		}
+	}
+
+	return rows, nil
+}
+
+func writeCSV(writer io.Writer, exportedListings []ExportedListing) error {
+	csvWriter := csv.NewWriter(writer)
+	err := csvWriter.Write(CSV_COLUMNS)
+	if err != nil {
+		return errors.Wrap(err, "(listing_files.writeCSV) writing header")
+	}
+
+	for _, listing := range exportedListings {
+		categories := make([]string, len(listing.Categories))
+		for i, category := range listing.Categories {
+			categories[i] = string(category)
+		}
+
+		err := csvWriter.Write([]string{
+			formatCSVString(listing.Name),
+			formatCSVString(listing.ShortDescription),
+			formatCSVString(listing.Description),
+			formatCSVInt(listing.Price),
+			formatCSVString(listing.Location),
+			string(listing.Cancellation),
+			formatCSVInt(listing.DurationMinutes),
+			formatCSVInt(listing.MaxGuests),
+			strings.Join(listing.Highlights, CSV_LIST_SEPARATOR),
+			strings.Join(listing.Includes, CSV_LIST_SEPARATOR),
+			strings.Join(listing.NotIncluded, CSV_LIST_SEPARATOR),
+			strings.Join(categories, CSV_LIST_SEPARATOR),
+			string(listing.AvailabilityType),
+			string(listing.AvailabilityDisplay),
+			listing.SourceLocale,
+		})
+		if err != nil {
+			return errors.Wrap(err, "(listing_files.writeCSV) writing row")
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func formatCSVString(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
+
+func formatCSVInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.FormatInt(*value, 10)
+}
+
+func convertListing(listing listings.ListingDetails) ExportedListing {
+	// Special categories are managed by admins, so they can't be imported
+	categories := []models.ListingCategoryType{}
+	for _, category := range listing.Categories {
+		if !slices.Contains(models.SPECIAL_CATEGORIES, category.Category) {
+			categories = append(categories, category.Category)
+		}
+	}
+
+	return ExportedListing{
+		Name:                listing.Name,
+		ShortDescription:    listing.ShortDescription,
+		Description:         listing.Description,
+		Price:               listing.Price,
+		Location:            listing.Location,
+		Cancellation:        listing.Cancellation,
+		DurationMinutes:     listing.DurationMinutes,
+		MaxGuests:           listing.MaxGuests,
+		Highlights:          listing.Highlights,
+		Includes:            listing.Includes,
+		NotIncluded:         listing.NotIncluded,
+		Categories:          categories,
+		AvailabilityType:    listing.AvailabilityType,
+		AvailabilityDisplay: listing.AvailabilityDisplay,
+		SourceLocale:        listing.SourceLocale,
+	}
+}
@@ -0,0 +1,13 @@
+package listing_files_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestListingFiles(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Listing Files Suite")
+}
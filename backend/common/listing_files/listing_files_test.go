@@ -0,0 +1,118 @@
+package listing_files_test
+
+import (
+	"strings"
+
+	"go.coaster.io/server/common/listing_files"
+	"go.coaster.io/server/common/models"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Listing files", func() {
+	Describe("Parse CSV", func() {
+		parse := func(lines ...string) ([]listing_files.Row, error) {
+			return listing_files.Parse(listing_files.FormatCSV, strings.NewReader(strings.Join(lines, "\n")))
+		}
+
+		It("parses every column", func() {
+			rows, err := parse(
+				strings.Join(listing_files.CSV_COLUMNS, ","),
+				`Dawn Patrol,Early surf,"Catch the first waves, with coffee",12000,"Santa Cruz, CA",flexible,180,6,Coffee|Wetsuit,Board|Lesson,Lunch,surfing|outdoors,date,calendar,en`,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rows).To(HaveLen(1))
+
+			row := rows[0]
+			Expect(row.Number).To(Equal(1))
+			Expect(row.Error).NotTo(HaveOccurred())
+			Expect(*row.Listing.Name).To(Equal("Dawn Patrol"))
+			Expect(*row.Listing.ShortDescription).To(Equal("Early surf"))
+			Expect(*row.Listing.Description).To(Equal("Catch the first waves, with coffee"))
+			Expect(*row.Listing.Price).To(Equal(int64(12000)))
+			Expect(*row.Listing.Location).To(Equal("Santa Cruz, CA"))
+			Expect(*row.Listing.Cancellation).To(Equal(models.ListingCancellationFlexible))
+			Expect(*row.Listing.DurationMinutes).To(Equal(int64(180)))
+			Expect(*row.Listing.MaxGuests).To(Equal(int64(6)))
+			Expect(row.Listing.Highlights).To(Equal([]string{"Coffee", "Wetsuit"}))
+			Expect(row.Listing.Includes).To(Equal([]string{"Board", "Lesson"}))
+			Expect(row.Listing.NotIncluded).To(Equal([]string{"Lunch"}))
+			Expect(row.Listing.Categories).To(Equal([]models.ListingCategoryType{models.CategorySurfing, models.CategoryOutdoors}))
+			Expect(*row.Listing.AvailabilityType).To(Equal(models.AvailabilityTypeDate))
+			Expect(*row.Listing.AvailabilityDisplay).To(Equal(models.AvailabilityDisplayCalendar))
+			Expect(*row.Listing.SourceLocale).To(Equal("en"))
+		})
+
+		It("accepts a subset of columns in any order, ignoring header case and spacing", func() {
+			rows, err := parse(
+				" Price , NAME",
+				"5000,Sunset Paddle",
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rows).To(HaveLen(1))
+			Expect(rows[0].Error).NotTo(HaveOccurred())
+			Expect(*rows[0].Listing.Name).To(Equal("Sunset Paddle"))
+			Expect(*rows[0].Listing.Price).To(Equal(int64(5000)))
+		})
+
+		It("leaves empty cells unset and drops empty list items", func() {
+			rows, err := parse(
+				"name,description,highlights",
+				"Sunset Paddle,  , Views || Snacks |",
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rows[0].Listing.Description).To(BeNil())
+			Expect(rows[0].Listing.Highlights).To(Equal([]string{"Views", "Snacks"}))
+		})
+
+		It("rejects unknown columns", func() {
+			_, err := parse("name,colour", "Sunset Paddle,blue")
+			Expect(err).To(MatchError(ContainSubstring("Unknown column: colour")))
+		})
+
+		It("rejects an empty file", func() {
+			_, err := parse("")
+			Expect(err).To(MatchError(ContainSubstring("The file is empty")))
+		})
+
+		It("returns no rows for a file with only a header", func() {
+			rows, err := parse("name,price")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rows).To(BeEmpty())
+		})
+
+		It("keeps parsing after a row with a bad number", func() {
+			rows, err := parse(
+				"name,price",
+				"Sunset Paddle,cheap",
+				"Dawn Patrol,12000",
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rows).To(HaveLen(2))
+			Expect(rows[0].Error).To(MatchError(ContainSubstring("price must be a whole number")))
+			Expect(rows[1].Number).To(Equal(2))
+			Expect(rows[1].Error).NotTo(HaveOccurred())
+			Expect(*rows[1].Listing.Price).To(Equal(int64(12000)))
+		})
+
+		It("flags rows with the wrong number of columns", func() {
+			rows, err := parse(
+				"name,price",
+				"Sunset Paddle,5000,extra",
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rows[0].Error).To(MatchError(ContainSubstring("Expected 2 columns but found 3")))
+		})
+
+		It("flags rows that aren't valid CSV", func() {
+			rows, err := parse(
+				"name,price",
+				`"Sunset "Paddle,5000`,
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rows).To(HaveLen(1))
+			Expect(rows[0].Error).To(MatchError(ContainSubstring("The row is not valid CSV")))
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package locales
+
+import (
+	"go.coaster.io/server/common/errors"
+	"golang.org/x/text/language"
+)
+
+// Listings without an explicit source locale were written in English
+const DEFAULT_LOCALE = "en"
+
+// Canonicalizes a BCP 47 locale such as "pt-br" to "pt-BR" so translations are stored consistently
+func Normalize(locale string) (string, error) {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return "", errors.NewBadRequestf("Invalid locale: %s", locale)
+	}
+
+	return tag.String(), nil
+}
+
+// Parses the locales a client asked for in order of preference. An explicit locale takes priority
+// over the Accept-Language header, and anything unparseable is ignored.
+func ParseRequested(localeParam string, acceptLanguage string) []language.Tag {
+	var requested []language.Tag
+	if len(localeParam) > 0 {
+		tag, err := language.Parse(localeParam)
+		if err == nil {
+			requested = append(requested, tag)
+		}
+	}
+
+	if len(acceptLanguage) > 0 {
+		tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+		if err == nil {
+			requested = append(requested, tags...)
+		}
+	}
+
+	return requested
+}
+
+// Picks the best of the available locales for the request. The first available locale is the
+// source language and is used whenever nothing else is a reasonable match.
+func Match(requested []language.Tag, available []string) string {
+	if len(available) == 0 {
+		return DEFAULT_LOCALE
+	}
+
+	if len(requested) == 0 || len(available) == 1 {
+		return available[0]
+	}
+
+	supported := make([]language.Tag, len(available))
+	for i, locale := range available {
+		supported[i] = language.Make(locale)
+	}
+
+	_, index, confidence := language.NewMatcher(supported).Match(requested...)
+	if confidence == language.No {
+		return available[0]
+	}
+
+	return available[index]
+}
@@ -0,0 +1,13 @@
+package locales_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestLocales(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Locales Suite")
+}
@@ -0,0 +1,63 @@
+package locales_test
+
+import (
+	"go.coaster.io/server/common/locales"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locales", func() {
+	Describe("Normalize", func() {
+		It("canonicalizes the casing of region subtags", func() {
+			Expect(locales.Normalize("pt-br")).To(Equal("pt-BR"))
+		})
+
+		It("rejects locales that aren't BCP 47", func() {
+			_, err := locales.Normalize("not a locale")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Match", func() {
+		match := func(localeParam string, acceptLanguage string, available ...string) string {
+			return locales.Match(locales.ParseRequested(localeParam, acceptLanguage), available)
+		}
+
+		It("falls back to the default locale when there are no locales", func() {
+			Expect(match("", "fr")).To(Equal(locales.DEFAULT_LOCALE))
+		})
+
+		It("uses the source locale when nothing was requested", func() {
+			Expect(match("", "", "fr", "en")).To(Equal("fr"))
+		})
+
+		It("uses the source locale when nothing requested is available", func() {
+			Expect(match("", "ja, ko;q=0.8", "fr", "en")).To(Equal("fr"))
+		})
+
+		It("picks the highest weighted locale from Accept-Language", func() {
+			Expect(match("", "de;q=0.5, es, en;q=0.8", "en", "de", "es")).To(Equal("es"))
+		})
+
+		It("matches a regional request to the base language", func() {
+			Expect(match("", "es-MX", "en", "es")).To(Equal("es"))
+		})
+
+		It("matches the exact region when it's available", func() {
+			Expect(match("", "pt-BR", "en", "pt-PT", "pt-BR")).To(Equal("pt-BR"))
+		})
+
+		It("prefers the locale param over Accept-Language", func() {
+			Expect(match("de", "es", "en", "de", "es")).To(Equal("de"))
+		})
+
+		It("ignores an unparseable locale param", func() {
+			Expect(match("not a locale", "es", "en", "es")).To(Equal("es"))
+		})
+
+		It("ignores an unparseable Accept-Language header", func() {
+			Expect(match("", ";;;", "fr", "en")).To(Equal("fr"))
+		})
+	})
+})
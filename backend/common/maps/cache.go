@@ -0,0 +1,206 @@
+package maps
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/geo"
+	"go.coaster.io/server/common/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Entries older than this are refreshed from the wrapped geocoder
+const GEOCODE_CACHE_TTL = 30 * 24 * time.Hour
+
+// Places looked up by ID are stored in the query cache under this prefix
+const PLACE_ID_CACHE_PREFIX = "place_id::"
+
+// Matches the cache key columns. Longer keys skip the cache and always go to the wrapped geocoder.
+const MAX_CACHE_KEY_LENGTH = 256
+
+// Wraps another geocoder and stores its results in Postgres so repeated searches for the same place are free
+type CachingGeocoder struct {
+	db       *gorm.DB
+	geocoder Geocoder
+}
+
+func NewCachingGeocoder(db *gorm.DB, geocoder Geocoder) Geocoder {
+	return CachingGeocoder{
+		db:       db,
+		geocoder: geocoder,
+	}
+}
+
+func (g CachingGeocoder) GetPlaceFromQuery(query string) (*Place, error) {
+	normalizedQuery := normalizeQuery(query)
+	if !isCacheable(normalizedQuery) {
+		place, err := g.geocoder.GetPlaceFromQuery(query)
+		if err != nil {
+			return nil, errors.Wrap(err, "(maps.CachingGeocoder.GetPlaceFromQuery)")
+		}
+
+		return place, nil
+	}
+
+	var cacheEntry models.GeocodeCacheEntry
+	result := g.db.Table("geocode_cache_entries").
+		Select("geocode_cache_entries.*").
+		Where("geocode_cache_entries.query = ?", normalizedQuery).
+		Where("geocode_cache_entries.updated_at > ?", time.Now().Add(-GEOCODE_CACHE_TTL)).
+		Where("geocode_cache_entries.deactivated_at IS NULL").
+		Take(&cacheEntry)
+	if result.Error == nil {
+		return &Place{
+			Name:        cacheEntry.Name,
+			Coordinates: cacheEntry.Coordinates,
+			PlaceID:     cacheEntry.PlaceID,
+		}, nil
+	} else if !errors.IsRecordNotFound(result.Error) {
+		return nil, errors.Wrap(result.Error, "(maps.CachingGeocoder.GetPlaceFromQuery) loading cache entry")
+	}
+
+	place, err := g.geocoder.GetPlaceFromQuery(query)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.CachingGeocoder.GetPlaceFromQuery)")
+	}
+
+	err = g.savePlace(normalizedQuery, *place)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.CachingGeocoder.GetPlaceFromQuery) saving cache entry")
+	}
+
+	return place, nil
+}
+
+// Suggestions change with every keystroke, so they aren't worth caching
+func (g CachingGeocoder) Autocomplete(query string, sessionToken string) ([]PlaceSuggestion, error) {
+	suggestions, err := g.geocoder.Autocomplete(query, sessionToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.CachingGeocoder.Autocomplete)")
+	}
+
+	return suggestions, nil
+}
+
+// Places picked from autocomplete skip the cache lookup, since only this call ends the session and
+// without it every autocomplete request is billed separately
+func (g CachingGeocoder) GetPlace(placeID string, sessionToken string) (*Place, error) {
+	cacheKey := PLACE_ID_CACHE_PREFIX + placeID
+	if !isCacheable(cacheKey) {
+		place, err := g.geocoder.GetPlace(placeID, sessionToken)
+		if err != nil {
+			return nil, errors.Wrap(err, "(maps.CachingGeocoder.GetPlace)")
+		}
+
+		return place, nil
+	}
+
+	if len(sessionToken) == 0 {
+		var cacheEntry models.GeocodeCacheEntry
+		result := g.db.Table("geocode_cache_entries").
+			Select("geocode_cache_entries.*").
+			Where("geocode_cache_entries.query = ?", cacheKey).
+			Where("geocode_cache_entries.updated_at > ?", time.Now().Add(-GEOCODE_CACHE_TTL)).
+			Where("geocode_cache_entries.deactivated_at IS NULL").
+			Take(&cacheEntry)
+		if result.Error == nil {
+			return &Place{
+				Name:        cacheEntry.Name,
+				Coordinates: cacheEntry.Coordinates,
+				PlaceID:     cacheEntry.PlaceID,
+			}, nil
+		} else if !errors.IsRecordNotFound(result.Error) {
+			return nil, errors.Wrap(result.Error, "(maps.CachingGeocoder.GetPlace) loading cache entry")
+		}
+	}
+
+	place, err := g.geocoder.GetPlace(placeID, sessionToken)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.CachingGeocoder.GetPlace)")
+	}
+
+	err = g.savePlace(cacheKey, *place)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.CachingGeocoder.GetPlace) saving cache entry")
+	}
+
+	return place, nil
+}
+
+func (g CachingGeocoder) GetPlaceDetails(placeID string, coordinates geo.Point) (*PlaceDetails, error) {
+	if !isCacheable(placeID) {
+		placeDetails, err := g.geocoder.GetPlaceDetails(placeID, coordinates)
+		if err != nil {
+			return nil, errors.Wrap(err, "(maps.CachingGeocoder.GetPlaceDetails)")
+		}
+
+		return placeDetails, nil
+	}
+
+	var cacheEntry models.PlaceDetailsCacheEntry
+	result := g.db.Table("place_details_cache_entries").
+		Select("place_details_cache_entries.*").
+		Where("place_details_cache_entries.place_id = ?", placeID).
+		Where("place_details_cache_entries.updated_at > ?", time.Now().Add(-GEOCODE_CACHE_TTL)).
+		Where("place_details_cache_entries.deactivated_at IS NULL").
+		Take(&cacheEntry)
+	if result.Error == nil {
+		return &PlaceDetails{
+			City:       cacheEntry.City,
+			Region:     cacheEntry.Region,
+			Country:    cacheEntry.Country,
+			PostalCode: cacheEntry.PostalCode,
+		}, nil
+	} else if !errors.IsRecordNotFound(result.Error) {
+		return nil, errors.Wrap(result.Error, "(maps.CachingGeocoder.GetPlaceDetails) loading cache entry")
+	}
+
+	placeDetails, err := g.geocoder.GetPlaceDetails(placeID, coordinates)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.CachingGeocoder.GetPlaceDetails)")
+	}
+
+	result = g.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "place_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"city", "region", "country", "postal_code", "updated_at"}),
+	}).Create(&models.PlaceDetailsCacheEntry{
+		PlaceID:    placeID,
+		City:       placeDetails.City,
+		Region:     placeDetails.Region,
+		Country:    placeDetails.Country,
+		PostalCode: placeDetails.PostalCode,
+	})
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(maps.CachingGeocoder.GetPlaceDetails) saving cache entry")
+	}
+
+	return placeDetails, nil
+}
+
+func (g CachingGeocoder) savePlace(cacheKey string, place Place) error {
+	result := g.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "query"}},
+		DoUpdates: clause.AssignmentColumns([]string{"place_id", "name", "coordinates", "updated_at"}),
+	}).Create(&models.GeocodeCacheEntry{
+		Query:       cacheKey,
+		PlaceID:     place.PlaceID,
+		Name:        place.Name,
+		Coordinates: place.Coordinates,
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(maps.CachingGeocoder.savePlace)")
+	}
+
+	return nil
+}
+
+func isCacheable(cacheKey string) bool {
+	return utf8.RuneCountInString(cacheKey) <= MAX_CACHE_KEY_LENGTH
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
@@ -0,0 +1,61 @@
+package maps
+
+import (
+	"os"
+
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/geo"
+	"gorm.io/gorm"
+)
+
+type Place struct {
+	Name        string
+	Coordinates geo.Point
+	PlaceID     string
+}
+
+type PlaceDetails struct {
+	City       string
+	Region     string
+	Country    string
+	PostalCode *string
+}
+
+type PlaceSuggestion struct {
+	PlaceID       string
+	Description   string   // Full name, e.g. "Santa Cruz, CA, USA"
+	MainText      string   // e.g. "Santa Cruz"
+	SecondaryText string   // e.g. "CA, USA"
+	Terms         []string // Each part of the name, e.g. ["Santa Cruz", "CA", "USA"]
+}
+
+type Geocoder interface {
+	// Resolves free text to the best matching place
+	GetPlaceFromQuery(query string) (*Place, error)
+	// Returns every matching place, in the provider's order, so the user can pick the right one.
+	// Requests with the same session token are billed as one search by Google once GetPlace is
+	// called with it for the chosen place; pass "" for none.
+	Autocomplete(query string, sessionToken string) ([]PlaceSuggestion, error)
+	// Pass the token from Autocomplete to end its session, or "" if the place wasn't picked there
+	GetPlace(placeID string, sessionToken string) (*Place, error)
+	GetPlaceDetails(placeID string, coordinates geo.Point) (*PlaceDetails, error)
+}
+
+var ErrNoResults = errors.NewBadRequest("No places found for this location")
+
+// Production uses Google behind the Postgres cache. Development uses the bundled gazetteer unless
+// USE_GOOGLE_GEOCODER is set, so it works without network access or Google credentials.
+func NewGeocoder(db *gorm.DB) (Geocoder, error) {
+	_, useGoogle := os.LookupEnv("USE_GOOGLE_GEOCODER")
+	if !application.IsProd() && !useGoogle {
+		return NewLocalGeocoder()
+	}
+
+	googleGeocoder, err := NewGoogleGeocoder()
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.NewGeocoder) creating Google geocoder")
+	}
+
+	return NewCachingGeocoder(db, googleGeocoder), nil
+}
@@ -3,6 +3,8 @@ package maps
 import (
 	"context"
 
+	"github.com/google/uuid"
+	"go.coaster.io/server/common/application"
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/geo"
 	"go.coaster.io/server/common/secret"
@@ -14,18 +16,22 @@ import (
 const MAPS_PROD_API_KEY_KEY = "projects/454026596701/secrets/maps-api-key/versions/latest"
 const MAPS_DEV_API_KEY_KEY = "projects/86315250181/secrets/maps-dev-api-key/versions/latest"
 
+// Only match places that make sense as a trip destination, not individual addresses or businesses
+const AUTOCOMPLETE_PLACE_TYPES = "locality|administrative_area_level_4|administrative_area_level_3|archipelago|natural_feature"
 
-type Place struct {
-	Name        string
-	Coordinates geo.Point
-	PlaceID     string
+type GoogleGeocoder struct {
+	apiKey string
 }
 
-type PlaceDetails struct {
-	City       string
-	Region     string
-	Country    string
-	PostalCode *string
+func NewGoogleGeocoder() (Geocoder, error) {
+	mapsApiKey, err := secret.FetchSecret(context.TODO(), getMapsApiKeyKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.NewGoogleGeocoder) fetching secret")
+	}
+
+	return GoogleGeocoder{
+		apiKey: *mapsApiKey,
+	}, nil
 }
 
 func getMapsApiKeyKey() string {
@@ -36,20 +42,15 @@ func getMapsApiKeyKey() string {
 	}
 }
 
-func GetPlaceFromQuery(query string) (*Place, error) {
-	mapsApiKey, err := secret.FetchSecret(context.TODO(), getMapsApiKeyKey())
-	if err != nil {
-		return nil, errors.Wrap(err, "(maps.GetPlaceFromQuery) fetching secret")
-	}
-	
-	c, err := maps.NewClient(maps.WithAPIKey(*mapsApiKey))
+func (g GoogleGeocoder) GetPlaceFromQuery(query string) (*Place, error) {
+	c, err := maps.NewClient(maps.WithAPIKey(g.apiKey))
 	if err != nil {
 		return nil, errors.Wrap(err, "(maps.GetPlaceFromQuery) creating maps client")
 	}
 
 	autocompleteRequest := &maps.PlaceAutocompleteRequest{
 		Input: query,
-		Types: "locality|administrative_area_level_4|administrative_area_level_3|archipelago|natural_feature",
+		Types: AUTOCOMPLETE_PLACE_TYPES,
 	}
 	autocompleteResponse, err := c.PlaceAutocomplete(context.TODO(), autocompleteRequest)
 	if err != nil {
@@ -57,7 +58,7 @@ func GetPlaceFromQuery(query string) (*Place, error) {
 	}
 
 	if len(autocompleteResponse.Predictions) == 0 {
-		return nil, errors.Newf("(maps.GetPlaceFromQuery) no predictions for query: %s", query)
+		return nil, errors.Wrapf(ErrNoResults, "(maps.GetPlaceFromQuery) no predictions for query: %s", query)
 	}
 
 	detailsRequest := &maps.PlaceDetailsRequest{
@@ -82,10 +83,88 @@ func GetPlaceFromQuery(query string) (*Place, error) {
 	}, nil
 }
 
-func GetPlaceDetails(placeId string, coordinates geo.Point) (*PlaceDetails, error) {
-	service, err := places.NewService(context.TODO(), option.WithAPIKey(MAPS_API_KEY))
+func (g GoogleGeocoder) Autocomplete(query string, sessionToken string) ([]PlaceSuggestion, error) {
+	c, err := maps.NewClient(maps.WithAPIKey(g.apiKey))
 	if err != nil {
-		return nil, errors.Wrap(err, "(maps.GetPlaceFromQuery) creating maps client")
+		return nil, errors.Wrap(err, "(maps.Autocomplete) creating maps client")
+	}
+
+	autocompleteRequest := &maps.PlaceAutocompleteRequest{
+		Input: query,
+		Types: AUTOCOMPLETE_PLACE_TYPES,
+	}
+
+	if len(sessionToken) > 0 {
+		parsedToken, err := uuid.Parse(sessionToken)
+		if err != nil {
+			return nil, errors.Wrapf(err, "(maps.Autocomplete) parsing session token %s", sessionToken)
+		}
+		autocompleteRequest.SessionToken = maps.PlaceAutocompleteSessionToken(parsedToken)
+	}
+	autocompleteResponse, err := c.PlaceAutocomplete(context.TODO(), autocompleteRequest)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.Autocomplete) autocomplete request")
+	}
+
+	suggestions := make([]PlaceSuggestion, len(autocompleteResponse.Predictions))
+	for i, prediction := range autocompleteResponse.Predictions {
+		suggestions[i] = PlaceSuggestion{
+			PlaceID:       prediction.PlaceID,
+			Description:   prediction.Description,
+			MainText:      prediction.StructuredFormatting.MainText,
+			SecondaryText: prediction.StructuredFormatting.SecondaryText,
+			Terms:         make([]string, len(prediction.Terms)),
+		}
+
+		for j, term := range prediction.Terms {
+			suggestions[i].Terms[j] = term.Value
+		}
+	}
+
+	return suggestions, nil
+}
+
+func (g GoogleGeocoder) GetPlace(placeID string, sessionToken string) (*Place, error) {
+	c, err := maps.NewClient(maps.WithAPIKey(g.apiKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.GetPlace) creating maps client")
+	}
+
+	detailsRequest := &maps.PlaceDetailsRequest{
+		PlaceID: placeID,
+		Fields: []maps.PlaceDetailsFieldMask{
+			maps.PlaceDetailsFieldMaskGeometry,
+			maps.PlaceDetailsFieldMaskFormattedAddress,
+		},
+	}
+
+	if len(sessionToken) > 0 {
+		parsedToken, err := uuid.Parse(sessionToken)
+		if err != nil {
+			return nil, errors.Wrapf(err, "(maps.GetPlace) parsing session token %s", sessionToken)
+		}
+		detailsRequest.SessionToken = maps.PlaceAutocompleteSessionToken(parsedToken)
+	}
+
+	detailsResponse, err := c.PlaceDetails(context.TODO(), detailsRequest)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.GetPlace) place details request")
+	}
+
+	return &Place{
+		Name: detailsResponse.FormattedAddress,
+		Coordinates: geo.Point{
+			Latitude:  detailsResponse.Geometry.Location.Lat,
+			Longitude: detailsResponse.Geometry.Location.Lng,
+		},
+		PlaceID: placeID,
+	}, nil
+}
+
+func (g GoogleGeocoder) GetPlaceDetails(placeId string, coordinates geo.Point) (*PlaceDetails, error) {
+	service, err := places.NewService(context.TODO(), option.WithAPIKey(g.apiKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.GetPlaceDetails) creating maps client")
 	}
 
 	c := places.NewPlacesService(service)
@@ -0,0 +1,192 @@
+package maps
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/geo"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// A small set of popular destinations so search and listing creation work offline. Place IDs are prefixed
+// with "local:" so they can never be confused with Google place IDs.
+//
+//go:embed gazetteer.csv
+var gazetteerCSV []byte
+
+type gazetteerEntry struct {
+	place      Place
+	details    PlaceDetails
+	searchName string
+}
+
+// Offline geocoder for tests and local development, backed by the bundled gazetteer
+type LocalGeocoder struct {
+	entries []gazetteerEntry
+}
+
+func NewLocalGeocoder() (Geocoder, error) {
+	records, err := csv.NewReader(bytes.NewReader(gazetteerCSV)).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.NewLocalGeocoder) reading gazetteer")
+	}
+
+	// Skip the header row
+	entries := make([]gazetteerEntry, 0, len(records)-1)
+	for _, record := range records[1:] {
+		entry, err := parseGazetteerRecord(record)
+		if err != nil {
+			return nil, errors.Wrapf(err, "(maps.NewLocalGeocoder) parsing gazetteer entry %s", record[0])
+		}
+
+		entries = append(entries, *entry)
+	}
+
+	return LocalGeocoder{
+		entries: entries,
+	}, nil
+}
+
+// Returns the first entry where every word in the query is the start of a word in the place name
+func (g LocalGeocoder) GetPlaceFromQuery(query string) (*Place, error) {
+	queryWords := strings.Fields(normalizePlaceName(query))
+	if len(queryWords) == 0 {
+		return nil, errors.Wrap(ErrNoResults, "(maps.LocalGeocoder.GetPlaceFromQuery) empty query")
+	}
+
+	for _, entry := range g.entries {
+		if matchesAllWords(entry.searchName, queryWords) {
+			place := entry.place
+			return &place, nil
+		}
+	}
+
+	return nil, errors.Wrapf(ErrNoResults, "(maps.LocalGeocoder.GetPlaceFromQuery) no match for query: %s", query)
+}
+
+func (g LocalGeocoder) Autocomplete(query string, sessionToken string) ([]PlaceSuggestion, error) {
+	queryWords := strings.Fields(normalizePlaceName(query))
+	if len(queryWords) == 0 {
+		return []PlaceSuggestion{}, nil
+	}
+
+	suggestions := []PlaceSuggestion{}
+	for _, entry := range g.entries {
+		if matchesAllWords(entry.searchName, queryWords) {
+			mainText, secondaryText, _ := strings.Cut(entry.place.Name, ", ")
+			suggestions = append(suggestions, PlaceSuggestion{
+				PlaceID:       entry.place.PlaceID,
+				Description:   entry.place.Name,
+				MainText:      mainText,
+				SecondaryText: secondaryText,
+				Terms:         strings.Split(entry.place.Name, ", "),
+			})
+		}
+	}
+
+	return suggestions, nil
+}
+
+func (g LocalGeocoder) GetPlace(placeID string, sessionToken string) (*Place, error) {
+	for _, entry := range g.entries {
+		if entry.place.PlaceID == placeID {
+			place := entry.place
+			return &place, nil
+		}
+	}
+
+	return nil, errors.Wrapf(ErrNoResults, "(maps.LocalGeocoder.GetPlace) unknown place ID: %s", placeID)
+}
+
+func (g LocalGeocoder) GetPlaceDetails(placeID string, coordinates geo.Point) (*PlaceDetails, error) {
+	for _, entry := range g.entries {
+		if entry.place.PlaceID == placeID {
+			details := entry.details
+			return &details, nil
+		}
+	}
+
+	return nil, errors.Newf("(maps.LocalGeocoder.GetPlaceDetails) unknown place ID: %s", placeID)
+}
+
+func parseGazetteerRecord(record []string) (*gazetteerEntry, error) {
+	if len(record) != 8 {
+		return nil, errors.Newf("(maps.parseGazetteerRecord) expected 8 fields, got %d", len(record))
+	}
+
+	latitude, err := strconv.ParseFloat(record[6], 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.parseGazetteerRecord) parsing latitude")
+	}
+
+	longitude, err := strconv.ParseFloat(record[7], 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "(maps.parseGazetteerRecord) parsing longitude")
+	}
+
+	var postalCode *string
+	if len(record[5]) > 0 {
+		postalCode = &record[5]
+	}
+
+	return &gazetteerEntry{
+		place: Place{
+			PlaceID: record[0],
+			Name:    record[1],
+			Coordinates: geo.Point{
+				Latitude:  latitude,
+				Longitude: longitude,
+			},
+		},
+		details: PlaceDetails{
+			City:       record[2],
+			Region:     record[3],
+			Country:    record[4],
+			PostalCode: postalCode,
+		},
+		searchName: normalizePlaceName(record[1]),
+	}, nil
+}
+
+func matchesAllWords(name string, queryWords []string) bool {
+	nameWords := strings.Fields(name)
+	for _, queryWord := range queryWords {
+		found := false
+		for _, nameWord := range nameWords {
+			if strings.HasPrefix(nameWord, queryWord) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Lowercases, removes accents and replaces punctuation with spaces so "Reykjavík," matches "reykjavik"
+func normalizePlaceName(name string) string {
+	removeAccents := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	unaccented, _, err := transform.String(removeAccents, name)
+	if err != nil {
+		unaccented = name
+	}
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+
+		return ' '
+	}, unaccented)
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Token is a hash, the raw token only exists in the email. Email is the address the token was sent
+// to, so a token can't verify a different address.
+type EmailVerificationToken struct {
+	UserID     int64
+	Email      string
+	Token      string
+	Expiration time.Time
+
+	BaseModel
+}
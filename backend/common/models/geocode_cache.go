@@ -0,0 +1,22 @@
+package models
+
+import "go.coaster.io/server/common/geo"
+
+type GeocodeCacheEntry struct {
+	Query       string
+	PlaceID     string
+	Name        string
+	Coordinates geo.Point
+
+	BaseModel
+}
+
+type PlaceDetailsCacheEntry struct {
+	PlaceID    string
+	City       string
+	Region     string
+	Country    string
+	PostalCode *string
+
+	BaseModel
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+type MediaType string
+
+const (
+	MediaTypeImage MediaType = "image"
+	MediaTypeVideo MediaType = "video"
+)
+
+type Projection string
+
+const (
+	ProjectionFlat Projection = "flat"
+	// 360° panoramas and videos, mapped onto a sphere by the viewer
+	ProjectionEquirectangular Projection = "equirectangular"
+)
+
+type ImageFormat string
+
+const (
+	ImageFormatJPEG ImageFormat = "jpeg"
+	ImageFormatWebP ImageFormat = "webp"
+)
+
+type ImageVariantName string
+
+const (
+	ImageVariantThumbnail ImageVariantName = "thumbnail"
+	ImageVariantCard      ImageVariantName = "card"
+	ImageVariantHero      ImageVariantName = "hero"
+)
+
+// A resized copy of an uploaded image, stored as its own object
+type ImageVariant struct {
+	Name      ImageVariantName `json:"name"`
+	Format    ImageFormat      `json:"format"`
+	Width     int              `json:"width"`
+	Height    int              `json:"height"`
+	StorageID string           `json:"storage_id"`
+}
+
+type ImageVariants []ImageVariant
+
+func (v *ImageVariants) Scan(val interface{}) error {
+	var data []byte
+	switch b := val.(type) {
+	case []byte:
+		data = b
+	case string:
+		data = []byte(b)
+	default:
+		return fmt.Errorf("unsupported type for image variants: %T", val)
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(v)
+}
@@ -5,7 +5,7 @@ type ItineraryStep struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	StepLabel   string `json:"step_label"`
-	StepOrder   int64  `json:"step_order"`
+	StepOrder   string `json:"step_order"` // Sort key from the ranks package
 
 	BaseModel
 }
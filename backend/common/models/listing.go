@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"github.com/lib/pq"
 	"go.coaster.io/server/common/geo"
 )
@@ -42,6 +44,7 @@ type Listing struct {
 	Price               *int64              `json:"price"`
 	Location            *string             `json:"location"`
 	Coordinates         *geo.Point          `json:"coordinates"`
+	ServiceArea         *geo.Polygon        `json:"service_area"` // Optional area the trip operates in, such as a pickup zone
 	PlaceID             *string             `json:"place_id"`
 	City                *string             `json:"city"`
 	Region              *string             `json:"region"`
@@ -57,6 +60,7 @@ type Listing struct {
 	NotIncluded         pq.StringArray      `json:"not_included" gorm:"type:varchar(160)[]"`
 	AvailabilityType    AvailabilityType    `json:"availability_type"`
 	AvailabilityDisplay AvailabilityDisplay `json:"availability_display"`
+	SourceLocale        string              `json:"source_locale"` // The language the host wrote the listing in
 
 	BaseModel
 }
@@ -64,10 +68,23 @@ type Listing struct {
 type ListingImage struct {
 	ListingID int64  `json:"listing_id"`
 	StorageID string `json:"storage_id"`
-	Rank      int    `json:"rank"` // TODO: use lexorank for this
+	Rank      string `json:"rank"` // Sort key from the ranks package
 	Width     int    `json:"width"`
 	Height    int    `json:"height"`
 
+	Variants      ImageVariants `json:"variants" gorm:"type:jsonb"` // Empty for images uploaded before variants were generated
+	Blurhash      *string       `json:"blurhash"`
+	DominantColor *string       `json:"dominant_color"`
+	MissingSince  *time.Time    `json:"missing_since"` // Set by blob reconciliation when the storage object can't be found
+
+	// Videos keep the video file in StorageID and a poster frame in PosterStorageID. The variants,
+	// blurhash and dominant color all come from the poster, so a video can stand in for a photo.
+	MediaType       MediaType  `json:"media_type"`
+	Projection      Projection `json:"projection"`
+	ContentType     *string    `json:"content_type"`
+	DurationMs      *int64     `json:"duration_ms"`
+	PosterStorageID *string    `json:"poster_storage_id"`
+
 	BaseModel
 }
 
@@ -100,6 +117,29 @@ const (
 
 var SPECIAL_CATEGORIES = []ListingCategoryType{CategoryFeatured, CategoryPopular}
 
+// Every category a host can pick for their listing
+var HOST_CATEGORIES = []ListingCategoryType{
+	CategorySurfing,
+	CategorySkiing,
+	CategoryFishing,
+	CategoryHiking,
+	CategoryCamping,
+	CategoryCycling,
+	CategoryBoating,
+	CategoryClimbing,
+	CategoryOutdoors,
+	CategoryDiving,
+	CategorySnorkeling,
+	CategorySafari,
+	CategorySup,
+	CategoryKiteSurf,
+	CategoryWindSurf,
+	CategoryWingfoil,
+	CategoryKayaking,
+	CategoryBuggying,
+	CategoryHunting,
+}
+
 type ListingCategory struct {
 	ListingID int64               `json:"listing_id"`
 	Category  ListingCategoryType `json:"category"`
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lib/pq"
+	"go.coaster.io/server/common/geo"
+)
+
+type ListingRevisionStatus string
+
+const (
+	ListingRevisionStatusPending     ListingRevisionStatus = "pending"   // Host is still editing
+	ListingRevisionStatusUnderReview ListingRevisionStatus = "review"    // Submitted and waiting for an admin
+	ListingRevisionStatusRejected    ListingRevisionStatus = "rejected"  // Host needs to make changes and submit again
+	ListingRevisionStatusPublished   ListingRevisionStatus = "published" // Is or was the live version of the listing
+	ListingRevisionStatusDiscarded   ListingRevisionStatus = "discarded" // Host threw away their changes
+)
+
+// Edits to a published listing are made on a revision so the live version stays untouched until
+// the changes are reviewed. Published revisions are kept as history and can be rolled back to.
+type ListingRevision struct {
+	ListingID   int64                 `json:"listing_id"`
+	AuthorID    int64                 `json:"author_id"`
+	ReviewerID  *int64                `json:"reviewer_id"`
+	Status      ListingRevisionStatus `json:"status"`
+	Content     ListingContent        `json:"content" gorm:"type:jsonb"`
+	Reasons     pq.StringArray        `json:"reasons" gorm:"type:varchar(64)[]"` // Only set for rejections
+	Feedback    *string               `json:"feedback"`                          // Only set for rejections
+	SubmittedAt sql.NullTime          `json:"submitted_at"`
+	PublishedAt sql.NullTime          `json:"published_at"`
+
+	BaseModel
+}
+
+// The host editable fields of a listing, stored as a snapshot on each revision. Itinerary steps, images,
+// meeting points and availability rules aren't included: they're edited on the live listing directly, so
+// changes to them aren't reviewed and aren't restored by rollbacks.
+type ListingContent struct {
+	Name                *string               `json:"name"`
+	Description         *string               `json:"description"`
+	Price               *int64                `json:"price"`
+	Location            *string               `json:"location"`
+	Coordinates         *geo.Point            `json:"coordinates"`
+	ServiceArea         *geo.Polygon          `json:"service_area"`
+	PlaceID             *string               `json:"place_id"`
+	City                *string               `json:"city"`
+	Region              *string               `json:"region"`
+	Country             *string               `json:"country"`
+	PostalCode          *string               `json:"postal_code"`
+	ShortDescription    *string               `json:"short_description"`
+	Cancellation        ListingCancellation   `json:"cancellation"`
+	DurationMinutes     *int64                `json:"duration_minutes"`
+	MaxGuests           *int64                `json:"max_guests"`
+	Highlights          []string              `json:"highlights"`
+	Includes            []string              `json:"includes"`
+	NotIncluded         []string              `json:"not_included"`
+	AvailabilityType    AvailabilityType      `json:"availability_type"`
+	AvailabilityDisplay AvailabilityDisplay   `json:"availability_display"`
+	SourceLocale        string                `json:"source_locale"`
+	Categories          []ListingCategoryType `json:"categories"`
+}
+
+func (c *ListingContent) Scan(val interface{}) error {
+	var data []byte
+	switch v := val.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type for listing content: %T", val)
+	}
+
+	return json.Unmarshal(data, c)
+}
+
+func (c ListingContent) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
@@ -0,0 +1,27 @@
+package models
+
+import "github.com/lib/pq"
+
+type RejectionReason string
+
+const (
+	RejectionReasonIncompleteInformation RejectionReason = "incomplete_information"
+	RejectionReasonLowQualityImages      RejectionReason = "low_quality_images"
+	RejectionReasonInaccurateDescription RejectionReason = "inaccurate_description"
+	RejectionReasonPricing               RejectionReason = "pricing"
+	RejectionReasonPolicyViolation       RejectionReason = "policy_violation"
+	RejectionReasonDuplicate             RejectionReason = "duplicate"
+	RejectionReasonOther                 RejectionReason = "other"
+)
+
+// Records every status change for a listing, such as a host submitting it or an admin approving or rejecting it
+type ListingStatusEvent struct {
+	ListingID  int64          `json:"listing_id"`
+	ActorID    int64          `json:"actor_id"`
+	FromStatus ListingStatus  `json:"from_status"`
+	ToStatus   ListingStatus  `json:"to_status"`
+	Reasons    pq.StringArray `json:"reasons" gorm:"type:varchar(64)[]"` // Only set for rejections
+	Feedback   *string        `json:"feedback"`                          // Only set for rejections
+
+	BaseModel
+}
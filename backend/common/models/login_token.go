@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// A single use magic link for logging in by email. Token is a hash, the raw token only exists in
+// the email. Origin is the site the link was requested from, and the only one it can be used on.
+type LoginToken struct {
+	UserID     int64
+	Token      string
+	Origin     string
+	Expiration time.Time
+	// Hash of the nonce cookie set in the browser that requested the link
+	DeviceNonce *string
+
+	BaseModel
+}
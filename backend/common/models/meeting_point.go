@@ -0,0 +1,12 @@
+package models
+
+import "go.coaster.io/server/common/geo"
+
+type MeetingPoint struct {
+	ListingID    int64     `json:"-"`
+	Name         string    `json:"name"`
+	Instructions *string   `json:"instructions"`
+	Coordinates  geo.Point `json:"coordinates"`
+
+	BaseModel
+}
@@ -8,6 +8,12 @@ type Session struct {
 	Token      string
 	UserID     int64
 	Expiration time.Time
+	// When the user last proved who they are, by logging in or reauthenticating
+	AuthenticatedAt time.Time
+	// When the user last entered a TOTP or recovery code in this session, if ever
+	SecondFactorAt *time.Time
+	// Wrong passwords or codes entered while reauthenticating since the last success
+	ReauthenticationFailures int
 
 	BaseModel
 }
@@ -0,0 +1,25 @@
+package models
+
+import "github.com/lib/pq"
+
+// A host provided translation of a listing's text. Empty fields fall back to the source language.
+type ListingTranslation struct {
+	ListingID        int64          `json:"listing_id"`
+	Locale           string         `json:"locale"`
+	Name             *string        `json:"name"`
+	Description      *string        `json:"description"`
+	ShortDescription *string        `json:"short_description"`
+	Highlights       pq.StringArray `json:"highlights" gorm:"type:varchar(160)[]"`
+
+	BaseModel
+}
+
+type ItineraryStepTranslation struct {
+	ItineraryStepID int64   `json:"itinerary_step_id"`
+	Locale          string  `json:"locale"`
+	Title           *string `json:"title"`
+	Description     *string `json:"description"`
+	StepLabel       *string `json:"step_label"`
+
+	BaseModel
+}
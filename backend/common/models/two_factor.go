@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type RecoveryCode struct {
+	UserID   int64
+	CodeHash string
+	UsedAt   *time.Time
+
+	BaseModel
+}
+
+// Issued after the first login step for users with two-factor enabled. Token is a hash, the raw
+// token is held by the client until it sends the code.
+type TwoFactorChallenge struct {
+	UserID     int64
+	Token      string
+	Expiration time.Time
+	// Counted before each code is checked, so this includes the one that succeeds
+	FailedAttempts int
+
+	BaseModel
+}
@@ -1,28 +1,41 @@
 package models
 
+import "time"
+
 type User struct {
-	FirstName            string              `json:"first_name"`
-	LastName             string              `json:"last_name"`
-	Email                string              `json:"email"`
-	Phone                *string             `json:"phone"`
-	About                *string             `json:"about"`
-	ProfilePictureURL    *string             `json:"profile_picture_url"`
-	ProfilePictureWidth  *int                `json:"profile_picture_width"`
-	ProfilePictureHeight *int                `json:"profile_picture_height"`
-	Blocked              bool                `json:"-"`
-	HashedPassword       *string             `json:"-"`
-	LoginMethod          LoginMethod         `json:"login_method"`
-	IsHost               bool                `json:"is_host"`
-	EmailVerified        bool                `json:"email_verified"`
-	IsAdmin              bool                `json:"is_admin"`
-	StripeAccountID      *string             `json:"stripe_account_id"`
-	StripeAccountStatus  StripeAccountStatus `json:"stripe_account_status"`
-	Currency             string              `json:"currency"`
-	CommissionPercent    int64               `json:"commission_percent"`
+	FirstName                   string              `json:"first_name"`
+	LastName                    string              `json:"last_name"`
+	Email                       string              `json:"email"`
+	Phone                       *string             `json:"phone"`
+	About                       *string             `json:"about"`
+	ProfilePictureURL           *string             `json:"profile_picture_url"`
+	ProfilePictureWidth         *int                `json:"profile_picture_width"`
+	ProfilePictureHeight        *int                `json:"profile_picture_height"`
+	ProfilePictureBlurhash      *string             `json:"profile_picture_blurhash"`
+	ProfilePictureDominantColor *string             `json:"profile_picture_dominant_color"`
+	Blocked                     bool                `json:"-"`
+	HashedPassword              *string             `json:"-"`
+	LoginMethod                 LoginMethod         `json:"login_method"`
+	IsHost                      bool                `json:"is_host"`
+	EmailVerified               bool                `json:"email_verified"`
+	IsAdmin                     bool                `json:"is_admin"`
+	TOTPSecret                  *string             `json:"-"` // Encrypted, see totp.EncryptSecret
+	TOTPEnabledAt               *time.Time          `json:"-"` // Nil while enrollment is unconfirmed
+	TOTPLastUsedStep            *int64              `json:"-"` // Stops a code from being used twice
+	TwoFactorAttempts           int                 `json:"-"` // Codes checked since TwoFactorAttemptsSince
+	TwoFactorAttemptsSince      *time.Time          `json:"-"`
+	StripeAccountID             *string             `json:"stripe_account_id"`
+	StripeAccountStatus         StripeAccountStatus `json:"stripe_account_status"`
+	Currency                    string              `json:"currency"`
+	CommissionPercent           int64               `json:"commission_percent"`
 
 	BaseModel
 }
 
+func (u User) HasTwoFactor() bool {
+	return u.TOTPEnabledAt != nil
+}
+
 type StripeAccountStatus string
 
 const (
@@ -35,6 +48,8 @@ type LoginMethod string
 const (
 	LoginMethodEmail     LoginMethod = "email"
 	LoginMethodGoogle    LoginMethod = "google"
+	LoginMethodApple     LoginMethod = "apple"
+	LoginMethodFacebook  LoginMethod = "facebook"
 	LoginMethodUndefined LoginMethod = "undefined"
 )
 
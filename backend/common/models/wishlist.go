@@ -0,0 +1,16 @@
+package models
+
+type Wishlist struct {
+	UserID     int64   `json:"user_id"`
+	Name       string  `json:"name"`
+	ShareToken *string `json:"-"` // Only set when the owner has enabled sharing
+
+	BaseModel
+}
+
+type WishlistListing struct {
+	WishlistID int64 `json:"wishlist_id"`
+	ListingID  int64 `json:"listing_id"`
+
+	BaseModel
+}
@@ -0,0 +1,173 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/secret"
+)
+
+// The private key downloaded from the Apple developer portal (the .p8 file)
+const APPLE_PRODUCTION_PRIVATE_KEY_KEY = "projects/454026596701/secrets/apple-prod-sign-in-key/versions/latest"
+const APPLE_DEVELOPMENT_PRIVATE_KEY_KEY = "projects/86315250181/secrets/apple-dev-sign-in-key/versions/latest"
+
+const APPLE_ISSUER = "https://appleid.apple.com"
+
+// Apple accepts client secrets that are valid for up to six months, but there's no reason to make
+// one last longer than the request it's used for
+const APPLE_CLIENT_SECRET_EXPIRATION = 5 * time.Minute
+
+// Users who hide their email get an address at this domain that forwards to them, but only for
+// emails sent from domains registered with Apple
+const APPLE_PRIVATE_RELAY_DOMAIN = "@privaterelay.appleid.com"
+
+type AppleConfig struct {
+	// Defaults to APPLE_ISSUER
+	Issuer string
+	// The Services ID, not the App ID
+	ClientID string
+	TeamID   string
+	KeyID    string
+	// PEM encoded PKCS #8 key
+	GetPrivateKey func(ctx context.Context) (string, error)
+	RedirectURL   string
+	HTTPClient    *http.Client
+}
+
+// Apple only sends the user's name the first time they sign in, as JSON posted alongside the code
+type appleUser struct {
+	Name struct {
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+	} `json:"name"`
+}
+
+// Sign in with Apple is OIDC, with two quirks: it posts the callback as a form instead of redirecting
+// (required when asking for the user's name), and the client secret is a JWT we sign ourselves.
+func NewAppleProvider(config AppleConfig) *OIDCProvider {
+	if config.Issuer == "" {
+		config.Issuer = APPLE_ISSUER
+	}
+
+	return NewOIDCProvider(OIDCConfig{
+		Provider:    OauthProviderApple,
+		Issuer:      config.Issuer,
+		ClientID:    config.ClientID,
+		Scopes:      []string{"openid", "name", "email"},
+		RedirectURL: config.RedirectURL,
+		AuthParams:  map[string]string{"response_mode": "form_post"},
+		HTTPClient:  config.HTTPClient,
+		GetClientSecret: func(ctx context.Context) (string, error) {
+			return createAppleClientSecret(ctx, config)
+		},
+		MapClaims: mapAppleClaims,
+	})
+}
+
+// Returns nil if Sign in with Apple isn't configured in this environment
+func newAppleProvider() Provider {
+	clientID, hasClientID := os.LookupEnv("APPLE_CLIENT_ID")
+	teamID, hasTeamID := os.LookupEnv("APPLE_TEAM_ID")
+	keyID, hasKeyID := os.LookupEnv("APPLE_KEY_ID")
+	if !hasClientID || !hasTeamID || !hasKeyID {
+		return nil
+	}
+
+	return NewAppleProvider(AppleConfig{
+		ClientID:      clientID,
+		TeamID:        teamID,
+		KeyID:         keyID,
+		GetPrivateKey: fetchApplePrivateKey,
+		RedirectURL:   getOauthRedirectUrl(),
+	})
+}
+
+func createAppleClientSecret(ctx context.Context, config AppleConfig) (string, error) {
+	pemKey, err := config.GetPrivateKey(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "(oauth.createAppleClientSecret) getting private key")
+	}
+
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return "", errors.New("(oauth.createAppleClientSecret) private key is not PEM encoded")
+	}
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", errors.Wrap(err, "(oauth.createAppleClientSecret) parsing private key")
+	}
+
+	privateKey, ok := parsedKey.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", errors.New("(oauth.createAppleClientSecret) private key is not an ECDSA key")
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    config.TeamID,
+		Subject:   config.ClientID,
+		Audience:  jwt.ClaimStrings{config.Issuer},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(APPLE_CLIENT_SECRET_EXPIRATION)),
+	})
+	token.Header["kid"] = config.KeyID
+
+	clientSecret, err := token.SignedString(privateKey)
+	if err != nil {
+		return "", errors.Wrap(err, "(oauth.createAppleClientSecret) signing")
+	}
+
+	return clientSecret, nil
+}
+
+func mapAppleClaims(claims *IDTokenClaims, callback Callback, info *ExternalUserInfo) error {
+	if callback.User != "" {
+		var user appleUser
+		err := json.Unmarshal([]byte(callback.User), &user)
+		if err != nil {
+			return errors.Wrap(err, "(oauth.mapAppleClaims) parsing user")
+		}
+
+		info.FirstName = user.Name.FirstName
+		info.LastName = user.Name.LastName
+	}
+
+	if strings.HasSuffix(strings.ToLower(info.Email), APPLE_PRIVATE_RELAY_DOMAIN) {
+		info.IsPrivateEmail = true
+	}
+
+	// Apple only hands out relay addresses it has set up itself
+	if info.IsPrivateEmail {
+		info.EmailVerified = true
+	}
+
+	return nil
+}
+
+func fetchApplePrivateKey(ctx context.Context) (string, error) {
+	privateKey, err := secret.FetchSecret(ctx, getApplePrivateKeyKey())
+	if err != nil {
+		return "", errors.Wrap(err, "(oauth.fetchApplePrivateKey)")
+	}
+
+	return *privateKey, nil
+}
+
+func getApplePrivateKeyKey() string {
+	if application.IsProd() {
+		return APPLE_PRODUCTION_PRIVATE_KEY_KEY
+	} else {
+		return APPLE_DEVELOPMENT_PRIVATE_KEY_KEY
+	}
+}
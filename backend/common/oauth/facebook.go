@@ -0,0 +1,185 @@
+package oauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/secret"
+	"golang.org/x/oauth2"
+)
+
+const FACEBOOK_PRODUCTION_SECRET_KEY = "projects/454026596701/secrets/facebook-prod-app-secret/versions/latest"
+const FACEBOOK_DEVELOPMENT_SECRET_KEY = "projects/86315250181/secrets/facebook-dev-app-secret/versions/latest"
+
+const FACEBOOK_GRAPH_URL = "https://graph.facebook.com/v19.0"
+const FACEBOOK_AUTH_URL = "https://www.facebook.com/v19.0/dialog/oauth"
+
+type FacebookConfig struct {
+	AppID        string
+	GetAppSecret func(ctx context.Context) (string, error)
+	RedirectURL  string
+	// Defaults to FACEBOOK_AUTH_URL and FACEBOOK_GRAPH_URL
+	AuthURL    string
+	GraphURL   string
+	HTTPClient *http.Client
+}
+
+type facebookUser struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Picture   struct {
+		Data struct {
+			URL          string `json:"url"`
+			IsSilhouette bool   `json:"is_silhouette"`
+		} `json:"data"`
+	} `json:"picture"`
+}
+
+// Facebook Login isn't OIDC on the web, so the user's details come from the Graph API instead of an
+// ID token. The nonce isn't needed since the access token is fetched directly from Facebook.
+type FacebookProvider struct {
+	config FacebookConfig
+}
+
+func NewFacebookProvider(config FacebookConfig) *FacebookProvider {
+	if config.AuthURL == "" {
+		config.AuthURL = FACEBOOK_AUTH_URL
+	}
+
+	if config.GraphURL == "" {
+		config.GraphURL = FACEBOOK_GRAPH_URL
+	}
+
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+
+	return &FacebookProvider{config: config}
+}
+
+// Returns nil if Facebook Login isn't configured in this environment
+func newFacebookProvider() Provider {
+	appID, ok := os.LookupEnv("FACEBOOK_APP_ID")
+	if !ok {
+		return nil
+	}
+
+	return NewFacebookProvider(FacebookConfig{
+		AppID:        appID,
+		GetAppSecret: fetchFacebookAppSecret,
+		RedirectURL:  getOauthRedirectUrl(),
+	})
+}
+
+func (p *FacebookProvider) Name() OauthProvider {
+	return OauthProviderFacebook
+}
+
+func (p *FacebookProvider) AuthCodeURL(ctx context.Context, state string, nonce string) (string, error) {
+	return p.getOauthConfig("").AuthCodeURL(state), nil
+}
+
+func (p *FacebookProvider) Exchange(ctx context.Context, callback Callback, nonce string) (*ExternalUserInfo, error) {
+	appSecret, err := p.config.GetAppSecret(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.FacebookProvider.Exchange) getting app secret")
+	}
+
+	oauth2Token, err := p.getOauthConfig(appSecret).Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.config.HTTPClient), callback.Code)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.FacebookProvider.Exchange) exchanging code for token")
+	}
+
+	// Proves the access token is being used by our app, in case it leaks
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write([]byte(oauth2Token.AccessToken))
+
+	query := url.Values{}
+	query.Set("fields", "id,first_name,last_name,email,picture.type(large)")
+	query.Set("access_token", oauth2Token.AccessToken)
+	query.Set("appsecret_proof", hex.EncodeToString(mac.Sum(nil)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.GraphURL+"/me?"+query.Encode(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.FacebookProvider.Exchange) creating request")
+	}
+
+	resp, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.FacebookProvider.Exchange) fetching user")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Newf("(oauth.FacebookProvider.Exchange) unexpected status %d fetching user", resp.StatusCode)
+	}
+
+	var user facebookUser
+	err = json.NewDecoder(resp.Body).Decode(&user)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.FacebookProvider.Exchange) decoding user")
+	}
+
+	// Accounts registered with a phone number, or that declined the email permission, have no email
+	if user.Email == "" {
+		return nil, errors.NewBadRequest("Your Facebook account doesn't have an email address we can use. Please sign up with your email instead.")
+	}
+
+	profilePictureURL := ""
+	if !user.Picture.Data.IsSilhouette {
+		profilePictureURL = user.Picture.Data.URL
+	}
+
+	return &ExternalUserInfo{
+		ExternalID:    user.ID,
+		OauthProvider: OauthProviderFacebook,
+		Email:         user.Email,
+		// Facebook doesn't say whether the user confirmed the email, so it's never used to sign in to
+		// an existing account. New accounts verify it like email signups do.
+		EmailVerified:     false,
+		FirstName:         user.FirstName,
+		LastName:          user.LastName,
+		ProfilePictureURL: profilePictureURL,
+	}, nil
+}
+
+func (p *FacebookProvider) getOauthConfig(appSecret string) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     p.config.AppID,
+		ClientSecret: appSecret,
+		Scopes:       []string{"email", "public_profile"},
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   p.config.AuthURL,
+			TokenURL:  p.config.GraphURL + "/oauth/access_token",
+			AuthStyle: oauth2.AuthStyleInParams,
+		},
+	}
+}
+
+func fetchFacebookAppSecret(ctx context.Context) (string, error) {
+	appSecret, err := secret.FetchSecret(ctx, getFacebookSecretKey())
+	if err != nil {
+		return "", errors.Wrap(err, "(oauth.fetchFacebookAppSecret)")
+	}
+
+	return *appSecret, nil
+}
+
+func getFacebookSecretKey() string {
+	if application.IsProd() {
+		return FACEBOOK_PRODUCTION_SECRET_KEY
+	} else {
+		return FACEBOOK_DEVELOPMENT_SECRET_KEY
+	}
+}
@@ -0,0 +1,54 @@
+package oauth
+
+import (
+	"context"
+
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/secret"
+)
+
+const GOOGLE_PRODUCTION_CLIENT_ID = "454026596701-bcsu6bfr36alkdpcq7im8rjgmm2cgcnl.apps.googleusercontent.com"
+const GOOGLE_PRODUCTION_SECRET_KEY = "projects/454026596701/secrets/google-prod-client-secret/versions/latest"
+const GOOGLE_DEVELOPMENT_CLIENT_ID = "86315250181-v19knnmf486fb5nebm2b47hu454abvet.apps.googleusercontent.com"
+const GOOGLE_DEVELOPMENT_SECRET_KEY = "projects/86315250181/secrets/google-dev-client-secret/versions/latest"
+
+const GOOGLE_ISSUER = "https://accounts.google.com"
+
+func newGoogleProvider() Provider {
+	return NewOIDCProvider(OIDCConfig{
+		Provider:          OauthProviderGoogle,
+		Issuer:            GOOGLE_ISSUER,
+		AdditionalIssuers: []string{"accounts.google.com"},
+		ClientID:          getGoogleClientID(),
+		GetClientSecret:   fetchGoogleClientSecret,
+		Scopes:            []string{"email", "profile", "openid"},
+		RedirectURL:       getOauthRedirectUrl(),
+		AuthParams:        map[string]string{"access_type": "online", "prompt": "consent"},
+	})
+}
+
+func fetchGoogleClientSecret(ctx context.Context) (string, error) {
+	googleClientSecret, err := secret.FetchSecret(ctx, getGoogleSecretKey())
+	if err != nil {
+		return "", errors.Wrap(err, "(oauth.fetchGoogleClientSecret)")
+	}
+
+	return *googleClientSecret, nil
+}
+
+func getGoogleSecretKey() string {
+	if application.IsProd() {
+		return GOOGLE_PRODUCTION_SECRET_KEY
+	} else {
+		return GOOGLE_DEVELOPMENT_SECRET_KEY
+	}
+}
+
+func getGoogleClientID() string {
+	if application.IsProd() {
+		return GOOGLE_PRODUCTION_CLIENT_ID
+	} else {
+		return GOOGLE_DEVELOPMENT_CLIENT_ID
+	}
+}
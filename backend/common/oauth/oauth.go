@@ -2,115 +2,109 @@ package oauth
 
 import (
 	"context"
+	"crypto/rand"
+	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"go.coaster.io/server/common/application"
 	"go.coaster.io/server/common/crypto"
 	"go.coaster.io/server/common/errors"
-	"go.coaster.io/server/common/secret"
-	"golang.org/x/oauth2"
-	"google.golang.org/api/idtoken"
-
-	googleoauth "golang.org/x/oauth2/google"
 )
 
-const GOOGLE_PRODUCTION_CLIENT_ID = "454026596701-bcsu6bfr36alkdpcq7im8rjgmm2cgcnl.apps.googleusercontent.com"
-const GOOGLE_PRODUCTION_SECRET_KEY = "projects/454026596701/secrets/google-prod-client-secret/versions/latest"
-const GOOGLE_DEVELOPMENT_CLIENT_ID = "86315250181-v19knnmf486fb5nebm2b47hu454abvet.apps.googleusercontent.com"
-const GOOGLE_DEVELOPMENT_SECRET_KEY = "projects/86315250181/secrets/google-dev-client-secret/versions/latest"
+// How long the user has to finish logging in with the provider
+const STATE_EXPIRATION = 10 * time.Minute
 
 type StateClaims struct {
 	Origin   string        `json:"origin"`
 	Provider OauthProvider `json:"provider"`
+	// Also sent to OIDC providers, which put it in the ID token. Checking it on the way back means an
+	// ID token can't be replayed into a different login.
+	Nonce string `json:"nonce"`
 	jwt.RegisteredClaims
 }
 
 type OauthProvider string
 
 const (
-	OauthProviderGoogle  OauthProvider = "google"
-	OauthProviderUnknown OauthProvider = "unknown"
+	OauthProviderGoogle   OauthProvider = "google"
+	OauthProviderApple    OauthProvider = "apple"
+	OauthProviderFacebook OauthProvider = "facebook"
+	OauthProviderUnknown  OauthProvider = "unknown"
 )
 
 type ExternalUserInfo struct {
-	ExternalID        string
-	OauthProvider     OauthProvider
-	Email             string
+	ExternalID    string
+	OauthProvider OauthProvider
+	Email         string
+	// Whether the provider confirmed the user owns the email. Unverified emails are never used to
+	// match existing accounts.
+	EmailVerified bool
+	// Apple lets users hide their address behind a relay address that only works for registered senders
+	IsPrivateEmail    bool
 	FirstName         string
 	LastName          string
 	ProfilePictureURL string
 }
 
-func FetchGoogleInfo(code string) (*ExternalUserInfo, error) {
-	secretKey := getGoogleSecretKey()
-	googleClientSecret, err := secret.FetchSecret(context.TODO(), secretKey)
-	if err != nil {
-		return nil, errors.Wrap(err, "(oauth.FetchGoogleInfo) fetching secret")
-	}
+// What the provider sent back to the redirect URL
+type Callback struct {
+	Code string
+	// Apple posts the user's name as JSON, and only the first time they sign in
+	User string
+}
 
-	clientId := getGoogleClientID()
-	oauthConf := &oauth2.Config{
-		ClientID:     clientId,
-		ClientSecret: *googleClientSecret,
-		Scopes:       []string{"email", "profile", "openid"},
-		RedirectURL:  getOauthRedirectUrl(),
-		Endpoint:     googleoauth.Endpoint,
-	}
+type Provider interface {
+	Name() OauthProvider
+	// The provider's login page. State and nonce come back with the callback.
+	AuthCodeURL(ctx context.Context, state string, nonce string) (string, error)
+	// Exchanges the code from the callback for the user's details
+	Exchange(ctx context.Context, callback Callback, nonce string) (*ExternalUserInfo, error)
+}
 
-	oauth2Token, err := oauthConf.Exchange(context.TODO(), code)
-	if err != nil {
-		return nil, errors.Wrap(err, "(oauth.FetchGoogleInfo) exchanging code for token")
-	}
+var providersOnce sync.Once
+var providers map[OauthProvider]Provider
+
+// Providers are built once and reused, since OIDC providers cache their discovery document and keys.
+// Returns a bad request for providers that don't exist or aren't configured in this environment.
+func GetProvider(name OauthProvider) (Provider, error) {
+	providersOnce.Do(func() {
+		providers = make(map[OauthProvider]Provider)
+		for _, provider := range []Provider{newGoogleProvider(), newAppleProvider(), newFacebookProvider()} {
+			if provider != nil {
+				providers[provider.Name()] = provider
+			}
+		}
+	})
 
-	// Extract the ID Token from OAuth2 token.
-	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+	provider, ok := providers[name]
 	if !ok {
-		return nil, errors.Newf("(oauth.FetchGoogleInfo) no id_token included in token exchange response: %+v", oauth2Token)
+		return nil, errors.NewBadRequestf("Unsupported login method: %s", name)
 	}
 
-	validator, err := idtoken.NewValidator(context.TODO())
-	if err != nil {
-		return nil, errors.Wrap(err, "(oauth.FetchGoogleInfo) creating validator")
-	}
+	return provider, nil
+}
 
-	payload, err := validator.Validate(context.TODO(), rawIDToken, clientId)
+func GetOauthRedirect(origin string, strProvider string) (*string, error) {
+	provider, err := GetProvider(getOAuthProvider(strProvider))
 	if err != nil {
-		return nil, errors.Wrap(err, "(oauth.FetchGoogleInfo) validating token")
+		return nil, errors.Wrap(err, "(oauth.GetOauthRedirect)")
 	}
 
-	return &ExternalUserInfo{
-		ExternalID:        payload.Subject,
-		OauthProvider:     OauthProviderGoogle,
-		Email:             payload.Claims["email"].(string),
-		FirstName:         payload.Claims["given_name"].(string),
-		LastName:          payload.Claims["family_name"].(string),
-		ProfilePictureURL: payload.Claims["picture"].(string),
-	}, nil
-}
-
-func GetOauthRedirect(origin string, strProvider string) (*string, error) {
-	provider := getOAuthProvider(strProvider)
-
-	var oauthConf *oauth2.Config
-	switch provider {
-	case OauthProviderGoogle:
-		oauthConf = &oauth2.Config{
-			ClientID:    getGoogleClientID(),
-			Scopes:      []string{"email", "profile", "openid"},
-			RedirectURL: getOauthRedirectUrl(),
-			Endpoint:    googleoauth.Endpoint,
-		}
-	default:
-		return nil, errors.Newf("unsupported login method: %s", strProvider)
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.GetOauthRedirect)")
 	}
 
 	token := jwt.NewWithClaims(crypto.SigningMethodKMSHS256, StateClaims{
 		origin,
-		provider,
+		provider.Name(),
+		nonce,
 		jwt.RegisteredClaims{
-			IssuedAt: jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(STATE_EXPIRATION)),
 		},
 	})
 
@@ -119,46 +113,43 @@ func GetOauthRedirect(origin string, strProvider string) (*string, error) {
 		return nil, errors.Wrap(err, "(oauth.GetOauthRedirect) signing token")
 	}
 
-	url := oauthConf.AuthCodeURL(signedString, oauth2.AccessTypeOnline, oauth2.ApprovalForce)
+	url, err := provider.AuthCodeURL(context.TODO(), signedString, nonce)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.GetOauthRedirect) building URL")
+	}
 
 	return &url, nil
 }
 
-func ValidateState(state string) (*OauthProvider, *string, error) {
+func ValidateState(state string) (*StateClaims, error) {
 	token, err := jwt.ParseWithClaims(state, &StateClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return nil, nil // no key needs to be fetched— we just call the GCP KMS endpoint
-	})
+	}, jwt.WithValidMethods([]string{crypto.SigningMethodKMSHS256.Alg()}))
 
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "(oauth.ValidateState) parsing token")
+		return nil, errors.Wrap(err, "(oauth.ValidateState) parsing token")
 	}
 
 	if !token.Valid {
-		return nil, nil, errors.Newf("token invalid: %v", token.Raw)
+		return nil, errors.Newf("token invalid: %v", token.Raw)
 	}
 
 	claims, ok := token.Claims.(*StateClaims)
 	if !ok {
-		return nil, nil, errors.Newf("token invalid: %v", token.Raw)
+		return nil, errors.Newf("token invalid: %v", token.Raw)
 	}
 
-	return &claims.Provider, &claims.Origin, nil
+	return claims, nil
 }
 
-func getGoogleSecretKey() string {
-	if application.IsProd() {
-		return GOOGLE_PRODUCTION_SECRET_KEY
-	} else {
-		return GOOGLE_DEVELOPMENT_SECRET_KEY
+func generateNonce() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrap(err, "(oauth.generateNonce)")
 	}
-}
 
-func getGoogleClientID() string {
-	if application.IsProd() {
-		return GOOGLE_PRODUCTION_CLIENT_ID
-	} else {
-		return GOOGLE_DEVELOPMENT_CLIENT_ID
-	}
+	return fmt.Sprintf("%x", b), nil
 }
 
 func getOauthRedirectUrl() string {
@@ -173,6 +164,10 @@ func getOAuthProvider(strProvider string) OauthProvider {
 	switch strings.ToLower(strProvider) {
 	case "google":
 		return OauthProviderGoogle
+	case "apple":
+		return OauthProviderApple
+	case "facebook":
+		return OauthProviderFacebook
 	default:
 		return OauthProviderUnknown
 	}
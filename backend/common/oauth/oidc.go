@@ -0,0 +1,438 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.coaster.io/server/common/errors"
+	"golang.org/x/oauth2"
+)
+
+// Providers rarely change their endpoints, but rotate keys regularly
+const DISCOVERY_CACHE_DURATION = 24 * time.Hour
+const KEYS_CACHE_DURATION = time.Hour
+
+// An ID token signed with an unknown key usually means the provider rotated keys, but refetching on
+// every unknown key would let anyone make us hammer the provider
+const KEYS_REFETCH_INTERVAL = time.Minute
+
+// Allowance for clock drift between us and the provider
+const ID_TOKEN_LEEWAY = time.Minute
+
+type OIDCConfig struct {
+	Provider OauthProvider
+	Issuer   string
+	// Other issuer values the provider puts in ID tokens, e.g. Google sometimes leaves off the scheme
+	AdditionalIssuers []string
+	ClientID          string
+	GetClientSecret   func(ctx context.Context) (string, error)
+	Scopes            []string
+	RedirectURL       string
+	// Extra parameters for the provider's login page
+	AuthParams map[string]string
+	// Defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// Fills in anything the standard claims don't cover. Called after the standard claims are mapped.
+	MapClaims func(claims *IDTokenClaims, callback Callback, info *ExternalUserInfo) error
+}
+
+type IDTokenClaims struct {
+	Nonce          string       `json:"nonce"`
+	Email          string       `json:"email"`
+	EmailVerified  FlexibleBool `json:"email_verified"`
+	IsPrivateEmail FlexibleBool `json:"is_private_email"`
+	GivenName      string       `json:"given_name"`
+	FamilyName     string       `json:"family_name"`
+	Picture        string       `json:"picture"`
+	jwt.RegisteredClaims
+}
+
+// Apple sends some boolean claims as the strings "true" and "false"
+type FlexibleBool bool
+
+func (b *FlexibleBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return errors.Wrap(err, "(oauth.FlexibleBool.UnmarshalJSON)")
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*b = FlexibleBool(v)
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return errors.Wrapf(err, "(oauth.FlexibleBool.UnmarshalJSON) parsing %s", v)
+		}
+		*b = FlexibleBool(parsed)
+	case nil:
+		*b = false
+	default:
+		return errors.Newf("(oauth.FlexibleBool.UnmarshalJSON) unexpected value %s", string(data))
+	}
+
+	return nil
+}
+
+type discoveryDocument struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+}
+
+type jsonWebKey struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// Generic OpenID Connect login. The provider's endpoints and signing keys are discovered from the
+// issuer, so adding an OIDC provider is just configuration.
+type OIDCProvider struct {
+	config OIDCConfig
+
+	mu            sync.Mutex
+	discovery     *discoveryDocument
+	discoveredAt  time.Time
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+
+	return &OIDCProvider{config: config}
+}
+
+func (p *OIDCProvider) Name() OauthProvider {
+	return p.config.Provider
+}
+
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state string, nonce string) (string, error) {
+	oauthConf, err := p.getOauthConfig(ctx, "")
+	if err != nil {
+		return "", errors.Wrap(err, "(oauth.OIDCProvider.AuthCodeURL)")
+	}
+
+	opts := []oauth2.AuthCodeOption{oauth2.SetAuthURLParam("nonce", nonce)}
+	for key, value := range p.config.AuthParams {
+		opts = append(opts, oauth2.SetAuthURLParam(key, value))
+	}
+
+	return oauthConf.AuthCodeURL(state, opts...), nil
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, callback Callback, nonce string) (*ExternalUserInfo, error) {
+	clientSecret, err := p.config.GetClientSecret(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.Exchange) getting client secret")
+	}
+
+	oauthConf, err := p.getOauthConfig(ctx, clientSecret)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.Exchange)")
+	}
+
+	oauth2Token, err := oauthConf.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.config.HTTPClient), callback.Code)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.Exchange) exchanging code for token")
+	}
+
+	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.Newf("(oauth.OIDCProvider.Exchange) no id_token included in token exchange response from %s", p.config.Provider)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, rawIDToken, nonce)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.Exchange)")
+	}
+
+	info := &ExternalUserInfo{
+		ExternalID:        claims.Subject,
+		OauthProvider:     p.config.Provider,
+		Email:             claims.Email,
+		EmailVerified:     bool(claims.EmailVerified),
+		IsPrivateEmail:    bool(claims.IsPrivateEmail),
+		FirstName:         claims.GivenName,
+		LastName:          claims.FamilyName,
+		ProfilePictureURL: claims.Picture,
+	}
+
+	if p.config.MapClaims != nil {
+		err = p.config.MapClaims(claims, callback, info)
+		if err != nil {
+			return nil, errors.Wrap(err, "(oauth.OIDCProvider.Exchange) mapping claims")
+		}
+	}
+
+	return info, nil
+}
+
+// Checks the signature against the provider's published keys, then the issuer, audience, expiry
+// and nonce
+func (p *OIDCProvider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*IDTokenClaims, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(ID_TOKEN_LEEWAY),
+	)
+
+	claims := &IDTokenClaims{}
+	_, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		keyID, _ := token.Header["kid"].(string)
+		return p.getKey(ctx, keyID)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.VerifyIDToken) parsing token")
+	}
+
+	if !p.isIssuerAllowed(claims.Issuer) {
+		return nil, errors.Newf("(oauth.OIDCProvider.VerifyIDToken) unexpected issuer %s", claims.Issuer)
+	}
+
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, errors.New("(oauth.OIDCProvider.VerifyIDToken) nonce mismatch")
+	}
+
+	if claims.Subject == "" {
+		return nil, errors.New("(oauth.OIDCProvider.VerifyIDToken) missing subject")
+	}
+
+	return claims, nil
+}
+
+func (p *OIDCProvider) isIssuerAllowed(issuer string) bool {
+	if issuer == p.config.Issuer {
+		return true
+	}
+
+	for _, additionalIssuer := range p.config.AdditionalIssuers {
+		if issuer == additionalIssuer {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *OIDCProvider) getOauthConfig(ctx context.Context, clientSecret string) (*oauth2.Config, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.getOauthConfig)")
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.config.ClientID,
+		ClientSecret: clientSecret,
+		Scopes:       p.config.Scopes,
+		RedirectURL:  p.config.RedirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:   discovery.AuthorizationEndpoint,
+			TokenURL:  discovery.TokenEndpoint,
+			AuthStyle: getAuthStyle(discovery.TokenEndpointAuthMethodsSupported),
+		},
+	}, nil
+}
+
+func (p *OIDCProvider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.discoveredAt) < DISCOVERY_CACHE_DURATION {
+		return p.discovery, nil
+	}
+
+	discoveryURL := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	var discovery discoveryDocument
+	err := p.getJSON(ctx, discoveryURL, &discovery)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.discover)")
+	}
+
+	// Otherwise whoever serves the document could point us at their own keys
+	if discovery.Issuer != p.config.Issuer {
+		return nil, errors.Newf("(oauth.OIDCProvider.discover) issuer %s does not match %s", discovery.Issuer, p.config.Issuer)
+	}
+
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, errors.Newf("(oauth.OIDCProvider.discover) incomplete discovery document from %s", discoveryURL)
+	}
+
+	p.discovery = &discovery
+	p.discoveredAt = time.Now()
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) getKey(ctx context.Context, keyID string) (interface{}, error) {
+	discovery, err := p.discover(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.getKey)")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.keys[keyID]
+	isStale := time.Since(p.keysFetchedAt) >= KEYS_CACHE_DURATION
+	if ok && !isStale {
+		return key, nil
+	}
+
+	if !isStale && time.Since(p.keysFetchedAt) < KEYS_REFETCH_INTERVAL {
+		return nil, errors.Newf("(oauth.OIDCProvider.getKey) unknown key %s", keyID)
+	}
+
+	var keySet jsonWebKeySet
+	err = p.getJSON(ctx, discovery.JWKSURI, &keySet)
+	if err != nil {
+		return nil, errors.Wrap(err, "(oauth.OIDCProvider.getKey) fetching keys")
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		publicKey, err := parseJSONWebKey(jwk)
+		if err != nil {
+			return nil, errors.Wrapf(err, "(oauth.OIDCProvider.getKey) parsing key %s", jwk.KeyID)
+		}
+
+		// Unsupported key types are skipped rather than failing every login
+		if publicKey != nil {
+			keys[jwk.KeyID] = publicKey
+		}
+	}
+
+	p.keys = keys
+	p.keysFetchedAt = time.Now()
+
+	key, ok = p.keys[keyID]
+	if !ok {
+		return nil, errors.Newf("(oauth.OIDCProvider.getKey) unknown key %s", keyID)
+	}
+
+	return key, nil
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "(oauth.OIDCProvider.getJSON) creating request")
+	}
+
+	resp, err := p.config.HTTPClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "(oauth.OIDCProvider.getJSON) fetching %s", url)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Newf("(oauth.OIDCProvider.getJSON) unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return errors.Wrapf(err, "(oauth.OIDCProvider.getJSON) decoding %s", url)
+	}
+
+	return nil
+}
+
+func parseJSONWebKey(jwk jsonWebKey) (interface{}, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, errors.Wrap(err, "(oauth.parseJSONWebKey) decoding modulus")
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, errors.Wrap(err, "(oauth.parseJSONWebKey) decoding exponent")
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return nil, nil
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, errors.Wrap(err, "(oauth.parseJSONWebKey) decoding x")
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, errors.Wrap(err, "(oauth.parseJSONWebKey) decoding y")
+		}
+
+		publicKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.New("(oauth.parseJSONWebKey) point is not on the curve")
+		}
+
+		return publicKey, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Providers that only accept the secret in the request body say so in the discovery document.
+// Without this the oauth2 package guesses, which costs a failed request on every login.
+func getAuthStyle(methods []string) oauth2.AuthStyle {
+	supportsPost := false
+	for _, method := range methods {
+		switch method {
+		case "client_secret_basic":
+			return oauth2.AuthStyleInHeader
+		case "client_secret_post":
+			supportsPost = true
+		}
+	}
+
+	if supportsPost {
+		return oauth2.AuthStyleInParams
+	}
+
+	return oauth2.AuthStyleAutoDetect
+}
@@ -0,0 +1,529 @@
+package oauth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.coaster.io/server/common/oauth"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const CLIENT_ID = "coaster-test-client"
+const NONCE = "test-nonce"
+
+// Stand-in identity provider serving discovery, keys and a token endpoint that hands out whatever
+// ID token the test asks for
+type fakeIdentityProvider struct {
+	server *httptest.Server
+
+	mu                 sync.Mutex
+	issuer             string
+	authMethods        []string
+	keyID              string
+	signingKey         interface{}
+	signingMethod      jwt.SigningMethod
+	claims             jwt.MapClaims
+	keysRequests       int
+	lastTokenRequest   url.Values
+	lastTokenBasicAuth [2]string
+}
+
+func newFakeIdentityProvider() *fakeIdentityProvider {
+	idp := &fakeIdentityProvider{authMethods: []string{"client_secret_basic"}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.handleDiscovery)
+	mux.HandleFunc("/keys", idp.handleKeys)
+	mux.HandleFunc("/token", idp.handleToken)
+	idp.server = httptest.NewServer(mux)
+	idp.issuer = idp.server.URL
+	idp.rotateRSAKey("key-1")
+
+	return idp
+}
+
+func (idp *fakeIdentityProvider) rotateRSAKey(keyID string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	Expect(err).NotTo(HaveOccurred())
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keyID = keyID
+	idp.signingKey = key
+	idp.signingMethod = jwt.SigningMethodRS256
+}
+
+func (idp *fakeIdentityProvider) useECKey(keyID string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keyID = keyID
+	idp.signingKey = key
+	idp.signingMethod = jwt.SigningMethodES256
+}
+
+func (idp *fakeIdentityProvider) validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"aud":            CLIENT_ID,
+		"sub":            "external-123",
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"nonce":          NONCE,
+		"email":          "traveler@example.com",
+		"email_verified": true,
+		"given_name":     "Ada",
+		"family_name":    "Lovelace",
+		"picture":        "https://example.com/ada.png",
+	}
+}
+
+func (idp *fakeIdentityProvider) setClaims(claims jwt.MapClaims) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.claims = claims
+}
+
+func (idp *fakeIdentityProvider) getKeysRequests() int {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	return idp.keysRequests
+}
+
+func (idp *fakeIdentityProvider) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                idp.issuer,
+		"authorization_endpoint":                idp.server.URL + "/authorize",
+		"token_endpoint":                        idp.server.URL + "/token",
+		"jwks_uri":                              idp.server.URL + "/keys",
+		"token_endpoint_auth_methods_supported": idp.authMethods,
+	})
+}
+
+func (idp *fakeIdentityProvider) handleKeys(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	idp.keysRequests++
+
+	var jwk map[string]string
+	switch key := idp.signingKey.(type) {
+	case *rsa.PrivateKey:
+		jwk = map[string]string{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": idp.keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case *ecdsa.PrivateKey:
+		jwk = map[string]string{
+			"kty": "EC",
+			"use": "sig",
+			"alg": "ES256",
+			"kid": idp.keyID,
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{jwk}})
+}
+
+func (idp *fakeIdentityProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+
+	r.ParseForm()
+	idp.lastTokenRequest = r.PostForm
+	username, password, _ := r.BasicAuth()
+	idp.lastTokenBasicAuth = [2]string{username, password}
+
+	token := jwt.NewWithClaims(idp.signingMethod, idp.claims)
+	token.Header["kid"] = idp.keyID
+	idToken, err := token.SignedString(idp.signingKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func newTestOIDCProvider(idp *fakeIdentityProvider) *oauth.OIDCProvider {
+	return oauth.NewOIDCProvider(oauth.OIDCConfig{
+		Provider: oauth.OauthProviderGoogle,
+		Issuer:   idp.server.URL,
+		ClientID: CLIENT_ID,
+		GetClientSecret: func(ctx context.Context) (string, error) {
+			return "client-secret", nil
+		},
+		Scopes:      []string{"openid", "email"},
+		RedirectURL: "http://localhost:8080/oauth_login",
+		AuthParams:  map[string]string{"prompt": "consent"},
+	})
+}
+
+var _ = Describe("OIDC provider", func() {
+	var idp *fakeIdentityProvider
+	var provider *oauth.OIDCProvider
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		idp = newFakeIdentityProvider()
+		provider = newTestOIDCProvider(idp)
+		idp.setClaims(idp.validClaims())
+	})
+
+	AfterEach(func() {
+		idp.server.Close()
+	})
+
+	It("builds the login URL from the discovery document", func() {
+		authURL, err := provider.AuthCodeURL(ctx, "state-value", NONCE)
+		Expect(err).NotTo(HaveOccurred())
+
+		parsed, err := url.Parse(authURL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Scheme + "://" + parsed.Host + parsed.Path).To(Equal(idp.server.URL + "/authorize"))
+
+		query := parsed.Query()
+		Expect(query.Get("client_id")).To(Equal(CLIENT_ID))
+		Expect(query.Get("redirect_uri")).To(Equal("http://localhost:8080/oauth_login"))
+		Expect(query.Get("response_type")).To(Equal("code"))
+		Expect(query.Get("scope")).To(Equal("openid email"))
+		Expect(query.Get("state")).To(Equal("state-value"))
+		Expect(query.Get("nonce")).To(Equal(NONCE))
+		Expect(query.Get("prompt")).To(Equal("consent"))
+	})
+
+	It("exchanges the code for the user's details", func() {
+		info, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*info).To(Equal(oauth.ExternalUserInfo{
+			ExternalID:        "external-123",
+			OauthProvider:     oauth.OauthProviderGoogle,
+			Email:             "traveler@example.com",
+			EmailVerified:     true,
+			FirstName:         "Ada",
+			LastName:          "Lovelace",
+			ProfilePictureURL: "https://example.com/ada.png",
+		}))
+
+		Expect(idp.lastTokenRequest.Get("code")).To(Equal("the-code"))
+		Expect(idp.lastTokenBasicAuth).To(Equal([2]string{CLIENT_ID, "client-secret"}))
+	})
+
+	It("accepts tokens signed with EC keys", func() {
+		idp.useECKey("ec-key")
+
+		info, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.ExternalID).To(Equal("external-123"))
+	})
+
+	It("rejects a token with a different nonce", func() {
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, "another-nonce")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects a token without a nonce", func() {
+		claims := idp.validClaims()
+		delete(claims, "nonce")
+		idp.setClaims(claims)
+
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects a token issued to another client", func() {
+		claims := idp.validClaims()
+		claims["aud"] = "someone-else"
+		idp.setClaims(claims)
+
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects a token from another issuer", func() {
+		claims := idp.validClaims()
+		claims["iss"] = "https://evil.example.com"
+		idp.setClaims(claims)
+
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects an expired token", func() {
+		claims := idp.validClaims()
+		claims["exp"] = time.Now().Add(-time.Hour).Unix()
+		idp.setClaims(claims)
+
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects a token without an expiry", func() {
+		claims := idp.validClaims()
+		delete(claims, "exp")
+		idp.setClaims(claims)
+
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects an unsigned token", func() {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, idp.validClaims())
+		rawIDToken, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = provider.VerifyIDToken(ctx, rawIDToken, NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects a token signed with a key the provider didn't publish", func() {
+		otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.validClaims())
+		token.Header["kid"] = "key-1"
+		rawIDToken, err := token.SignedString(otherKey)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = provider.VerifyIDToken(ctx, rawIDToken, NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("limits how often an unknown key triggers a refetch", func() {
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(idp.getKeysRequests()).To(Equal(1))
+
+		idp.rotateRSAKey("key-2")
+
+		_, err = provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		// Keys were just fetched, so the new key isn't picked up straight away
+		Expect(err).To(HaveOccurred())
+		Expect(idp.getKeysRequests()).To(Equal(1))
+	})
+
+	It("fetches keys once while they're cached", func() {
+		for i := 0; i < 3; i++ {
+			_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		Expect(idp.getKeysRequests()).To(Equal(1))
+	})
+
+	It("rejects a discovery document for another issuer", func() {
+		idp.mu.Lock()
+		idp.issuer = "https://evil.example.com"
+		idp.mu.Unlock()
+
+		_, err := provider.AuthCodeURL(ctx, "state-value", NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("accepts email_verified as a string", func() {
+		claims := idp.validClaims()
+		claims["email_verified"] = "false"
+		idp.setClaims(claims)
+
+		info, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.EmailVerified).To(BeFalse())
+	})
+})
+
+var _ = Describe("Apple provider", func() {
+	var idp *fakeIdentityProvider
+	var provider *oauth.OIDCProvider
+	var teamKey *ecdsa.PrivateKey
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		idp = newFakeIdentityProvider()
+		idp.authMethods = []string{"client_secret_post"}
+		idp.useECKey("apple-key")
+
+		var err error
+		teamKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		der, err := x509.MarshalPKCS8PrivateKey(teamKey)
+		Expect(err).NotTo(HaveOccurred())
+		pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+
+		provider = oauth.NewAppleProvider(oauth.AppleConfig{
+			Issuer:   idp.server.URL,
+			ClientID: CLIENT_ID,
+			TeamID:   "TEAM123",
+			KeyID:    "KEY123",
+			GetPrivateKey: func(ctx context.Context) (string, error) {
+				return pemKey, nil
+			},
+			RedirectURL: "http://localhost:8080/oauth_login",
+		})
+
+		claims := idp.validClaims()
+		delete(claims, "given_name")
+		delete(claims, "family_name")
+		delete(claims, "picture")
+		claims["email"] = "abc123@privaterelay.appleid.com"
+		claims["email_verified"] = "true"
+		claims["is_private_email"] = "true"
+		idp.setClaims(claims)
+	})
+
+	AfterEach(func() {
+		idp.server.Close()
+	})
+
+	It("asks Apple to post the callback", func() {
+		authURL, err := provider.AuthCodeURL(ctx, "state-value", NONCE)
+		Expect(err).NotTo(HaveOccurred())
+
+		parsed, err := url.Parse(authURL)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed.Query().Get("response_mode")).To(Equal("form_post"))
+		Expect(parsed.Query().Get("scope")).To(Equal("openid name email"))
+	})
+
+	It("signs the client secret with the team's key", func() {
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(idp.lastTokenRequest.Get("client_id")).To(Equal(CLIENT_ID))
+		clientSecret := idp.lastTokenRequest.Get("client_secret")
+
+		claims := jwt.RegisteredClaims{}
+		token, err := jwt.ParseWithClaims(clientSecret, &claims, func(token *jwt.Token) (interface{}, error) {
+			return &teamKey.PublicKey, nil
+		}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(idp.server.URL), jwt.WithIssuer("TEAM123"), jwt.WithExpirationRequired())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(token.Header["kid"]).To(Equal("KEY123"))
+		Expect(claims.Subject).To(Equal(CLIENT_ID))
+	})
+
+	It("reads the name Apple posts on first sign in", func() {
+		user := `{"name":{"firstName":"Ada","lastName":"Lovelace"},"email":"abc123@privaterelay.appleid.com"}`
+		info, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code", User: user}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.OauthProvider).To(Equal(oauth.OauthProviderApple))
+		Expect(info.FirstName).To(Equal("Ada"))
+		Expect(info.LastName).To(Equal("Lovelace"))
+		Expect(info.Email).To(Equal("abc123@privaterelay.appleid.com"))
+		Expect(info.EmailVerified).To(BeTrue())
+		Expect(info.IsPrivateEmail).To(BeTrue())
+	})
+
+	It("signs in without a name on later sign ins", func() {
+		info, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.FirstName).To(BeEmpty())
+		Expect(info.ExternalID).To(Equal("external-123"))
+	})
+
+	It("flags relay addresses even without the claim", func() {
+		claims := idp.validClaims()
+		claims["email"] = "abc123@privaterelay.appleid.com"
+		idp.setClaims(claims)
+
+		info, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.IsPrivateEmail).To(BeTrue())
+	})
+})
+
+var _ = Describe("Facebook provider", func() {
+	var server *httptest.Server
+	var provider *oauth.FacebookProvider
+	var user map[string]interface{}
+	var lastMeQuery url.Values
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		user = map[string]interface{}{
+			"id":         "fb-123",
+			"email":      "traveler@example.com",
+			"first_name": "Ada",
+			"last_name":  "Lovelace",
+			"picture":    map[string]interface{}{"data": map[string]interface{}{"url": "https://example.com/ada.png", "is_silhouette": false}},
+		}
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "access-token", "token_type": "bearer"})
+		})
+		mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+			lastMeQuery = r.URL.Query()
+			json.NewEncoder(w).Encode(user)
+		})
+		server = httptest.NewServer(mux)
+
+		provider = oauth.NewFacebookProvider(oauth.FacebookConfig{
+			AppID: CLIENT_ID,
+			GetAppSecret: func(ctx context.Context) (string, error) {
+				return "app-secret", nil
+			},
+			RedirectURL: "http://localhost:8080/oauth_login",
+			AuthURL:     server.URL + "/dialog/oauth",
+			GraphURL:    server.URL,
+		})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("fetches the user from the Graph API", func() {
+		info, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*info).To(Equal(oauth.ExternalUserInfo{
+			ExternalID:        "fb-123",
+			OauthProvider:     oauth.OauthProviderFacebook,
+			Email:             "traveler@example.com",
+			EmailVerified:     false,
+			FirstName:         "Ada",
+			LastName:          "Lovelace",
+			ProfilePictureURL: "https://example.com/ada.png",
+		}))
+		Expect(lastMeQuery.Get("appsecret_proof")).NotTo(BeEmpty())
+	})
+
+	It("refuses accounts without an email", func() {
+		delete(user, "email")
+
+		_, err := provider.Exchange(ctx, oauth.Callback{Code: "the-code"}, NONCE)
+		Expect(err).To(HaveOccurred())
+	})
+})
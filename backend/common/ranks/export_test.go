@@ -0,0 +1,4 @@
+package ranks
+
+// Exposes internals to the external test package
+var Midpoint = midpoint
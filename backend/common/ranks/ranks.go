@@ -0,0 +1,184 @@
+package ranks
+
+import (
+	"strings"
+
+	"go.coaster.io/server/common/errors"
+)
+
+// Ranks are base 36 fractions without the leading "0.", so "i" sits halfway between "" and "z" and
+// there's always room for another key between any two. Sorting the keys as plain bytes gives the
+// order, which is why rank columns use COLLATE "C". Keys never end in "0", since nothing could be
+// placed just before them.
+const DIGITS = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// Repeatedly inserting into the same gap grows keys by about one character per insert. Once a key
+// gets this long the whole set is respaced, which keeps keys short without rewriting on every move.
+const MAX_LENGTH = 16
+
+var ErrNeedsRebalance = errors.New("ranks need rebalancing")
+
+// Something that's ordered by rank, like a listing image or an itinerary step
+type Item struct {
+	ID   int64
+	Rank string
+}
+
+// Returns a key that sorts strictly between before and after. An empty before means the start of
+// the list and an empty after means the end.
+func Between(before string, after string) (string, error) {
+	if !isValid(before) || !isValid(after) {
+		return "", errors.Newf("(ranks.Between) invalid rank %q or %q", before, after)
+	}
+
+	if after != "" && before >= after {
+		return "", ErrNeedsRebalance
+	}
+
+	return midpoint(before, after), nil
+}
+
+// Evenly spaced keys for n items, used for new lists and for rebalancing
+func Spread(n int) []string {
+	// Enough digits that neighbouring keys are at least 36 apart, leaving room for later inserts
+	width := 1
+	capacity := 36
+	for capacity < (n+1)*36 {
+		width++
+		capacity *= 36
+	}
+
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = format((i+1)*capacity/(n+1), width)
+	}
+
+	return keys
+}
+
+// Computes the new rank for moving an item to just after afterID, or to the front when afterID is
+// nil. Items must be in their current order. Returns ErrNeedsRebalance when the neighbours' keys are
+// equal or too long, in which case the caller should respace the set with Spread and try again.
+func Move(items []Item, itemID int64, afterID *int64) (string, error) {
+	found := false
+	others := make([]Item, 0, len(items))
+	for _, item := range items {
+		if item.ID == itemID {
+			found = true
+			continue
+		}
+
+		others = append(others, item)
+	}
+
+	if !found {
+		return "", errors.NewBadRequestf("Invalid ID: %d", itemID)
+	}
+
+	position := 0
+	if afterID != nil {
+		position = -1
+		for i, item := range others {
+			if item.ID == *afterID {
+				position = i + 1
+				break
+			}
+		}
+
+		if position == -1 {
+			return "", errors.NewBadRequestf("Invalid ID: %d", *afterID)
+		}
+	}
+
+	var before, after string
+	if position > 0 {
+		before = others[position-1].Rank
+	}
+	if position < len(others) {
+		after = others[position].Rank
+	}
+
+	rank, err := Between(before, after)
+	if err != nil {
+		return "", err
+	}
+
+	if len(rank) > MAX_LENGTH {
+		return "", ErrNeedsRebalance
+	}
+
+	return rank, nil
+}
+
+// Finds a key between a and b, treating both as fractions. The empty string stands in for 0 as a
+// lower bound and for 1 as an upper bound.
+func midpoint(a string, b string) string {
+	// Skip the shared prefix, padding a with zeros since trailing zeros don't change its value
+	n := 0
+	for n < len(b) && charAt(a, n) == b[n] {
+		n++
+	}
+
+	if n > 0 {
+		rest := ""
+		if n < len(a) {
+			rest = a[n:]
+		}
+
+		return b[:n] + midpoint(rest, b[n:])
+	}
+
+	digitA := digitAt(a, 0)
+	digitB := len(DIGITS)
+	if b != "" {
+		digitB = strings.IndexByte(DIGITS, b[0])
+	}
+
+	if digitB-digitA > 1 {
+		return string(DIGITS[(digitA+digitB+1)/2])
+	}
+
+	// The first digits are adjacent. If b has more digits, its first digit alone is already in between.
+	if len(b) > 1 {
+		return b[:1]
+	}
+
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+
+	return string(DIGITS[digitA]) + midpoint(rest, "")
+}
+
+func charAt(key string, i int) byte {
+	if i < len(key) {
+		return key[i]
+	}
+
+	return DIGITS[0]
+}
+
+func digitAt(key string, i int) int {
+	return strings.IndexByte(DIGITS, charAt(key, i))
+}
+
+func format(value int, width int) string {
+	digits := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		digits[i] = DIGITS[value%36]
+		value /= 36
+	}
+
+	return strings.TrimRight(string(digits), "0")
+}
+
+func isValid(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(DIGITS, key[i]) == -1 {
+			return false
+		}
+	}
+
+	return !strings.HasSuffix(key, "0")
+}
@@ -0,0 +1,13 @@
+package ranks_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestRanks(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ranks Suite")
+}
@@ -0,0 +1,119 @@
+package ranks_test
+
+import (
+	"strings"
+
+	"go.coaster.io/server/common/ranks"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func expectOrdered(keys ...string) {
+	for i := 1; i < len(keys); i++ {
+		ExpectWithOffset(1, keys[i-1] < keys[i]).To(BeTrue(), "expected %q < %q", keys[i-1], keys[i])
+	}
+}
+
+var _ = Describe("Ranks", func() {
+	DescribeTable("Between",
+		func(before string, after string, expected string) {
+			rank, err := ranks.Between(before, after)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rank).To(Equal(expected))
+			Expect(strings.HasSuffix(rank, "0")).To(BeFalse())
+
+			if after == "" {
+				expectOrdered(before, rank)
+			} else {
+				expectOrdered(before, rank, after)
+			}
+		},
+		Entry("empty list", "", "", "i"),
+		Entry("start of the list", "", "i", "9"),
+		Entry("end of the list", "i", "", "r"),
+		Entry("before the smallest single digit", "", "1", "0i"),
+		Entry("after the largest single digit", "z", "", "zi"),
+		Entry("gap of two digits", "a", "c", "b"),
+		Entry("adjacent keys", "a", "b", "ai"),
+		Entry("adjacent keys where the upper key is longer", "a", "b1", "b"),
+		Entry("carry past a trailing z", "az", "b", "azi"),
+		Entry("carry past several trailing zs", "azz", "b", "azzi"),
+		Entry("shared prefix", "ab", "ad", "ac"),
+		Entry("upper key extends the lower key", "a", "a1", "a0i"),
+		Entry("upper key with a leading zero", "", "01", "00i"),
+	)
+
+	DescribeTable("Between errors",
+		func(before string, after string, expected error) {
+			_, err := ranks.Between(before, after)
+			if expected != nil {
+				Expect(err).To(MatchError(expected))
+			} else {
+				Expect(err).To(HaveOccurred())
+			}
+		},
+		Entry("equal keys", "a", "a", ranks.ErrNeedsRebalance),
+		Entry("keys out of order", "b", "a", ranks.ErrNeedsRebalance),
+		Entry("trailing zero", "a0", "", nil),
+		Entry("uppercase digit", "", "A", nil),
+	)
+
+	DescribeTable("midpoint",
+		func(a string, b string, expected string) {
+			Expect(ranks.Midpoint(a, b)).To(Equal(expected))
+		},
+		Entry("empty bounds", "", "", "i"),
+		Entry("empty upper bound rounds up", "a", "", "n"),
+		Entry("empty lower bound", "", "2", "1"),
+		Entry("lower bound padded with zeros", "", "001", "000i"),
+		Entry("adjacent digits descend into the lower key", "y", "z", "yi"),
+	)
+
+	It("keeps finding keys when inserting into the same gap", func() {
+		before, after := "a", "b"
+		for i := 0; i < 50; i++ {
+			rank, err := ranks.Between(before, after)
+			Expect(err).NotTo(HaveOccurred())
+			expectOrdered(before, rank, after)
+			after = rank
+		}
+	})
+
+	DescribeTable("Spread",
+		func(n int, expected []string) {
+			keys := ranks.Spread(n)
+			Expect(keys).To(HaveLen(n))
+			if expected != nil {
+				Expect(keys).To(Equal(expected))
+			}
+
+			expectOrdered(keys...)
+			for i, key := range keys {
+				Expect(key).NotTo(BeEmpty())
+				Expect(strings.HasSuffix(key, "0")).To(BeFalse())
+
+				// Every gap, including the ends of the list, has room for a short key
+				before := ""
+				if i > 0 {
+					before = keys[i-1]
+				}
+				rank, err := ranks.Between(before, key)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(len(rank)).To(BeNumerically("<=", len(key)+1))
+			}
+		},
+		Entry("no items", 0, []string{}),
+		Entry("one item", 1, []string{"i"}),
+		Entry("two items", 2, []string{"c", "o"}),
+		Entry("largest count with single digit keys", 35, nil),
+		Entry("smallest count with longer keys", 36, nil),
+		Entry("many items", 1000, nil),
+	)
+
+	It("uses single digit keys up to 35 items", func() {
+		for _, key := range ranks.Spread(35) {
+			Expect(key).To(HaveLen(1))
+		}
+	})
+})
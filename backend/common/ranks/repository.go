@@ -0,0 +1,152 @@
+package ranks
+
+import (
+	"fmt"
+
+	"go.coaster.io/server/common/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// An ordered set of rows, e.g. the images for one listing. Table and column names come from code,
+// never from requests, so they're safe to build queries with.
+type Scope struct {
+	Table       string
+	RankColumn  string
+	ScopeColumn string
+	ScopeID     int64
+}
+
+// Moves one row to just after afterID, or to the front when afterID is nil. Only the moved row is
+// updated unless the set needs rebalancing first.
+func MoveRow(db *gorm.DB, scope Scope, itemID int64, afterID *int64) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		items, err := scope.load(tx)
+		if err != nil {
+			return err
+		}
+
+		rank, err := Move(items, itemID, afterID)
+		if errors.Is(err, ErrNeedsRebalance) {
+			items, err = scope.rebalance(tx, items)
+			if err != nil {
+				return err
+			}
+
+			rank, err = Move(items, itemID, afterID)
+		}
+		if err != nil {
+			return err
+		}
+
+		return scope.update(tx, itemID, rank)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "(ranks.MoveRow) moving %s %d", scope.Table, itemID)
+	}
+
+	return nil
+}
+
+// Returns the rank for a new row at the end of the set. Concurrent inserts can end up with the same
+// rank, so rows are always sorted by ID as well, and the tie is broken for good on the next rebalance.
+func NextRank(db *gorm.DB, scope Scope) (string, error) {
+	items, err := scope.load(db)
+	if err != nil {
+		return "", errors.Wrap(err, "(ranks.NextRank)")
+	}
+
+	if len(items) == 0 {
+		return Spread(1)[0], nil
+	}
+
+	return Between(items[len(items)-1].Rank, "")
+}
+
+// Respaces the set in the given order. Rows can be left out, e.g. when the client only shows part of
+// the set, in which case the given rows are shuffled among the positions they already hold and the
+// rest stay where they are.
+func Reorder(db *gorm.DB, scope Scope, orderedIDs []int64) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		items, err := scope.load(tx)
+		if err != nil {
+			return err
+		}
+
+		existingIDs := make(map[int64]bool)
+		for _, item := range items {
+			existingIDs[item.ID] = true
+		}
+
+		reorderedIDs := make(map[int64]bool)
+		for _, id := range orderedIDs {
+			if !existingIDs[id] || reorderedIDs[id] {
+				return errors.NewBadRequestf("Invalid ID: %d", id)
+			}
+
+			reorderedIDs[id] = true
+		}
+
+		ordered := make([]Item, len(items))
+		next := 0
+		for i, item := range items {
+			if reorderedIDs[item.ID] {
+				ordered[i] = Item{ID: orderedIDs[next]}
+				next++
+			} else {
+				ordered[i] = Item{ID: item.ID}
+			}
+		}
+
+		_, err = scope.rebalance(tx, ordered)
+		return err
+	})
+	if err != nil {
+		return errors.Wrapf(err, "(ranks.Reorder) reordering %s", scope.Table)
+	}
+
+	return nil
+}
+
+// Locks the rows so concurrent moves in the same set are applied one at a time
+func (s Scope) load(db *gorm.DB) ([]Item, error) {
+	var items []Item
+	result := db.Table(s.Table).
+		Select(fmt.Sprintf("id, %s AS rank", s.RankColumn)).
+		Where(fmt.Sprintf("%s = ?", s.ScopeColumn), s.ScopeID).
+		Where("deactivated_at IS NULL").
+		Order(fmt.Sprintf("%s ASC, id ASC", s.RankColumn)).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Find(&items)
+	if result.Error != nil {
+		return nil, errors.Wrapf(result.Error, "(ranks.Scope.load) loading %s", s.Table)
+	}
+
+	return items, nil
+}
+
+func (s Scope) rebalance(db *gorm.DB, items []Item) ([]Item, error) {
+	keys := Spread(len(items))
+	rebalanced := make([]Item, len(items))
+	for i, item := range items {
+		err := s.update(db, item.ID, keys[i])
+		if err != nil {
+			return nil, err
+		}
+
+		rebalanced[i] = Item{ID: item.ID, Rank: keys[i]}
+	}
+
+	return rebalanced, nil
+}
+
+func (s Scope) update(db *gorm.DB, id int64, rank string) error {
+	result := db.Table(s.Table).
+		Where("id = ?", id).
+		Update(s.RankColumn, rank)
+	if result.Error != nil {
+		return errors.Wrapf(result.Error, "(ranks.Scope.update) updating %s %d", s.Table, id)
+	}
+
+	return nil
+}
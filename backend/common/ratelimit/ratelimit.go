@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Keys that haven't been seen for this long are dropped so the map doesn't grow forever
+const IDLE_EXPIRATION = 10 * time.Minute
+
+type entry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Token bucket per key, e.g. per client IP. State is kept in memory, so each server instance
+// enforces its own limit.
+type Limiter struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	limit     rate.Limit
+	burst     int
+	lastSweep time.Time
+}
+
+// Allows requestsPerMinute on average, with bursts of up to burst requests
+func NewLimiter(requestsPerMinute int, burst int) *Limiter {
+	return &Limiter{
+		entries:   make(map[string]*entry),
+		limit:     rate.Limit(float64(requestsPerMinute) / 60),
+		burst:     burst,
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastSweep) > IDLE_EXPIRATION {
+		for entryKey, entry := range l.entries {
+			if now.Sub(entry.lastSeen) > IDLE_EXPIRATION {
+				delete(l.entries, entryKey)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	keyEntry, ok := l.entries[key]
+	if !ok {
+		keyEntry = &entry{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.entries[key] = keyEntry
+	}
+	keyEntry.lastSeen = now
+
+	return keyEntry.limiter.AllowN(now, 1)
+}
+
+// Cloud Run appends the address it received the request from to X-Forwarded-For, so the last entry
+// is the only one the client can't spoof
+func GetClientIP(r *http.Request) string {
+	forwardedFor := r.Header.Get("X-Forwarded-For")
+	if len(forwardedFor) > 0 {
+		addresses := strings.Split(forwardedFor, ",")
+		return strings.TrimSpace(addresses[len(addresses)-1])
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -252,3 +252,44 @@ func LoadAvailabilityInRange(db *gorm.DB, listing models.Listing, startDate time
 
 	return availability, nil
 }
+
+// Copies the active rules and time slots from one listing to another, used when duplicating a listing
+func CopyForListing(db *gorm.DB, fromListingID int64, toListingID int64) error {
+	rules, err := LoadForListing(db, fromListingID)
+	if err != nil {
+		return errors.Wrap(err, "(availability_rules.CopyForListing) loading rules")
+	}
+
+	for _, rule := range rules {
+		availabilityRule := models.AvailabilityRule{
+			ListingID:       toListingID,
+			Name:            rule.Name,
+			Type:            rule.Type,
+			StartDate:       rule.StartDate,
+			EndDate:         rule.EndDate,
+			RecurringYears:  rule.RecurringYears,
+			RecurringMonths: rule.RecurringMonths,
+		}
+
+		result := db.Create(&availabilityRule)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "(availability_rules.CopyForListing) creating rule")
+		}
+
+		for _, slot := range rule.TimeSlots {
+			timeSlot := models.TimeSlot{
+				AvailabilityRuleID: availabilityRule.ID,
+				StartTime:          slot.StartTime,
+				Capacity:           slot.Capacity,
+				DayOfWeek:          slot.DayOfWeek,
+			}
+
+			result := db.Create(&timeSlot)
+			if result.Error != nil {
+				return errors.Wrapf(result.Error, "(availability_rules.CopyForListing) creating time slot: %+v", timeSlot)
+			}
+		}
+	}
+
+	return nil
+}
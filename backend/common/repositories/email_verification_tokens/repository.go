@@ -0,0 +1,98 @@
+package email_verification_tokens
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/sessions"
+	"gorm.io/gorm"
+)
+
+const TOKEN_BITS = 256
+const TOKEN_EXPIRATION = time.Hour * 24 * 3 // 3 days
+
+// Limits on resending, so the endpoint can't be used to flood someone's inbox
+const RESEND_COOLDOWN = time.Minute
+const MAX_SENDS_PER_DAY = 5
+
+// Returns the raw token to put in the email. Earlier tokens stay valid until they expire, so any of
+// the emails the user received will work.
+func Create(db *gorm.DB, user *models.User) (*string, error) {
+	rawToken, err := generateToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "(email_verification_tokens.Create)")
+	}
+
+	// Stored hashed like sessions, since a leaked token would let someone verify the address
+	verificationToken := models.EmailVerificationToken{
+		UserID:     user.ID,
+		Email:      user.Email,
+		Token:      sessions.HashToken(*rawToken),
+		Expiration: time.Now().Add(TOKEN_EXPIRATION),
+	}
+
+	result := db.Create(&verificationToken)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(email_verification_tokens.Create)")
+	}
+
+	return rawToken, nil
+}
+
+func LoadValidByToken(db *gorm.DB, rawToken string) (*models.EmailVerificationToken, error) {
+	var verificationToken models.EmailVerificationToken
+	result := db.Table("email_verification_tokens").
+		Select("email_verification_tokens.*").
+		Where("email_verification_tokens.token = ?", sessions.HashToken(rawToken)).
+		Where("email_verification_tokens.expiration >= ?", time.Now()).
+		Where("email_verification_tokens.deactivated_at IS NULL").
+		Take(&verificationToken)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(email_verification_tokens.LoadValidByToken)")
+	}
+
+	return &verificationToken, nil
+}
+
+// Includes tokens that have since been used or expired, since those emails were still sent
+func LoadSentSince(db *gorm.DB, userID int64, since time.Time) ([]models.EmailVerificationToken, error) {
+	var verificationTokens []models.EmailVerificationToken
+	result := db.Table("email_verification_tokens").
+		Select("email_verification_tokens.*").
+		Where("email_verification_tokens.user_id = ?", userID).
+		Where("email_verification_tokens.created_at >= ?", since).
+		Order("email_verification_tokens.created_at DESC").
+		Find(&verificationTokens)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(email_verification_tokens.LoadSentSince)")
+	}
+
+	return verificationTokens, nil
+}
+
+// Once the address is verified, none of the other outstanding emails should work
+func DeactivateAllForUser(db *gorm.DB, userID int64) error {
+	result := db.Table("email_verification_tokens").
+		Where("email_verification_tokens.user_id = ?", userID).
+		Where("email_verification_tokens.deactivated_at IS NULL").
+		Update("deactivated_at", time.Now())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(email_verification_tokens.DeactivateAllForUser)")
+	}
+
+	return nil
+}
+
+func generateToken() (*string, error) {
+	b := make([]byte, TOKEN_BITS/8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "(email_verification_tokens.generateToken)")
+	}
+
+	token := fmt.Sprintf("%x", b)
+	return &token, nil
+}
@@ -6,10 +6,11 @@ import (
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/input"
 	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/ranks"
 	"gorm.io/gorm"
 )
 
-func createItineraryStep(db *gorm.DB, listingID int64, stepInput input.ItineraryStep, stepOrder int64) (*models.ItineraryStep, error) {
+func createItineraryStep(db *gorm.DB, listingID int64, stepInput input.ItineraryStep, stepOrder string) (*models.ItineraryStep, error) {
 	if stepInput.Title == nil || stepInput.Description == nil || stepInput.StepLabel == nil {
 		return nil, errors.NewCustomerVisibleError("Missing required fields for new itinerary step")
 	}
@@ -30,7 +31,7 @@ func createItineraryStep(db *gorm.DB, listingID int64, stepInput input.Itinerary
 	return &itineraryStep, nil
 }
 
-func updateItineraryStep(db *gorm.DB, step models.ItineraryStep, stepInput input.ItineraryStep, stepOrder int64) (*models.ItineraryStep, error) {
+func updateItineraryStep(db *gorm.DB, step models.ItineraryStep, stepInput input.ItineraryStep, stepOrder string) (*models.ItineraryStep, error) {
 	if stepInput.Title != nil {
 		step.Title = *stepInput.Title
 	}
@@ -53,6 +54,7 @@ func updateItineraryStep(db *gorm.DB, step models.ItineraryStep, stepInput input
 	return &step, nil
 }
 
+// Replaces the whole itinerary in the given order. Use MoveItineraryStep to reorder a single step.
 func UpdateItinerarySteps(db *gorm.DB, listingID int64, itineraryStepInput []input.ItineraryStep) ([]models.ItineraryStep, error) {
 	existingSteps, err := LoadItineraryForListing(db, listingID)
 	if err != nil {
@@ -66,45 +68,70 @@ func UpdateItinerarySteps(db *gorm.DB, listingID int64, itineraryStepInput []inp
 
 	var newSteps []models.ItineraryStep = make([]models.ItineraryStep, len(itineraryStepInput))
 	var updatedStepIds map[int64]bool = make(map[int64]bool)
-	for i, stepInput := range itineraryStepInput {
-		if stepInput.ID == nil {
-			// Create a new itinerary step
-			newStep, err := createItineraryStep(db, listingID, stepInput, int64(i))
-			if err != nil {
-				return nil, errors.Wrap(err, "(itinerary_steps.UpdateItinerarySteps) creating new itinerary step")
+	stepOrders := ranks.Spread(len(itineraryStepInput))
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i, stepInput := range itineraryStepInput {
+			if stepInput.ID == nil {
+				// Create a new itinerary step
+				newStep, err := createItineraryStep(tx, listingID, stepInput, stepOrders[i])
+				if err != nil {
+					return errors.Wrap(err, "creating new itinerary step")
+				}
+
+				newSteps[i] = *newStep
+			} else {
+				// Update existing step
+				existingStep, ok := itineraryStepIdMap[*stepInput.ID]
+				if !ok {
+					return errors.NewCustomerVisibleErrorf("Invalid itinerary step ID: %d", *stepInput.ID)
+				}
+
+				updatedStep, err := updateItineraryStep(tx, existingStep, stepInput, stepOrders[i])
+				if err != nil {
+					return errors.Wrap(err, "updating itinerary step")
+				}
+
+				updatedStepIds[existingStep.ID] = true
+
+				newSteps[i] = *updatedStep
 			}
+		}
 
-			newSteps[i] = *newStep
-		} else {
-			// Update existing step
-			existingStep, ok := itineraryStepIdMap[*stepInput.ID]
+		for _, step := range existingSteps {
+			// Delete any existing steps that weren't in the input list
+			_, ok := updatedStepIds[step.ID]
 			if !ok {
-				return nil, errors.NewCustomerVisibleErrorf("Invalid itinerary step ID: %d", stepInput.ID)
+				result := tx.Model(step).Update("deactivated_at", time.Now())
+				if result.Error != nil {
+					return errors.Wrapf(result.Error, "deleting itinerary step %+v", step)
+				}
 			}
+		}
 
-			updatedStep, err := updateItineraryStep(db, existingStep, stepInput, int64(i))
-			if err != nil {
-				return nil, errors.Wrap(err, "(itinerary_steps.UpdateItinerarySteps) updating itinerary step")
-			}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "(itinerary_steps.UpdateItinerarySteps)")
+	}
 
-			updatedStepIds[existingStep.ID] = true
+	return newSteps, nil
+}
 
-			newSteps[i] = *updatedStep
-		}
+// Moves a step to just after another one, or to the front when afterStepID is nil
+func MoveItineraryStep(db *gorm.DB, listingID int64, stepID int64, afterStepID *int64) error {
+	scope := ranks.Scope{
+		Table:       "itinerary_steps",
+		RankColumn:  "step_order",
+		ScopeColumn: "listing_id",
+		ScopeID:     listingID,
 	}
 
-	for _, step := range existingSteps {
-		// Delete any existing steps that weren't in the input list
-		_, ok := updatedStepIds[step.ID]
-		if !ok {
-			result := db.Model(step).Update("deactivated_at", time.Now())
-			if result.Error != nil {
-				return nil, errors.Wrapf(err, "(itinerary_steps.UpdateItinerarySteps) deleting itinerary step %+v", step)
-			}
-		}
+	err := ranks.MoveRow(db, scope, stepID, afterStepID)
+	if err != nil {
+		return errors.Wrap(err, "(itinerary_steps.MoveItineraryStep)")
 	}
 
-	return newSteps, nil
+	return nil
 }
 
 func LoadItineraryForListing(db *gorm.DB, listingID int64) ([]models.ItineraryStep, error) {
@@ -113,7 +140,7 @@ func LoadItineraryForListing(db *gorm.DB, listingID int64) ([]models.ItinerarySt
 		Select("itinerary_steps.*").
 		Where("itinerary_steps.listing_id = ?", listingID).
 		Where("itinerary_steps.deactivated_at IS NULL").
-		Order("itinerary_steps.step_order ASC").
+		Order("itinerary_steps.step_order ASC, itinerary_steps.id ASC").
 		Find(&itinerarySteps)
 	if result.Error != nil {
 		// Not guaranteed to have any itinerary steps for a listing so just return an empty slice
@@ -126,3 +153,31 @@ func LoadItineraryForListing(db *gorm.DB, listingID int64) ([]models.ItinerarySt
 
 	return itinerarySteps, nil
 }
+
+// Copies the itinerary from one listing to another, returning the new step ID for each original step
+func CopyForListing(db *gorm.DB, fromListingID int64, toListingID int64) (map[int64]int64, error) {
+	steps, err := LoadItineraryForListing(db, fromListingID)
+	if err != nil {
+		return nil, errors.Wrap(err, "(itinerary_steps.CopyForListing) loading itinerary steps")
+	}
+
+	stepIDs := make(map[int64]int64)
+	for _, step := range steps {
+		itineraryStep := models.ItineraryStep{
+			ListingID:   toListingID,
+			Title:       step.Title,
+			Description: step.Description,
+			StepLabel:   step.StepLabel,
+			StepOrder:   step.StepOrder,
+		}
+
+		result := db.Create(&itineraryStep)
+		if result.Error != nil {
+			return nil, errors.Wrap(result.Error, "(itinerary_steps.CopyForListing) creating itinerary step")
+		}
+
+		stepIDs[step.ID] = itineraryStep.ID
+	}
+
+	return stepIDs, nil
+}
@@ -0,0 +1,147 @@
+package listings
+
+import (
+	"fmt"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/images"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/availability_rules"
+	"go.coaster.io/server/common/repositories/itinerary_steps"
+	"go.coaster.io/server/common/repositories/meeting_points"
+	"gorm.io/gorm"
+)
+
+// The storage objects copied for one gallery item. Variants are copied alongside the still under
+// matching IDs.
+type CopiedMedia struct {
+	StorageID       string
+	PosterStorageID *string
+}
+
+// Deep copies a listing into a new draft for the same host. Images and videos point at the copied
+// storage objects in copiedMedia, keyed by the original image ID. Bookings and revision history stay with
+// the original listing.
+func DuplicateListing(db *gorm.DB, source ListingDetails, copiedMedia map[int64]CopiedMedia) (*ListingDetails, error) {
+	listing := source.Listing
+	listing.BaseModel = models.BaseModel{}
+	listing.Status = models.ListingStatusDraft
+	if source.Name != nil {
+		name := fmt.Sprintf("%s (copy)", *source.Name)
+		listing.Name = &name
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Create(&listing)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "(listings.DuplicateListing) creating listing")
+		}
+
+		categories := []models.ListingCategoryType{}
+		for _, category := range source.Categories {
+			categories = append(categories, category.Category)
+		}
+
+		// Special categories are skipped here since they're chosen by admins for the original listing
+		err := updateListingCategories(tx, listing.ID, categories)
+		if err != nil {
+			return errors.Wrap(err, "(listings.DuplicateListing) copying categories")
+		}
+
+		for _, image := range source.Images {
+			copied, ok := copiedMedia[image.ID]
+			if !ok {
+				return errors.Newf("(listings.DuplicateListing) missing copied storage object for image %d", image.ID)
+			}
+
+			copiedImage := models.ListingImage{
+				ListingID:       listing.ID,
+				MediaType:       image.MediaType,
+				Projection:      image.Projection,
+				StorageID:       copied.StorageID,
+				ContentType:     image.ContentType,
+				DurationMs:      image.DurationMs,
+				PosterStorageID: copied.PosterStorageID,
+				Rank:            image.Rank,
+				Width:           image.Width,
+				Height:          image.Height,
+				Blurhash:        image.Blurhash,
+				DominantColor:   image.DominantColor,
+			}
+			copiedImage.Variants = images.RenameVariants(image.Variants, images.GetStillStorageID(copiedImage))
+
+			_, err := CreateListingImage(tx, copiedImage)
+			if err != nil {
+				return errors.Wrap(err, "(listings.DuplicateListing) copying image")
+			}
+		}
+
+		stepIDs, err := itinerary_steps.CopyForListing(tx, source.ID, listing.ID)
+		if err != nil {
+			return errors.Wrap(err, "(listings.DuplicateListing)")
+		}
+
+		err = meeting_points.CopyForListing(tx, source.ID, listing.ID)
+		if err != nil {
+			return errors.Wrap(err, "(listings.DuplicateListing)")
+		}
+
+		err = availability_rules.CopyForListing(tx, source.ID, listing.ID)
+		if err != nil {
+			return errors.Wrap(err, "(listings.DuplicateListing)")
+		}
+
+		err = copyTranslations(tx, source.ID, listing.ID, stepIDs)
+		if err != nil {
+			return errors.Wrap(err, "(listings.DuplicateListing)")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return loadDetailsForListing(db, listing)
+}
+
+func copyTranslations(db *gorm.DB, fromListingID int64, toListingID int64, stepIDs map[int64]int64) error {
+	translations, err := LoadTranslations(db, fromListingID)
+	if err != nil {
+		return errors.Wrap(err, "(listings.copyTranslations) loading translations")
+	}
+
+	for _, translation := range translations {
+		result := db.Create(&models.ListingTranslation{
+			ListingID:        toListingID,
+			Locale:           translation.Locale,
+			Name:             translation.Name,
+			Description:      translation.Description,
+			ShortDescription: translation.ShortDescription,
+			Highlights:       translation.Highlights,
+		})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "(listings.copyTranslations) creating translation")
+		}
+
+		for _, stepTranslation := range translation.ItinerarySteps {
+			stepID, ok := stepIDs[stepTranslation.ItineraryStepID]
+			if !ok {
+				continue
+			}
+
+			result := db.Create(&models.ItineraryStepTranslation{
+				ItineraryStepID: stepID,
+				Locale:          stepTranslation.Locale,
+				Title:           stepTranslation.Title,
+				Description:     stepTranslation.Description,
+				StepLabel:       stepTranslation.StepLabel,
+			})
+			if result.Error != nil {
+				return errors.Wrap(result.Error, "(listings.copyTranslations) creating itinerary step translation")
+			}
+		}
+	}
+
+	return nil
+}
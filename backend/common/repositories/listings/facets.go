@@ -0,0 +1,170 @@
+package listings
+
+import (
+	"github.com/lib/pq"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"gorm.io/gorm"
+)
+
+// Lower bounds of each bucket, the last bucket has no upper bound
+var PRICE_FACET_BUCKETS = []int64{0, 50, 100, 250, 500}
+var DURATION_FACET_BUCKETS = []int64{0, 120, 240, 480, 1440} // Minutes
+
+type SearchFacets struct {
+	Categories      []FacetCount       `json:"categories"`
+	Countries       []FacetCount       `json:"countries"`
+	Regions         []RegionFacetCount `json:"regions"`
+	PriceBuckets    []RangeFacetCount  `json:"price_buckets"`
+	DurationBuckets []RangeFacetCount  `json:"duration_buckets"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type RegionFacetCount struct {
+	Country string `json:"country"`
+	Region  string `json:"region"`
+	Count   int64  `json:"count"`
+}
+
+// Min and Max are inclusive so they can be passed straight back as search filters. Max is nil for the last bucket.
+type RangeFacetCount struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max"`
+	Count int64  `json:"count"`
+}
+
+type bucketCount struct {
+	Bucket int
+	Count  int64
+}
+
+// Counts are computed over the filtered search results, except that each facet ignores its own filter so that
+// the other options for that facet are still shown once one is selected.
+func LoadSearchFacets(db *gorm.DB, params SearchParams) (*SearchFacets, error) {
+	categories, err := loadCategoryFacet(db, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.LoadSearchFacets) loading categories")
+	}
+
+	countries, err := loadCountryFacet(db, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.LoadSearchFacets) loading countries")
+	}
+
+	regions, err := loadRegionFacet(db, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.LoadSearchFacets) loading regions")
+	}
+
+	priceParams := params
+	priceParams.MinPrice = nil
+	priceParams.MaxPrice = nil
+	priceBuckets, err := loadRangeFacet(db, priceParams, "listings.price", PRICE_FACET_BUCKETS)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.LoadSearchFacets) loading price buckets")
+	}
+
+	durationParams := params
+	durationParams.MinDuration = nil
+	durationParams.MaxDuration = nil
+	durationBuckets, err := loadRangeFacet(db, durationParams, "listings.duration_minutes", DURATION_FACET_BUCKETS)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.LoadSearchFacets) loading duration buckets")
+	}
+
+	return &SearchFacets{
+		Categories:      categories,
+		Countries:       countries,
+		Regions:         regions,
+		PriceBuckets:    priceBuckets,
+		DurationBuckets: durationBuckets,
+	}, nil
+}
+
+func loadCategoryFacet(db *gorm.DB, params SearchParams) ([]FacetCount, error) {
+	params.Categories = nil
+
+	categories := []FacetCount{}
+	result := filteredSearchQuery(db, params).
+		Select("listing_categories.category AS value, COUNT(DISTINCT listings.id) AS count").
+		Joins("JOIN listing_categories ON listing_categories.listing_id = listings.id AND listing_categories.deactivated_at IS NULL").
+		Where("listing_categories.category NOT IN ?", models.SPECIAL_CATEGORIES).
+		Group("listing_categories.category").
+		Order("count DESC, value ASC").
+		Scan(&categories)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.loadCategoryFacet)")
+	}
+
+	return categories, nil
+}
+
+func loadCountryFacet(db *gorm.DB, params SearchParams) ([]FacetCount, error) {
+	params.Countries = nil
+
+	countries := []FacetCount{}
+	result := filteredSearchQuery(db, params).
+		Select("listings.country AS value, COUNT(*) AS count").
+		Where("listings.country IS NOT NULL").
+		Group("listings.country").
+		Order("count DESC, value ASC").
+		Scan(&countries)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.loadCountryFacet)")
+	}
+
+	return countries, nil
+}
+
+func loadRegionFacet(db *gorm.DB, params SearchParams) ([]RegionFacetCount, error) {
+	params.Regions = nil
+
+	regions := []RegionFacetCount{}
+	result := filteredSearchQuery(db, params).
+		Select("listings.country AS country, listings.region AS region, COUNT(*) AS count").
+		Where("listings.country IS NOT NULL").
+		Where("listings.region IS NOT NULL").
+		Group("listings.country, listings.region").
+		Order("count DESC, region ASC").
+		Scan(&regions)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.loadRegionFacet)")
+	}
+
+	return regions, nil
+}
+
+// Returns a count for every bucket, including empty ones, so the UI can draw a histogram
+func loadRangeFacet(db *gorm.DB, params SearchParams, column string, lowerBounds []int64) ([]RangeFacetCount, error) {
+	// width_bucket returns 0 for values below the first threshold, so skip the first lower bound to get 0-indexed buckets
+	var bucketCounts []bucketCount
+	result := filteredSearchQuery(db, params).
+		Select("width_bucket("+column+"::bigint, ?::bigint[]) AS bucket, COUNT(*) AS count", pq.Int64Array(lowerBounds[1:])).
+		Where(column + " IS NOT NULL").
+		Group("bucket").
+		Scan(&bucketCounts)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.loadRangeFacet)")
+	}
+
+	buckets := make([]RangeFacetCount, len(lowerBounds))
+	for i, lowerBound := range lowerBounds {
+		buckets[i].Min = lowerBound
+		if i < len(lowerBounds)-1 {
+			max := lowerBounds[i+1] - 1
+			buckets[i].Max = &max
+		}
+	}
+
+	for _, bucketCount := range bucketCounts {
+		if bucketCount.Bucket >= 0 && bucketCount.Bucket < len(buckets) {
+			buckets[bucketCount.Bucket].Count = bucketCount.Count
+		}
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,58 @@
+package listings
+
+import (
+	"slices"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/models"
+	"gorm.io/gorm"
+)
+
+// Runs the same checks as creating or updating a listing without saving anything
+func ValidateListingInput(listingInput input.Listing) error {
+	// Special categories are dropped when the listing is saved, but anything else has to be a real category
+	for _, category := range listingInput.Categories {
+		if !slices.Contains(models.HOST_CATEGORIES, category) && !slices.Contains(models.SPECIAL_CATEGORIES, category) {
+			return errors.NewBadRequestf("Unknown category: %s", category)
+		}
+	}
+
+	return applyListingUpdates(&models.Listing{}, listingInput)
+}
+
+// Creates a draft for each listing. Either every listing is created or none are.
+func ImportListings(db *gorm.DB, userID int64, listingInputs []input.Listing) ([]models.Listing, error) {
+	importedListings := make([]models.Listing, len(listingInputs))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for i, listingInput := range listingInputs {
+			// Imported listings always start as drafts so the host can review them before submitting
+			listingInput.Status = nil
+
+			listing, err := CreateListing(tx, userID, listingInput)
+			if err != nil {
+				return errors.Wrapf(err, "(listings.ImportListings) creating listing %d", i+1)
+			}
+
+			// Creating a listing only sets the basic fields, so apply everything else the file included
+			err = applyListingUpdates(listing, listingInput)
+			if err != nil {
+				return errors.Wrapf(err, "(listings.ImportListings) applying fields for listing %d", i+1)
+			}
+
+			result := tx.Save(listing)
+			if result.Error != nil {
+				return errors.Wrapf(result.Error, "(listings.ImportListings) saving listing %d", i+1)
+			}
+
+			importedListings[i] = *listing
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return importedListings, nil
+}
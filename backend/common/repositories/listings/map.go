@@ -0,0 +1,144 @@
+package listings
+
+import (
+	"math"
+	"strconv"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/geo"
+	"gorm.io/gorm"
+)
+
+// Map zoom levels below this return clusters instead of individual listings
+const MAX_CLUSTER_ZOOM = 10
+
+// Roughly how many clusters fit across one 256px map tile
+const CLUSTER_CELLS_PER_TILE = 4
+
+type clusterRow struct {
+	Longitude float64
+	Latitude  float64
+	Count     int64
+	West      float64
+	South     float64
+	East      float64
+	North     float64
+}
+
+type SearchCluster struct {
+	Longitude float64         `json:"longitude"`
+	Latitude  float64         `json:"latitude"`
+	Count     int64           `json:"count"`
+	Bounds    geo.BoundingBox `json:"bounds"` // Zooming to these bounds will show every listing in the cluster
+}
+
+func ShouldCluster(zoom int) bool {
+	return zoom < MAX_CLUSTER_ZOOM
+}
+
+// Groups the filtered search results into grid cells sized for the zoom level, using the same filters as Search
+func LoadSearchClusters(db *gorm.DB, params SearchParams, zoom int) ([]SearchCluster, error) {
+	err := validateSearchParams(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.LoadSearchClusters) validating params")
+	}
+
+	if zoom < 0 {
+		return nil, errors.NewBadRequest("Zoom must not be negative")
+	}
+
+	// A web mercator tile at zoom z covers 360 / 2^z degrees of longitude
+	gridSize := 360 / math.Pow(2, float64(zoom)) / CLUSTER_CELLS_PER_TILE
+
+	clusterPointSQL, clusterPointVars := getClusterPointSQL(params.Bounds)
+	clusterPoints := filteredSearchQuery(db, params).
+		Select("listings.id, "+clusterPointSQL+" AS cluster_point", clusterPointVars...)
+
+	// The grid size is computed above rather than user input, so it's safe to format into the query
+	var rows []clusterRow
+	result := db.Table("(?) AS cluster_points", clusterPoints).
+		Select(`ST_X(ST_Centroid(ST_Collect(cluster_point))) AS longitude,
+			ST_Y(ST_Centroid(ST_Collect(cluster_point))) AS latitude,
+			COUNT(*) AS count,
+			ST_XMin(ST_Extent(cluster_point)) AS west,
+			ST_YMin(ST_Extent(cluster_point)) AS south,
+			ST_XMax(ST_Extent(cluster_point)) AS east,
+			ST_YMax(ST_Extent(cluster_point)) AS north`).
+		Where("cluster_point IS NOT NULL").
+		Group("ST_SnapToGrid(cluster_point, " + strconv.FormatFloat(gridSize, 'f', -1, 64) + ")").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadSearchClusters)")
+	}
+
+	clusters := make([]SearchCluster, len(rows))
+	for i, row := range rows {
+		clusters[i] = SearchCluster{
+			Longitude: row.Longitude,
+			Latitude:  row.Latitude,
+			Count:     row.Count,
+			Bounds: geo.BoundingBox{
+				West:  row.West,
+				South: row.South,
+				East:  row.East,
+				North: row.North,
+			},
+		}
+	}
+
+	return clusters, nil
+}
+
+// Matches if the listing's main location, service area or any meeting point is in the bounds
+func whereInBounds(query *gorm.DB, bounds geo.BoundingBox) *gorm.DB {
+	envelopeSQL, envelopeVars := getEnvelopeSQL(bounds)
+
+	var vars []interface{}
+	vars = append(vars, envelopeVars...)
+	vars = append(vars, envelopeVars...)
+	vars = append(vars, envelopeVars...)
+	return query.Where(
+		`(ST_Intersects(listings.coordinates::geometry, `+envelopeSQL+`)
+		OR ST_Intersects(listings.service_area::geometry, `+envelopeSQL+`)
+		OR EXISTS (SELECT 1 FROM meeting_points WHERE meeting_points.listing_id = listings.id AND meeting_points.deactivated_at IS NULL AND ST_Intersects(meeting_points.coordinates::geometry, `+envelopeSQL+`)))`,
+		vars...,
+	)
+}
+
+// Picks one point per listing to cluster on. Listings can match the bounds through their service area or a
+// meeting point, so the point has to come from whichever of those is in the bounds, otherwise the cluster
+// would be drawn outside the map or the listing would be missing from the counts.
+func getClusterPointSQL(bounds *geo.BoundingBox) (string, []interface{}) {
+	if bounds == nil {
+		return `COALESCE(
+			listings.coordinates::geometry,
+			(SELECT meeting_points.coordinates::geometry FROM meeting_points WHERE meeting_points.listing_id = listings.id AND meeting_points.deactivated_at IS NULL ORDER BY meeting_points.id LIMIT 1),
+			ST_PointOnSurface(listings.service_area::geometry))`, nil
+	}
+
+	envelopeSQL, envelopeVars := getEnvelopeSQL(*bounds)
+
+	var vars []interface{}
+	vars = append(vars, envelopeVars...)
+	vars = append(vars, envelopeVars...)
+	vars = append(vars, envelopeVars...)
+	return `CASE WHEN ST_Intersects(listings.coordinates::geometry, ` + envelopeSQL + `) THEN listings.coordinates::geometry
+		ELSE COALESCE(
+			(SELECT meeting_points.coordinates::geometry FROM meeting_points WHERE meeting_points.listing_id = listings.id AND meeting_points.deactivated_at IS NULL AND ST_Intersects(meeting_points.coordinates::geometry, ` + envelopeSQL + `) ORDER BY meeting_points.id LIMIT 1),
+			ST_PointOnSurface(ST_Intersection(listings.service_area::geometry, ` + envelopeSQL + `)))
+		END`, vars
+}
+
+// Boxes that cross the antimeridian are split into one box on each side of it
+func getEnvelopeSQL(bounds geo.BoundingBox) (string, []interface{}) {
+	if bounds.CrossesAntimeridian() {
+		return "ST_Collect(ST_MakeEnvelope(?, ?, 180, ?, 4326), ST_MakeEnvelope(-180, ?, ?, ?, 4326))", []interface{}{
+			bounds.West, bounds.South, bounds.North,
+			bounds.South, bounds.East, bounds.North,
+		}
+	}
+
+	return "ST_MakeEnvelope(?, ?, ?, ?, 4326)", []interface{}{
+		bounds.West, bounds.South, bounds.East, bounds.North,
+	}
+}
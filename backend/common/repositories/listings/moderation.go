@@ -0,0 +1,187 @@
+package listings
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ListingUnderReview struct {
+	ListingDetails
+	SubmittedAt time.Time
+}
+
+// Oldest submissions first so the queue is worked in order
+func LoadUnderReview(db *gorm.DB) ([]ListingUnderReview, error) {
+	var listings []models.Listing
+	result := db.Table("listings").
+		Select("listings.*").
+		Where("listings.status = ?", models.ListingStatusUnderReview).
+		Where("listings.deactivated_at IS NULL").
+		Order("listings.updated_at ASC").
+		Find(&listings)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadUnderReview)")
+	}
+
+	listingsUnderReview := make([]ListingUnderReview, len(listings))
+	for i, listing := range listings {
+		details, err := loadDetailsForListing(db, listing)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.LoadUnderReview) loading details")
+		}
+
+		submittedAt, err := loadSubmittedAt(db, listing)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.LoadUnderReview) loading submission time")
+		}
+
+		listingsUnderReview[i] = ListingUnderReview{
+			ListingDetails: *details,
+			SubmittedAt:    submittedAt,
+		}
+	}
+
+	return listingsUnderReview, nil
+}
+
+func ApproveListing(db *gorm.DB, listing *models.Listing, actorID int64) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := lockUnderReview(tx, listing, "Only listings under review can be approved")
+		if err != nil {
+			return err
+		}
+
+		err = setStatus(tx, listing, models.ListingStatusPublished, actorID, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		return recordPublishedRevision(tx, *listing, actorID)
+	})
+	if err != nil {
+		return errors.Wrap(err, "(listings.ApproveListing)")
+	}
+
+	return nil
+}
+
+// Rejected listings go back to drafts so the host can make changes and submit again
+func RejectListing(db *gorm.DB, listing *models.Listing, actorID int64, rejection input.ListingRejection) error {
+	reasons := make(pq.StringArray, len(rejection.Reasons))
+	for i, reason := range rejection.Reasons {
+		reasons[i] = string(reason)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		err := lockUnderReview(tx, listing, "Only listings under review can be rejected")
+		if err != nil {
+			return err
+		}
+
+		return setStatus(tx, listing, models.ListingStatusDraft, actorID, reasons, input.SanitizePtr(rejection.Feedback))
+	})
+	if err != nil {
+		return errors.Wrap(err, "(listings.RejectListing)")
+	}
+
+	return nil
+}
+
+// Locks the listing for the rest of the transaction and checks it's still under review, so two
+// admins acting at once can't both approve or reject it
+func lockUnderReview(tx *gorm.DB, listing *models.Listing, message string) error {
+	var locked models.Listing
+	result := tx.Table("listings").
+		Select("listings.*").
+		Where("listings.id = ?", listing.ID).
+		Where("listings.deactivated_at IS NULL").
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Take(&locked)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.lockUnderReview)")
+	}
+
+	if locked.Status != models.ListingStatusUnderReview {
+		return errors.NewBadRequest(message)
+	}
+
+	listing.Status = locked.Status
+	return nil
+}
+
+func LoadStatusEvents(db *gorm.DB, listingID int64) ([]models.ListingStatusEvent, error) {
+	var statusEvents []models.ListingStatusEvent
+	result := db.Table("listing_status_events").
+		Select("listing_status_events.*").
+		Where("listing_status_events.listing_id = ?", listingID).
+		Where("listing_status_events.deactivated_at IS NULL").
+		Order("listing_status_events.created_at ASC").
+		Find(&statusEvents)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadStatusEvents)")
+	}
+
+	return statusEvents, nil
+}
+
+func setStatus(db *gorm.DB, listing *models.Listing, status models.ListingStatus, actorID int64, reasons pq.StringArray, feedback *string) error {
+	fromStatus := listing.Status
+	result := db.Model(listing).Update("status", status)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.setStatus) updating status")
+	}
+
+	err := recordStatusEvent(db, listing.ID, actorID, fromStatus, status, reasons, feedback)
+	if err != nil {
+		return errors.Wrap(err, "(listings.setStatus)")
+	}
+
+	return nil
+}
+
+func recordStatusEvent(db *gorm.DB, listingID int64, actorID int64, fromStatus models.ListingStatus, toStatus models.ListingStatus, reasons pq.StringArray, feedback *string) error {
+	if reasons == nil {
+		reasons = pq.StringArray{}
+	}
+
+	result := db.Create(&models.ListingStatusEvent{
+		ListingID:  listingID,
+		ActorID:    actorID,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		Reasons:    reasons,
+		Feedback:   feedback,
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.recordStatusEvent)")
+	}
+
+	return nil
+}
+
+// Falls back to the last update for listings submitted before status events were recorded
+func loadSubmittedAt(db *gorm.DB, listing models.Listing) (time.Time, error) {
+	var statusEvent models.ListingStatusEvent
+	result := db.Table("listing_status_events").
+		Select("listing_status_events.*").
+		Where("listing_status_events.listing_id = ?", listing.ID).
+		Where("listing_status_events.to_status = ?", models.ListingStatusUnderReview).
+		Where("listing_status_events.deactivated_at IS NULL").
+		Order("listing_status_events.created_at DESC").
+		Take(&statusEvent)
+	if result.Error != nil {
+		if errors.IsRecordNotFound(result.Error) {
+			return listing.UpdatedAt, nil
+		}
+
+		return time.Time{}, errors.Wrap(result.Error, "(listings.loadSubmittedAt)")
+	}
+
+	return statusEvent.CreatedAt, nil
+}
@@ -6,13 +6,16 @@ import (
 
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/geo"
+	"go.coaster.io/server/common/images"
 	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/locales"
 	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/ranks"
 	"go.coaster.io/server/common/repositories/availability_rules"
 	"go.coaster.io/server/common/repositories/itinerary_steps"
+	"go.coaster.io/server/common/repositories/meeting_points"
 	"go.coaster.io/server/common/repositories/users"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 type ListingDetails struct {
@@ -21,6 +24,8 @@ type ListingDetails struct {
 	Images         []models.ListingImage
 	Categories     []models.ListingCategory
 	ItinerarySteps []models.ItineraryStep
+	MeetingPoints  []models.MeetingPoint
+	Locale         string // The locale the text fields are in, see LocalizeListings
 }
 
 type ListingMetadata struct {
@@ -28,6 +33,13 @@ type ListingMetadata struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type ListingLocation struct {
+	City    string
+	Region  string
+	Country string
+	Count   int64
+}
+
 func LoadDetailsByIDAndUser(db *gorm.DB, listingID int64, user *models.User) (*ListingDetails, error) {
 	var listing models.Listing
 	result := db.Table("listings").
@@ -105,12 +117,19 @@ func LoadAllByUserID(db *gorm.DB, userID int64) ([]ListingDetails, error) {
 			return nil, errors.Wrap(err, "(listings.LoadByID) getting itinerary")
 		}
 
+		meetingPoints, err := meeting_points.LoadMeetingPointsForListing(db, listing.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.LoadAllByUserID) getting meeting points")
+		}
+
 		listingDetails[i] = ListingDetails{
 			listing,
 			host,
 			images,
 			categories,
 			itinerarySteps,
+			meetingPoints,
+			listing.SourceLocale,
 		}
 	}
 
@@ -132,21 +151,31 @@ func GetDraftListing(db *gorm.DB, userID int64) (*ListingDetails, error) {
 	return loadDetailsForListing(db, listing)
 }
 
-func SubmitListing(db *gorm.DB, listing *models.Listing) error {
-	result := db.Model(listing).Update("status", models.ListingStatusUnderReview)
-	if result.Error != nil {
-		return errors.Wrap(result.Error, "(listings.SubmitListing)")
-	}
-
-	return nil
-}
-
 // TODO: pass other fields
 func CreateListing(
 	db *gorm.DB,
 	userID int64,
 	listingInput input.Listing,
 ) (*models.Listing, error) {
+	sourceLocale := locales.DEFAULT_LOCALE
+	if listingInput.SourceLocale != nil {
+		var err error
+		sourceLocale, err = locales.Normalize(*listingInput.SourceLocale)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.CreateListing)")
+		}
+	}
+
+	var serviceArea *geo.Polygon
+	if listingInput.ServiceArea != nil && len(*listingInput.ServiceArea) > 0 {
+		err := listingInput.ServiceArea.Validate()
+		if err != nil {
+			return nil, errors.NewBadRequestf("Invalid service area: %s", err.Error())
+		}
+
+		serviceArea = listingInput.ServiceArea
+	}
+
 	listing := models.Listing{
 		UserID:              userID,
 		Name:                listingInput.Name,
@@ -154,6 +183,7 @@ func CreateListing(
 		Price:               listingInput.Price,
 		Location:            listingInput.Location,
 		Coordinates:         listingInput.Coordinates,
+		ServiceArea:         serviceArea,
 		PlaceID:             listingInput.PlaceID,
 		City:                listingInput.City,
 		Region:              listingInput.Region,
@@ -166,6 +196,7 @@ func CreateListing(
 		NotIncluded:         []string{},
 		AvailabilityType:    models.AvailabilityTypeDate,
 		AvailabilityDisplay: models.AvailabilityDisplayCalendar,
+		SourceLocale:        sourceLocale,
 	}
 
 	result := db.Create(&listing)
@@ -190,7 +221,51 @@ func CreateListing(
 	return &listing, nil
 }
 
-func UpdateListing(db *gorm.DB, listing *models.Listing, listingUpdates input.Listing) (*ListingDetails, error) {
+func UpdateListing(db *gorm.DB, listing *models.Listing, listingUpdates input.Listing, actorID int64) (*ListingDetails, error) {
+	fromStatus := listing.Status
+	fromAvailabilityType := listing.AvailabilityType
+
+	err := applyListingUpdates(listing, listingUpdates)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.UpdateListing)")
+	}
+
+	if listing.AvailabilityType != fromAvailabilityType {
+		err := availability_rules.DeactivateAllForListing(db, listing.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.UpdateListing) deactivating availability rules")
+		}
+	}
+
+	// TODO: only admins can make the status published
+	if listingUpdates.Status != nil && *listingUpdates.Status != models.ListingStatusPublished {
+		listing.Status = *listingUpdates.Status
+	}
+
+	result := db.Save(&listing)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.UpdateListing)")
+	}
+
+	if listing.Status != fromStatus {
+		err := recordStatusEvent(db, listing.ID, actorID, fromStatus, listing.Status, nil, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.UpdateListing) recording status change")
+		}
+	}
+
+	if listingUpdates.Categories != nil {
+		err := updateListingCategories(db, listing.ID, listingUpdates.Categories)
+		if err != nil {
+			return nil, errors.Wrap(err, "(api.UpdateListing) updating listing categories")
+		}
+	}
+
+	return loadDetailsForListing(db, *listing)
+}
+
+// Applies the content fields from the updates without saving, status and categories are handled by the caller
+func applyListingUpdates(listing *models.Listing, listingUpdates input.Listing) error {
 	if listingUpdates.Name != nil {
 		listing.Name = listingUpdates.Name
 	}
@@ -210,7 +285,7 @@ func UpdateListing(db *gorm.DB, listing *models.Listing, listingUpdates input.Li
 			listingUpdates.Region == nil ||
 			listingUpdates.Country == nil ||
 			listingUpdates.PostalCode == nil {
-			return nil, errors.Newf("(listings.UpdateListing) missing location fields for location %s", *listingUpdates.Location)
+			return errors.Newf("(listings.applyListingUpdates) missing location fields for location %s", *listingUpdates.Location)
 		}
 
 		listing.Location = listingUpdates.Location
@@ -222,6 +297,19 @@ func UpdateListing(db *gorm.DB, listing *models.Listing, listingUpdates input.Li
 		listing.PostalCode = listingUpdates.PostalCode
 	}
 
+	if listingUpdates.ServiceArea != nil {
+		if len(*listingUpdates.ServiceArea) == 0 {
+			listing.ServiceArea = nil
+		} else {
+			err := listingUpdates.ServiceArea.Validate()
+			if err != nil {
+				return errors.NewBadRequestf("Invalid service area: %s", err.Error())
+			}
+
+			listing.ServiceArea = listingUpdates.ServiceArea
+		}
+	}
+
 	if listingUpdates.ShortDescription != nil {
 		listing.ShortDescription = listingUpdates.ShortDescription
 	}
@@ -251,37 +339,23 @@ func UpdateListing(db *gorm.DB, listing *models.Listing, listingUpdates input.Li
 	}
 
 	if listingUpdates.AvailabilityType != nil {
-		if listing.AvailabilityType != *listingUpdates.AvailabilityType {
-			listing.AvailabilityType = *listingUpdates.AvailabilityType
-			err := availability_rules.DeactivateAllForListing(db, listing.ID)
-			if err != nil {
-				return nil, errors.Wrap(err, "(listings.UpdateListing) deactivating availability rules")
-			}
-		}
+		listing.AvailabilityType = *listingUpdates.AvailabilityType
 	}
 
 	if listingUpdates.AvailabilityDisplay != nil {
 		listing.AvailabilityDisplay = *listingUpdates.AvailabilityDisplay
 	}
 
-	// TODO: only admins can make the status published
-	if listingUpdates.Status != nil && *listingUpdates.Status != models.ListingStatusPublished {
-		listing.Status = *listingUpdates.Status
-	}
-
-	result := db.Save(&listing)
-	if result.Error != nil {
-		return nil, errors.Wrap(result.Error, "(listings.UpdateListing)")
-	}
-
-	if listingUpdates.Categories != nil {
-		err := updateListingCategories(db, listing.ID, listingUpdates.Categories)
+	if listingUpdates.SourceLocale != nil {
+		sourceLocale, err := locales.Normalize(*listingUpdates.SourceLocale)
 		if err != nil {
-			return nil, errors.Wrap(err, "(api.UpdateListing) updating listing categories")
+			return errors.Wrap(err, "(listings.applyListingUpdates)")
 		}
+
+		listing.SourceLocale = sourceLocale
 	}
 
-	return loadDetailsForListing(db, *listing)
+	return nil
 }
 
 func updateListingCategories(db *gorm.DB, listingID int64, categories []models.ListingCategoryType) error {
@@ -315,15 +389,7 @@ func updateListingCategories(db *gorm.DB, listingID int64, categories []models.L
 	return nil
 }
 
-func CreateListingImage(db *gorm.DB, listingID int64, storageID string, rank int, width int, height int) (*models.ListingImage, error) {
-	listingImage := models.ListingImage{
-		ListingID: listingID,
-		StorageID: storageID,
-		Rank:      rank,
-		Width:     width,
-		Height:    height,
-	}
-
+func CreateListingImage(db *gorm.DB, listingImage models.ListingImage) (*models.ListingImage, error) {
 	result := db.Create(&listingImage)
 	if result.Error != nil {
 		return nil, errors.Wrap(result.Error, "(listings.CreateListingImage)")
@@ -332,6 +398,84 @@ func CreateListingImage(db *gorm.DB, listingID int64, storageID string, rank int
 	return &listingImage, nil
 }
 
+// Every image that's still in use, regardless of its listing's status
+func LoadAllActiveImages(db *gorm.DB) ([]models.ListingImage, error) {
+	var listingImages []models.ListingImage
+	result := db.Table("listing_images").
+		Select("listing_images.*").
+		Where("listing_images.deactivated_at IS NULL").
+		Order("listing_images.id ASC").
+		Find(&listingImages)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadAllActiveImages)")
+	}
+
+	return listingImages, nil
+}
+
+// Flags images whose storage objects are missing and clears the flag on the rest. Images that were
+// already flagged keep their original time.
+func UpdateImagesMissing(db *gorm.DB, missingImageIDs []int64, foundImageIDs []int64) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(missingImageIDs) > 0 {
+			result := tx.Model(&models.ListingImage{}).
+				Where("id IN ?", missingImageIDs).
+				Where("missing_since IS NULL").
+				Update("missing_since", time.Now())
+			if result.Error != nil {
+				return errors.Wrap(result.Error, "flagging missing images")
+			}
+		}
+
+		if len(foundImageIDs) > 0 {
+			result := tx.Model(&models.ListingImage{}).
+				Where("id IN ?", foundImageIDs).
+				Where("missing_since IS NOT NULL").
+				Update("missing_since", nil)
+			if result.Error != nil {
+				return errors.Wrap(result.Error, "clearing found images")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "(listings.UpdateImagesMissing)")
+	}
+
+	return nil
+}
+
+// Images uploaded before placeholders were generated, paged by ID
+func LoadImagesMissingPlaceholder(db *gorm.DB, afterID int64, limit int) ([]models.ListingImage, error) {
+	var listingImages []models.ListingImage
+	result := db.Table("listing_images").
+		Select("listing_images.*").
+		Where("listing_images.id > ?", afterID).
+		Where("listing_images.blurhash IS NULL").
+		Where("listing_images.deactivated_at IS NULL").
+		Order("listing_images.id ASC").
+		Limit(limit).
+		Find(&listingImages)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadImagesMissingPlaceholder)")
+	}
+
+	return listingImages, nil
+}
+
+func UpdateImagePlaceholder(db *gorm.DB, listingImage *models.ListingImage, placeholder images.Placeholder) error {
+	result := db.Model(listingImage).Updates(map[string]interface{}{
+		"blurhash":       placeholder.Blurhash,
+		"dominant_color": placeholder.DominantColor,
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.UpdateImagePlaceholder)")
+	}
+
+	return nil
+}
+
 func DeleteListing(db *gorm.DB, listing *models.Listing) error {
 	currentTime := time.Now()
 	result := db.Model(listing).Update("deactivated_at", currentTime)
@@ -367,14 +511,40 @@ func LoadListingImage(db *gorm.DB, listingID int64, imageID int64) (*models.List
 	return &listingImage, nil
 }
 
-func UpdateImageRank(db *gorm.DB, listingID int64, imageID int64, rank int) error {
-	result := db.Table("listing_images").
-		Where("listing_images.listing_id = ?", listingID).
-		Where("listing_images.id = ?", imageID).
-		Update("rank", rank)
+func getImageRankScope(listingID int64) ranks.Scope {
+	return ranks.Scope{
+		Table:       "listing_images",
+		RankColumn:  "rank",
+		ScopeColumn: "listing_id",
+		ScopeID:     listingID,
+	}
+}
 
-	if result.Error != nil {
-		return errors.Wrap(result.Error, "(listings.DeleteListingImage)")
+func GetNextImageRank(db *gorm.DB, listingID int64) (string, error) {
+	rank, err := ranks.NextRank(db, getImageRankScope(listingID))
+	if err != nil {
+		return "", errors.Wrap(err, "(listings.GetNextImageRank)")
+	}
+
+	return rank, nil
+}
+
+// Moves an image to just after another one, or to the front when afterImageID is nil
+func MoveImage(db *gorm.DB, listingID int64, imageID int64, afterImageID *int64) error {
+	err := ranks.MoveRow(db, getImageRankScope(listingID), imageID, afterImageID)
+	if err != nil {
+		return errors.Wrap(err, "(listings.MoveImage)")
+	}
+
+	return nil
+}
+
+// Videos are listed separately from photos, so hosts can reorder just the photos and the videos keep
+// their positions
+func ReorderImages(db *gorm.DB, listingID int64, imageIDs []int64) error {
+	err := ranks.Reorder(db, getImageRankScope(listingID), imageIDs)
+	if err != nil {
+		return errors.Wrap(err, "(listings.ReorderImages)")
 	}
 
 	return nil
@@ -386,7 +556,7 @@ func LoadImagesForListing(db *gorm.DB, listingID int64) ([]models.ListingImage,
 		Select("listing_images.*").
 		Where("listing_images.listing_id = ?", listingID).
 		Where("listing_images.deactivated_at IS NULL").
-		Order("listing_images.rank ASC").
+		Order("listing_images.rank ASC, listing_images.id ASC").
 		Find(&listingImages)
 	if result.Error != nil {
 		// Not guaranteed to have any images for a listing so just return an empty slice
@@ -400,63 +570,28 @@ func LoadImagesForListing(db *gorm.DB, listingID int64) ([]models.ListingImage,
 	return listingImages, nil
 }
 
-func LoadListingsWithinRadius(db *gorm.DB, coordinates geo.Point, radius int64) ([]ListingDetails, error) {
-	var listings []models.Listing
-	result := db.Raw("SELECT * FROM listings WHERE ST_DWithin(?, listings.coordinates::Geography, ?) AND listings.status = ?;", coordinates, radius, models.ListingStatusPublished).Find(&listings)
-	if result.Error != nil {
-		return nil, errors.Wrap(result.Error, "(listings.LoadListingsWithinRadius)")
-	}
-
-	listingDetails := make([]ListingDetails, len(listings))
-	for i, listing := range listings {
-		details, err := loadDetailsForListing(db, listing)
-		if err != nil {
-			return nil, errors.Wrap(err, "(listings.LoadListingsWithinRadius) loading details")
-		}
-
-		listingDetails[i] = *details
-	}
-
-	return listingDetails, nil
-}
-
-func LoadListingsForQuery(db *gorm.DB, query string) ([]ListingDetails, error) {
-	var listings []models.Listing
+func LoadAllPublishedMetadata(db *gorm.DB) ([]ListingMetadata, error) {
+	var listingMetadataList []ListingMetadata
 	result := db.Table("listings").
-		Select("listings.*").
-		Where("listings.ts @@ to_tsquery('english', replace(?, ' ', '|'))", query).
+		Select("listings.id", "listings.updated_at").
 		Where("listings.status = ?", models.ListingStatusPublished).
-		Clauses(clause.OrderBy{
-			Expression: clause.Expr{SQL: "ts_rank(ts, to_tsquery('english', replace(?, ' ', '|'))) DESC", Vars: []interface{}{query}},
-		}).
-		Find(&listings)
+		Where("listings.deactivated_at IS NULL").
+		Find(&listingMetadataList)
 	if result.Error != nil {
-		return nil, errors.Wrap(result.Error, "(listings.LoadListingsForQuery)")
-	}
-
-	listingDetails := make([]ListingDetails, len(listings))
-	for i, listing := range listings {
-		details, err := loadDetailsForListing(db, listing)
-		if err != nil {
-			return nil, errors.Wrap(err, "(listings.LoadListingsForQuery) loading details")
-		}
-
-		listingDetails[i] = *details
+		return nil, errors.Wrap(result.Error, "(listings.LoadAllPublishedIDs)")
 	}
 
-	return listingDetails, nil
-}
-
-func LoadFeatured(db *gorm.DB) ([]ListingDetails, error) {
-	return LoadListingsByCategory(db, []models.ListingCategoryType{models.CategoryFeatured})
+	return listingMetadataList, nil
 }
 
+// Every published listing, unpaginated, for the commerce feeds
 func LoadAllPublished(db *gorm.DB) ([]ListingDetails, error) {
 	var listings []models.Listing
 	result := db.Table("listings").
 		Select("listings.*").
 		Where("listings.status = ?", models.ListingStatusPublished).
 		Where("listings.deactivated_at IS NULL").
+		Order("listings.id ASC").
 		Find(&listings)
 	if result.Error != nil {
 		return nil, errors.Wrap(result.Error, "(listings.LoadAllPublished)")
@@ -475,70 +610,20 @@ func LoadAllPublished(db *gorm.DB) ([]ListingDetails, error) {
 	return listingDetails, nil
 }
 
-func LoadByDuration(db *gorm.DB, durationMinutes int64) ([]ListingDetails, error) {
-	var listings []models.Listing
-	result := db.Table("listings").
-		Select("listings.*").
-		Where("listings.status = ?", models.ListingStatusPublished).
-		Where("listings.duration_minutes <= ?", durationMinutes).
-		Where("listings.deactivated_at IS NULL").
-		Find(&listings)
-	if result.Error != nil {
-		return nil, errors.Wrap(result.Error, "(listings.LoadDayTrips)")
-	}
-
-	listingDetails := make([]ListingDetails, len(listings))
-	for i, listing := range listings {
-		details, err := loadDetailsForListing(db, listing)
-		if err != nil {
-			return nil, errors.Wrap(err, "(listings.LoadByDuration) loading details")
-		}
-
-		listingDetails[i] = *details
-	}
-
-	return listingDetails, nil
-}
-
-func LoadListingsByCategory(db *gorm.DB, categories []models.ListingCategoryType) ([]ListingDetails, error) {
-	var listings []models.Listing
-	result := db.Table("listings").
-		Select("listings.*").
-		Joins("JOIN listing_categories ON listing_categories.listing_id = listings.id").
-		Where("listings.status = ?", models.ListingStatusPublished).
-		Where("listing_categories.category IN ?", categories).
-		Where("listings.deactivated_at IS NULL").
-		Where("listing_categories.deactivated_at IS NULL").
-		Find(&listings)
-	if result.Error != nil {
-		return nil, errors.Wrap(result.Error, "(listings.LoadListingsByCategory)")
-	}
-
-	listingDetails := make([]ListingDetails, len(listings))
-	for i, listing := range listings {
-		details, err := loadDetailsForListing(db, listing)
-		if err != nil {
-			return nil, errors.Wrap(err, "(listings.LoadListingsByCategory) loading details")
-		}
-
-		listingDetails[i] = *details
-	}
-
-	return listingDetails, nil
-}
-
-func LoadAllPublishedMetadata(db *gorm.DB) ([]ListingMetadata, error) {
-	var listingMetadataList []ListingMetadata
+func LoadPublishedLocations(db *gorm.DB) ([]ListingLocation, error) {
+	var listingLocations []ListingLocation
 	result := db.Table("listings").
-		Select("listings.id", "listings.updated_at").
+		Select("listings.city, listings.region, listings.country, COUNT(*) AS count").
 		Where("listings.status = ?", models.ListingStatusPublished).
 		Where("listings.deactivated_at IS NULL").
-		Find(&listingMetadataList)
+		Where("listings.city IS NOT NULL").
+		Group("listings.city, listings.region, listings.country").
+		Find(&listingLocations)
 	if result.Error != nil {
-		return nil, errors.Wrap(result.Error, "(listings.LoadAllPublishedIDs)")
+		return nil, errors.Wrap(result.Error, "(listings.LoadPublishedLocations)")
 	}
 
-	return listingMetadataList, nil
+	return listingLocations, nil
 }
 
 func LoadCategoriesForListing(db *gorm.DB, listingID int64) ([]models.ListingCategory, error) {
@@ -588,6 +673,35 @@ func LoadListingsForTag(db *gorm.DB, tagID int64) ([]ListingDetails, error) {
 	return listingDetails, nil
 }
 
+func LoadListingsForWishlist(db *gorm.DB, wishlistID int64) ([]ListingDetails, error) {
+	var listings []models.Listing
+	result := db.Table("listings").
+		Select("listings.*").
+		Joins("JOIN wishlist_listings ON wishlist_listings.listing_id = listings.id").
+		Where("wishlist_listings.wishlist_id = ?", wishlistID).
+		Where("listings.status = ?", models.ListingStatusPublished).
+		Where("listings.deactivated_at IS NULL").
+		Where("wishlist_listings.deactivated_at IS NULL").
+		Order("wishlist_listings.created_at DESC").
+		Find(&listings)
+
+	if result.Error != nil {
+		return nil, errors.Wrapf(result.Error, "(listings.LoadListingsForWishlist) loading listings for wishlist %d", wishlistID)
+	}
+
+	listingDetails := make([]ListingDetails, len(listings))
+	for i, listing := range listings {
+		details, err := loadDetailsForListing(db, listing)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.LoadListingsForWishlist) loading details")
+		}
+
+		listingDetails[i] = *details
+	}
+
+	return listingDetails, nil
+}
+
 func loadDetailsForListing(db *gorm.DB, listing models.Listing) (*ListingDetails, error) {
 	host, err := users.LoadUserByID(db, listing.UserID)
 	if err != nil {
@@ -609,11 +723,18 @@ func loadDetailsForListing(db *gorm.DB, listing models.Listing) (*ListingDetails
 		return nil, errors.Wrap(err, "(listings.LoadByID) getting itinerary")
 	}
 
+	meetingPoints, err := meeting_points.LoadMeetingPointsForListing(db, listing.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.loadDetailsForListing) getting meeting points")
+	}
+
 	return &ListingDetails{
 		listing,
 		host,
 		images,
 		categories,
 		itinerarySteps,
+		meetingPoints,
+		listing.SourceLocale,
 	}, nil
 }
@@ -0,0 +1,564 @@
+package listings
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/availability_rules"
+	"gorm.io/gorm"
+)
+
+// Revisions that are still being worked on, a listing has at most one of these at a time
+var OPEN_REVISION_STATUSES = []models.ListingRevisionStatus{
+	models.ListingRevisionStatusPending,
+	models.ListingRevisionStatusUnderReview,
+	models.ListingRevisionStatusRejected,
+}
+
+type ContentChange struct {
+	Field  string          `json:"field"`
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+type RevisionUnderReview struct {
+	Revision models.ListingRevision
+	Live     ListingDetails
+	Changes  []ContentChange
+}
+
+func GetListingContent(listing models.Listing, categories []models.ListingCategory) models.ListingContent {
+	categoryTypes := []models.ListingCategoryType{}
+	for _, category := range categories {
+		if slices.Contains(models.SPECIAL_CATEGORIES, category.Category) {
+			continue
+		}
+
+		categoryTypes = append(categoryTypes, category.Category)
+	}
+
+	return buildListingContent(listing, categoryTypes)
+}
+
+// Returns the listing as it would look with the revision published, used to preview pending changes
+func ApplyRevision(listing ListingDetails, revision models.ListingRevision) ListingDetails {
+	applyListingContent(&listing.Listing, revision.Content)
+
+	// Special categories are managed by admins so they carry over from the live listing
+	categories := []models.ListingCategory{}
+	for _, category := range listing.Categories {
+		if slices.Contains(models.SPECIAL_CATEGORIES, category.Category) {
+			categories = append(categories, category)
+		}
+	}
+
+	for _, category := range revision.Content.Categories {
+		categories = append(categories, models.ListingCategory{ListingID: listing.ID, Category: category})
+	}
+
+	listing.Categories = categories
+	return listing
+}
+
+func LoadOpenRevision(db *gorm.DB, listingID int64) (*models.ListingRevision, error) {
+	var revision models.ListingRevision
+	result := db.Table("listing_revisions").
+		Select("listing_revisions.*").
+		Where("listing_revisions.listing_id = ?", listingID).
+		Where("listing_revisions.status IN ?", OPEN_REVISION_STATUSES).
+		Where("listing_revisions.deactivated_at IS NULL").
+		Take(&revision)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadOpenRevision)")
+	}
+
+	return &revision, nil
+}
+
+func LoadRevisionByID(db *gorm.DB, revisionID int64) (*models.ListingRevision, error) {
+	var revision models.ListingRevision
+	result := db.Table("listing_revisions").
+		Select("listing_revisions.*").
+		Where("listing_revisions.id = ?", revisionID).
+		Where("listing_revisions.deactivated_at IS NULL").
+		Take(&revision)
+	if result.Error != nil {
+		return nil, errors.Wrapf(result.Error, "(listings.LoadRevisionByID) error for ID %d", revisionID)
+	}
+
+	return &revision, nil
+}
+
+// Newest first, discarded revisions are left out since they were never reviewed
+func LoadRevisions(db *gorm.DB, listingID int64) ([]models.ListingRevision, error) {
+	var revisions []models.ListingRevision
+	result := db.Table("listing_revisions").
+		Select("listing_revisions.*").
+		Where("listing_revisions.listing_id = ?", listingID).
+		Where("listing_revisions.status <> ?", models.ListingRevisionStatusDiscarded).
+		Where("listing_revisions.deactivated_at IS NULL").
+		Order("listing_revisions.created_at DESC").
+		Find(&revisions)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadRevisions)")
+	}
+
+	return revisions, nil
+}
+
+// Oldest submissions first so the queue is worked in order
+func LoadRevisionsUnderReview(db *gorm.DB) ([]RevisionUnderReview, error) {
+	var revisions []models.ListingRevision
+	result := db.Table("listing_revisions").
+		Select("listing_revisions.*").
+		Joins("JOIN listings ON listings.id = listing_revisions.listing_id").
+		Where("listing_revisions.status = ?", models.ListingRevisionStatusUnderReview).
+		Where("listing_revisions.deactivated_at IS NULL").
+		Where("listings.deactivated_at IS NULL").
+		Order("listing_revisions.submitted_at ASC").
+		Find(&revisions)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadRevisionsUnderReview)")
+	}
+
+	revisionsUnderReview := make([]RevisionUnderReview, len(revisions))
+	for i, revision := range revisions {
+		var listing models.Listing
+		result := db.Table("listings").
+			Select("listings.*").
+			Where("listings.id = ?", revision.ListingID).
+			Take(&listing)
+		if result.Error != nil {
+			return nil, errors.Wrap(result.Error, "(listings.LoadRevisionsUnderReview) loading listing")
+		}
+
+		live, err := loadDetailsForListing(db, listing)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.LoadRevisionsUnderReview) loading details")
+		}
+
+		changes, err := DiffContent(GetListingContent(live.Listing, live.Categories), revision.Content)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.LoadRevisionsUnderReview) diffing revision")
+		}
+
+		revisionsUnderReview[i] = RevisionUnderReview{
+			Revision: revision,
+			Live:     *live,
+			Changes:  changes,
+		}
+	}
+
+	return revisionsUnderReview, nil
+}
+
+// Applies the updates to the open revision for a published listing, starting a new one from the live
+// version if needed. Passing the under review status submits the revision, any other edit moves it
+// back to pending so admins never approve changes they haven't seen.
+func UpdateRevision(db *gorm.DB, listing ListingDetails, listingUpdates input.Listing, actorID int64) (*models.ListingRevision, error) {
+	var revision *models.ListingRevision
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		revision, err = LoadOpenRevision(tx, listing.ID)
+		if err != nil {
+			if !errors.IsRecordNotFound(err) {
+				return errors.Wrap(err, "(listings.UpdateRevision) loading open revision")
+			}
+
+			err = ensureBaselineRevision(tx, listing)
+			if err != nil {
+				return errors.Wrap(err, "(listings.UpdateRevision)")
+			}
+
+			revision = &models.ListingRevision{
+				ListingID: listing.ID,
+				Content:   GetListingContent(listing.Listing, listing.Categories),
+				Reasons:   pq.StringArray{},
+			}
+		}
+
+		preview := listing.Listing
+		applyListingContent(&preview, revision.Content)
+		err = applyListingUpdates(&preview, listingUpdates)
+		if err != nil {
+			return errors.Wrap(err, "(listings.UpdateRevision)")
+		}
+
+		categories := revision.Content.Categories
+		if listingUpdates.Categories != nil {
+			categories = []models.ListingCategoryType{}
+			for _, category := range listingUpdates.Categories {
+				if !slices.Contains(models.SPECIAL_CATEGORIES, category) {
+					categories = append(categories, category)
+				}
+			}
+		}
+
+		revision.Content = buildListingContent(preview, categories)
+		revision.AuthorID = actorID
+		if listingUpdates.Status != nil && *listingUpdates.Status == models.ListingStatusUnderReview {
+			revision.Status = models.ListingRevisionStatusUnderReview
+			revision.SubmittedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		} else {
+			revision.Status = models.ListingRevisionStatusPending
+			revision.SubmittedAt = sql.NullTime{}
+		}
+
+		result := tx.Save(revision)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "(listings.UpdateRevision) saving revision")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+func SubmitRevision(db *gorm.DB, revision *models.ListingRevision) error {
+	if revision.Status == models.ListingRevisionStatusUnderReview {
+		return errors.NewBadRequest("These changes have already been submitted for review")
+	}
+
+	if !slices.Contains(OPEN_REVISION_STATUSES, revision.Status) {
+		return errors.NewBadRequest("Only pending changes can be submitted for review")
+	}
+
+	result := db.Model(revision).Updates(map[string]interface{}{
+		"status":       models.ListingRevisionStatusUnderReview,
+		"submitted_at": time.Now(),
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.SubmitRevision)")
+	}
+
+	return nil
+}
+
+func DiscardRevision(db *gorm.DB, revision *models.ListingRevision) error {
+	if !slices.Contains(OPEN_REVISION_STATUSES, revision.Status) {
+		return errors.NewBadRequest("Only pending changes can be discarded")
+	}
+
+	result := db.Model(revision).Update("status", models.ListingRevisionStatusDiscarded)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.DiscardRevision)")
+	}
+
+	return nil
+}
+
+func ApproveRevision(db *gorm.DB, listing *models.Listing, revision *models.ListingRevision, actorID int64) error {
+	if revision.Status != models.ListingRevisionStatusUnderReview {
+		return errors.NewBadRequest("Only revisions under review can be approved")
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return publishRevision(tx, listing, revision, actorID)
+	})
+	if err != nil {
+		return errors.Wrap(err, "(listings.ApproveRevision)")
+	}
+
+	return nil
+}
+
+// Rejected revisions stay open so the host can make changes and submit them again
+func RejectRevision(db *gorm.DB, revision *models.ListingRevision, actorID int64, rejection input.ListingRejection) error {
+	if revision.Status != models.ListingRevisionStatusUnderReview {
+		return errors.NewBadRequest("Only revisions under review can be rejected")
+	}
+
+	reasons := make(pq.StringArray, len(rejection.Reasons))
+	for i, reason := range rejection.Reasons {
+		reasons[i] = string(reason)
+	}
+
+	revision.Status = models.ListingRevisionStatusRejected
+	revision.ReviewerID = &actorID
+	revision.Reasons = reasons
+	revision.Feedback = input.SanitizePtr(rejection.Feedback)
+	result := db.Save(revision)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.RejectRevision)")
+	}
+
+	return nil
+}
+
+// Restores the content of a previously published revision. For published listings the content goes into
+// the open revision as pending changes, replacing anything already there, so the host has to submit it for
+// review like any other edit. Drafts aren't visible to guests, so their content is restored directly and
+// reviewed when they're submitted.
+func RollbackToRevision(db *gorm.DB, listing ListingDetails, target models.ListingRevision, actorID int64) (*models.ListingRevision, error) {
+	if target.ListingID != listing.ID || target.Status != models.ListingRevisionStatusPublished {
+		return nil, errors.NewBadRequest("Listings can only be rolled back to a previously published version")
+	}
+
+	if listing.Status != models.ListingStatusPublished {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return applyContentToListing(tx, &listing.Listing, target.Content)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.RollbackToRevision)")
+		}
+
+		return nil, nil
+	}
+
+	var revision *models.ListingRevision
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		revision, err = LoadOpenRevision(tx, listing.ID)
+		if err != nil {
+			if !errors.IsRecordNotFound(err) {
+				return errors.Wrap(err, "loading open revision")
+			}
+
+			err = ensureBaselineRevision(tx, listing)
+			if err != nil {
+				return errors.Wrap(err, "ensuring baseline revision")
+			}
+
+			revision = &models.ListingRevision{ListingID: listing.ID}
+		}
+
+		revision.Content = target.Content
+		revision.AuthorID = actorID
+		revision.Status = models.ListingRevisionStatusPending
+		revision.ReviewerID = nil
+		revision.Reasons = pq.StringArray{}
+		revision.Feedback = nil
+		revision.SubmittedAt = sql.NullTime{}
+
+		result := tx.Save(revision)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "saving revision")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.RollbackToRevision)")
+	}
+
+	return revision, nil
+}
+
+// Lists the fields that differ between two versions of a listing, in the order they appear on the listing
+func DiffContent(from models.ListingContent, to models.ListingContent) ([]ContentChange, error) {
+	fromFields, err := getContentFields(from)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.DiffContent)")
+	}
+
+	toFields, err := getContentFields(to)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.DiffContent)")
+	}
+
+	changes := []ContentChange{}
+	contentType := reflect.TypeOf(models.ListingContent{})
+	for i := 0; i < contentType.NumField(); i++ {
+		field := strings.Split(contentType.Field(i).Tag.Get("json"), ",")[0]
+		if !bytes.Equal(fromFields[field], toFields[field]) {
+			changes = append(changes, ContentChange{
+				Field:  field,
+				Before: fromFields[field],
+				After:  toFields[field],
+			})
+		}
+	}
+
+	return changes, nil
+}
+
+func getContentFields(content models.ListingContent) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.getContentFields) encoding content")
+	}
+
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.getContentFields) decoding content")
+	}
+
+	return fields, nil
+}
+
+func publishRevision(db *gorm.DB, listing *models.Listing, revision *models.ListingRevision, actorID int64) error {
+	err := applyContentToListing(db, listing, revision.Content)
+	if err != nil {
+		return errors.Wrap(err, "(listings.publishRevision)")
+	}
+
+	revision.Status = models.ListingRevisionStatusPublished
+	revision.ReviewerID = &actorID
+	revision.PublishedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	result := db.Save(revision)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.publishRevision) saving revision")
+	}
+
+	return nil
+}
+
+func applyContentToListing(db *gorm.DB, listing *models.Listing, content models.ListingContent) error {
+	if listing.AvailabilityType != content.AvailabilityType {
+		err := availability_rules.DeactivateAllForListing(db, listing.ID)
+		if err != nil {
+			return errors.Wrap(err, "(listings.applyContentToListing) deactivating availability rules")
+		}
+	}
+
+	applyListingContent(listing, content)
+	result := db.Save(listing)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.applyContentToListing) saving listing")
+	}
+
+	err := updateListingCategories(db, listing.ID, content.Categories)
+	if err != nil {
+		return errors.Wrap(err, "(listings.applyContentToListing)")
+	}
+
+	return nil
+}
+
+// Records the live version of a listing as a published revision so the history starts from
+// the first version that went live
+func recordPublishedRevision(db *gorm.DB, listing models.Listing, actorID int64) error {
+	categories, err := LoadCategoriesForListing(db, listing.ID)
+	if err != nil {
+		return errors.Wrap(err, "(listings.recordPublishedRevision) loading categories")
+	}
+
+	now := time.Now()
+	result := db.Create(&models.ListingRevision{
+		ListingID:   listing.ID,
+		AuthorID:    listing.UserID,
+		ReviewerID:  &actorID,
+		Status:      models.ListingRevisionStatusPublished,
+		Content:     GetListingContent(listing, categories),
+		Reasons:     pq.StringArray{},
+		SubmittedAt: sql.NullTime{Time: now, Valid: true},
+		PublishedAt: sql.NullTime{Time: now, Valid: true},
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.recordPublishedRevision)")
+	}
+
+	return nil
+}
+
+// Listings published before revisions existed have no history, so record the live version before
+// the first edit to keep it available for rollbacks
+func ensureBaselineRevision(db *gorm.DB, listing ListingDetails) error {
+	var count int64
+	result := db.Table("listing_revisions").
+		Where("listing_revisions.listing_id = ?", listing.ID).
+		Where("listing_revisions.status = ?", models.ListingRevisionStatusPublished).
+		Where("listing_revisions.deactivated_at IS NULL").
+		Count(&count)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.ensureBaselineRevision) counting revisions")
+	}
+
+	if count > 0 {
+		return nil
+	}
+
+	result = db.Create(&models.ListingRevision{
+		ListingID:   listing.ID,
+		AuthorID:    listing.UserID,
+		Status:      models.ListingRevisionStatusPublished,
+		Content:     GetListingContent(listing.Listing, listing.Categories),
+		Reasons:     pq.StringArray{},
+		PublishedAt: sql.NullTime{Time: listing.UpdatedAt, Valid: true},
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.ensureBaselineRevision) creating revision")
+	}
+
+	return nil
+}
+
+func buildListingContent(listing models.Listing, categories []models.ListingCategoryType) models.ListingContent {
+	// Normalize empty lists so unchanged fields compare equal when diffing
+	highlights := []string{}
+	highlights = append(highlights, listing.Highlights...)
+	includes := []string{}
+	includes = append(includes, listing.Includes...)
+	notIncluded := []string{}
+	notIncluded = append(notIncluded, listing.NotIncluded...)
+	sortedCategories := []models.ListingCategoryType{}
+	sortedCategories = append(sortedCategories, categories...)
+	slices.Sort(sortedCategories)
+
+	return models.ListingContent{
+		Name:                listing.Name,
+		Description:         listing.Description,
+		Price:               listing.Price,
+		Location:            listing.Location,
+		Coordinates:         listing.Coordinates,
+		ServiceArea:         listing.ServiceArea,
+		PlaceID:             listing.PlaceID,
+		City:                listing.City,
+		Region:              listing.Region,
+		Country:             listing.Country,
+		PostalCode:          listing.PostalCode,
+		ShortDescription:    listing.ShortDescription,
+		Cancellation:        listing.Cancellation,
+		DurationMinutes:     listing.DurationMinutes,
+		MaxGuests:           listing.MaxGuests,
+		Highlights:          highlights,
+		Includes:            includes,
+		NotIncluded:         notIncluded,
+		AvailabilityType:    listing.AvailabilityType,
+		AvailabilityDisplay: listing.AvailabilityDisplay,
+		SourceLocale:        listing.SourceLocale,
+		Categories:          sortedCategories,
+	}
+}
+
+func applyListingContent(listing *models.Listing, content models.ListingContent) {
+	listing.Name = content.Name
+	listing.Description = content.Description
+	listing.Price = content.Price
+	listing.Location = content.Location
+	listing.Coordinates = content.Coordinates
+	listing.ServiceArea = content.ServiceArea
+	listing.PlaceID = content.PlaceID
+	listing.City = content.City
+	listing.Region = content.Region
+	listing.Country = content.Country
+	listing.PostalCode = content.PostalCode
+	listing.ShortDescription = content.ShortDescription
+	listing.Cancellation = content.Cancellation
+	listing.DurationMinutes = content.DurationMinutes
+	listing.MaxGuests = content.MaxGuests
+	listing.Highlights = content.Highlights
+	listing.Includes = content.Includes
+	listing.NotIncluded = content.NotIncluded
+	listing.AvailabilityType = content.AvailabilityType
+	listing.AvailabilityDisplay = content.AvailabilityDisplay
+
+	// Revisions recorded before source locales existed don't have one
+	if len(content.SourceLocale) > 0 {
+		listing.SourceLocale = content.SourceLocale
+	}
+}
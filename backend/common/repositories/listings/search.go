@@ -0,0 +1,539 @@
+package listings
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"html"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/geo"
+	"go.coaster.io/server/common/models"
+	"gorm.io/gorm"
+)
+
+const DEFAULT_SEARCH_LIMIT = 24
+const MAX_SEARCH_LIMIT = 100
+const MAX_SEARCH_DATE_RANGE_DAYS = 366
+
+// Farther than any two points on Earth, in meters, for sorting listings with no location after the rest
+const UNKNOWN_DISTANCE_SORT_KEY = 1e8
+
+type SearchSort string
+
+const (
+	SearchSortRelevance SearchSort = "relevance"
+	SearchSortPriceAsc  SearchSort = "price_asc"
+	SearchSortPriceDesc SearchSort = "price_desc"
+	SearchSortDistance  SearchSort = "distance"
+	SearchSortNewest    SearchSort = "newest"
+	// TODO: add a rating sort once listings have reviews
+)
+
+// All filters are optional and are combined with AND
+type SearchParams struct {
+	Query       *string
+	Location    *geo.Point // Origin for distances, also filters by radius when Radius is set
+	Radius      int64      // Meters
+	Bounds      *geo.BoundingBox
+	Categories  []models.ListingCategoryType
+	Countries   []string
+	Regions     []string
+	MinPrice    *int64
+	MaxPrice    *int64
+	Guests      *int64
+	MinDuration *int64 // Minutes
+	MaxDuration *int64 // Minutes
+	StartDate   *time.Time
+	EndDate     *time.Time // Defaults to the start date if only the start date is set
+	ExcludeID   *int64
+	Sort        SearchSort
+	Cursor      *string
+	Limit       int
+}
+
+type SearchResults struct {
+	Listings   []ListingDetails
+	TotalCount int64
+	NextCursor *string
+	Snippets   map[int64]string  // Highlighted description excerpts by listing ID, only set for text searches
+	Distances  map[int64]float64 // Meters from the search location by listing ID, only set for location searches
+}
+
+// Cursors are opaque to clients. They hold the sort key and ID of the last listing on the previous
+// page so the next page can be fetched with a keyset condition instead of an offset.
+type searchCursor struct {
+	Sort    SearchSort `json:"s"`
+	SortKey float64    `json:"k"`
+	ID      int64      `json:"i"`
+}
+
+type searchRow struct {
+	models.Listing
+	SortKey  float64
+	Snippet  *string
+	Distance *float64
+}
+
+// Every sort is expressed as a single double precision value so that cursors can be handled uniformly
+type sortExpression struct {
+	SQL        string
+	Vars       []interface{}
+	Descending bool
+}
+
+func Search(db *gorm.DB, params SearchParams) (*SearchResults, error) {
+	err := validateSearchParams(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.Search) validating params")
+	}
+
+	sortExpr, err := getSortExpression(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.Search) getting sort expression")
+	}
+
+	var totalCount int64
+	result := filteredSearchQuery(db, params).Count(&totalCount)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.Search) counting listings")
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = DEFAULT_SEARCH_LIMIT
+	} else if limit > MAX_SEARCH_LIMIT {
+		limit = MAX_SEARCH_LIMIT
+	}
+
+	selectSQL := "listings.*, " + sortExpr.SQL + " AS sort_key"
+	// Copied so the appends below can't write into the sort expression's backing array
+	selectVars := append([]interface{}{}, sortExpr.Vars...)
+	if params.Query != nil {
+		selectSQL += ", " + SNIPPET_SQL + " AS snippet"
+		selectVars = append(selectVars, toPrefixTsQuery(*params.Query))
+	}
+
+	if params.Location != nil {
+		distanceSQL, distanceVars := getDistanceSQL(*params.Location)
+		selectSQL += ", " + distanceSQL + " AS distance"
+		selectVars = append(selectVars, distanceVars...)
+	}
+
+	query := filteredSearchQuery(db, params).Select(selectSQL, selectVars...)
+
+	if params.Cursor != nil {
+		cursor, err := decodeSearchCursor(*params.Cursor)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.Search) decoding cursor")
+		}
+
+		if cursor.Sort != getSort(params) {
+			return nil, errors.NewBadRequest("Cursor does not match the requested sort")
+		}
+
+		comparison := ">"
+		if sortExpr.Descending {
+			comparison = "<"
+		}
+
+		var vars []interface{}
+		vars = append(vars, sortExpr.Vars...)
+		vars = append(vars, cursor.SortKey)
+		vars = append(vars, sortExpr.Vars...)
+		vars = append(vars, cursor.SortKey, cursor.ID)
+		query = query.Where(
+			"("+sortExpr.SQL+" "+comparison+" ? OR ("+sortExpr.SQL+" = ? AND listings.id "+comparison+" ?))",
+			vars...,
+		)
+	}
+
+	direction := "ASC"
+	if sortExpr.Descending {
+		direction = "DESC"
+	}
+
+	// Fetch one extra row to know whether there is another page
+	var rows []searchRow
+	result = query.
+		Order("sort_key " + direction + ", listings.id " + direction).
+		Limit(limit + 1).
+		Find(&rows)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.Search) loading listings")
+	}
+
+	var nextCursor *string
+	if len(rows) > limit {
+		rows = rows[:limit]
+		lastRow := rows[len(rows)-1]
+		encoded, err := encodeSearchCursor(searchCursor{
+			Sort:    getSort(params),
+			SortKey: lastRow.SortKey,
+			ID:      lastRow.ID,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.Search) encoding cursor")
+		}
+
+		nextCursor = encoded
+	}
+
+	listingDetails := make([]ListingDetails, len(rows))
+	snippets := make(map[int64]string)
+	distances := make(map[int64]float64)
+	for i, row := range rows {
+		details, err := loadDetailsForListing(db, row.Listing)
+		if err != nil {
+			return nil, errors.Wrap(err, "(listings.Search) loading details")
+		}
+
+		listingDetails[i] = *details
+		if row.Snippet != nil {
+			snippets[row.ID] = formatSnippet(*row.Snippet)
+		}
+
+		if row.Distance != nil {
+			distances[row.ID] = *row.Distance
+		}
+	}
+
+	return &SearchResults{
+		Listings:   listingDetails,
+		TotalCount: totalCount,
+		NextCursor: nextCursor,
+		Snippets:   snippets,
+		Distances:  distances,
+	}, nil
+}
+
+// Finds the closest published listings to the given one, optionally limited to listings sharing one of its categories
+func LoadNearby(db *gorm.DB, listing ListingDetails, sameCategories bool, limit int) (*SearchResults, error) {
+	if listing.Coordinates == nil {
+		return &SearchResults{Listings: []ListingDetails{}}, nil
+	}
+
+	params := SearchParams{
+		Location:  listing.Coordinates,
+		ExcludeID: &listing.ID,
+		Sort:      SearchSortDistance,
+		Limit:     limit,
+	}
+
+	if sameCategories {
+		for _, category := range listing.Categories {
+			if !slices.Contains(models.SPECIAL_CATEGORIES, category.Category) {
+				params.Categories = append(params.Categories, category.Category)
+			}
+		}
+	}
+
+	searchResults, err := Search(db, params)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.LoadNearby)")
+	}
+
+	// Only the closest listings are needed, so there is no next page
+	searchResults.NextCursor = nil
+	return searchResults, nil
+}
+
+func filteredSearchQuery(db *gorm.DB, params SearchParams) *gorm.DB {
+	query := db.Table("listings").
+		Where("listings.status = ?", models.ListingStatusPublished).
+		Where("listings.deactivated_at IS NULL")
+
+	if params.Query != nil {
+		// Full-text matches handle stemming and partial words, trigram matches on the name and location handle typos
+		query = query.Where(
+			"(listings.ts @@ to_tsquery('english_unaccent', ?) OR lower(unaccent(?)) <% listings.search_text)",
+			toPrefixTsQuery(*params.Query),
+			*params.Query,
+		)
+	}
+
+	if params.Location != nil && params.Radius > 0 {
+		// Match if the listing's main location, service area or any meeting point is within the radius
+		query = query.Where(
+			`(ST_DWithin(?, listings.coordinates::geography, ?)
+			OR ST_DWithin(?, listings.service_area, ?)
+			OR EXISTS (SELECT 1 FROM meeting_points WHERE meeting_points.listing_id = listings.id AND meeting_points.deactivated_at IS NULL AND ST_DWithin(?, meeting_points.coordinates, ?)))`,
+			*params.Location, params.Radius,
+			*params.Location, params.Radius,
+			*params.Location, params.Radius,
+		)
+	}
+
+	if params.ExcludeID != nil {
+		query = query.Where("listings.id != ?", *params.ExcludeID)
+	}
+
+	if params.Bounds != nil {
+		query = whereInBounds(query, *params.Bounds)
+	}
+
+	if len(params.Categories) > 0 {
+		// Use EXISTS rather than a join so listings with multiple matching categories are only returned once
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM listing_categories WHERE listing_categories.listing_id = listings.id AND listing_categories.category IN ? AND listing_categories.deactivated_at IS NULL)",
+			params.Categories,
+		)
+	}
+
+	if len(params.Countries) > 0 {
+		query = query.Where("listings.country IN ?", params.Countries)
+	}
+
+	if len(params.Regions) > 0 {
+		query = query.Where("listings.region IN ?", params.Regions)
+	}
+
+	if params.MinPrice != nil {
+		query = query.Where("listings.price >= ?", *params.MinPrice)
+	}
+
+	if params.MaxPrice != nil {
+		query = query.Where("listings.price <= ?", *params.MaxPrice)
+	}
+
+	if params.Guests != nil {
+		query = query.Where("listings.max_guests >= ?", *params.Guests)
+	}
+
+	if params.MinDuration != nil {
+		query = query.Where("listings.duration_minutes >= ?", *params.MinDuration)
+	}
+
+	if params.MaxDuration != nil {
+		query = query.Where("listings.duration_minutes <= ?", *params.MaxDuration)
+	}
+
+	if params.StartDate != nil {
+		endDate := *params.StartDate
+		if params.EndDate != nil {
+			endDate = *params.EndDate
+		}
+
+		guests := int64(1)
+		if params.Guests != nil {
+			guests = *params.Guests
+		}
+
+		query = query.Where(
+			AVAILABILITY_FILTER_SQL,
+			params.StartDate.Format(time.DateOnly),
+			endDate.Format(time.DateOnly),
+			models.AvailabilityRuleTypeFixedDate,
+			models.AvailabilityRuleTypeFixedRange,
+			models.AvailabilityRuleTypeRecurring,
+			guests,
+		)
+	}
+
+	return query
+}
+
+// Matches listings with at least one time slot in the date range that has room for the requested number of guests.
+// Mirrors the rule matching in the availability package: fixed date rules match their single date, fixed range rules
+// match their range on the time slot's day of the week, and recurring rules match the day of the week in any of the
+// rule's years and months (all of them if none are set). Remaining capacity subtracts unexpired bookings for the slot.
+const AVAILABILITY_FILTER_SQL = `EXISTS (
+	SELECT 1
+	FROM availability_rules
+	JOIN time_slots ON time_slots.availability_rule_id = availability_rules.id AND time_slots.deactivated_at IS NULL
+	CROSS JOIN generate_series(?::date, ?::date, '1 day'::interval) AS days(day)
+	WHERE availability_rules.listing_id = listings.id
+	AND availability_rules.deactivated_at IS NULL
+	AND (
+		(availability_rules.type = ? AND availability_rules.start_date = days.day::date)
+		OR (
+			availability_rules.type = ?
+			AND days.day::date BETWEEN availability_rules.start_date AND availability_rules.end_date
+			AND time_slots.day_of_week = EXTRACT(DOW FROM days.day)
+		)
+		OR (
+			availability_rules.type = ?
+			AND time_slots.day_of_week = EXTRACT(DOW FROM days.day)
+			AND (COALESCE(cardinality(availability_rules.recurring_years), 0) = 0 OR EXTRACT(YEAR FROM days.day)::smallint = ANY(availability_rules.recurring_years))
+			AND (COALESCE(cardinality(availability_rules.recurring_months), 0) = 0 OR EXTRACT(MONTH FROM days.day)::smallint = ANY(availability_rules.recurring_months))
+		)
+	)
+	AND COALESCE(time_slots.capacity, listings.max_guests) - COALESCE((
+		SELECT SUM(bookings.guests)
+		FROM bookings
+		WHERE bookings.listing_id = listings.id
+		AND bookings.start_date = days.day::date
+		AND bookings.start_time IS NOT DISTINCT FROM time_slots.start_time
+		AND (bookings.expires_at >= NOW() OR bookings.expires_at IS NULL)
+		AND bookings.deactivated_at IS NULL
+	), 0) >= ?
+)`
+
+// The selection markers are private use characters so that they can't appear in listing text. They are swapped for
+// <mark> tags after the rest of the snippet is escaped.
+const SNIPPET_START = "\uE000"
+const SNIPPET_STOP = "\uE001"
+const SNIPPET_SQL = `ts_headline(
+	'english_unaccent',
+	regexp_replace(coalesce(listings.short_description, '') || ' ' || coalesce(listings.description, ''), '<[^>]*>', ' ', 'g'),
+	to_tsquery('english_unaccent', ?),
+	'StartSel=` + SNIPPET_START + `, StopSel=` + SNIPPET_STOP + `, MaxWords=30, MinWords=15, MaxFragments=2'
+)`
+
+// Converts free text into a tsquery that requires every word, with prefix matching so partial words still match.
+// Punctuation is dropped so user input can't produce tsquery syntax errors.
+func toPrefixTsQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+func formatSnippet(snippet string) string {
+	escaped := html.EscapeString(html.UnescapeString(snippet))
+	escaped = strings.ReplaceAll(escaped, SNIPPET_START, "<mark>")
+	return strings.ReplaceAll(escaped, SNIPPET_STOP, "</mark>")
+}
+
+func validateSearchParams(params SearchParams) error {
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		return errors.NewBadRequest("Minimum price must be less than or equal to maximum price")
+	}
+
+	if params.MinDuration != nil && params.MaxDuration != nil && *params.MinDuration > *params.MaxDuration {
+		return errors.NewBadRequest("Minimum duration must be less than or equal to maximum duration")
+	}
+
+	if params.Guests != nil && *params.Guests < 1 {
+		return errors.NewBadRequest("Guests must be at least 1")
+	}
+
+	if params.EndDate != nil && params.StartDate == nil {
+		return errors.NewBadRequest("End date requires a start date")
+	}
+
+	if params.StartDate != nil {
+		today := time.Now().Truncate(24 * time.Hour)
+		if params.StartDate.Before(today) {
+			return errors.NewBadRequest("Start date must not be in the past")
+		}
+
+		if params.EndDate != nil {
+			if params.EndDate.Before(*params.StartDate) {
+				return errors.NewBadRequest("End date must be after start date")
+			}
+
+			if params.EndDate.Sub(*params.StartDate) > MAX_SEARCH_DATE_RANGE_DAYS*24*time.Hour {
+				return errors.NewBadRequestf("Date range must be at most %d days", MAX_SEARCH_DATE_RANGE_DAYS)
+			}
+		}
+	}
+
+	return nil
+}
+
+func getSort(params SearchParams) SearchSort {
+	if len(params.Sort) == 0 {
+		return SearchSortRelevance
+	}
+
+	return params.Sort
+}
+
+func getSortExpression(params SearchParams) (*sortExpression, error) {
+	newest := sortExpression{
+		SQL:        "EXTRACT(EPOCH FROM listings.created_at)::float8",
+		Descending: true,
+	}
+
+	switch getSort(params) {
+	case SearchSortRelevance:
+		if params.Query != nil {
+			// Rank uses the field weights from the tsvector, with trigram similarity as a smaller boost for near matches
+			return &sortExpression{
+				SQL:        "(ts_rank(listings.ts, to_tsquery('english_unaccent', ?)) + 0.1 * word_similarity(lower(unaccent(?)), listings.search_text))::float8",
+				Vars:       []interface{}{toPrefixTsQuery(*params.Query), *params.Query},
+				Descending: true,
+			}, nil
+		} else if params.Location != nil {
+			return getDistanceSortExpression(*params.Location), nil
+		} else {
+			return &newest, nil
+		}
+	case SearchSortPriceAsc:
+		return &sortExpression{
+			SQL:        "COALESCE(listings.price, 0)::float8",
+			Descending: false,
+		}, nil
+	case SearchSortPriceDesc:
+		return &sortExpression{
+			SQL:        "COALESCE(listings.price, 0)::float8",
+			Descending: true,
+		}, nil
+	case SearchSortDistance:
+		if params.Location == nil {
+			return nil, errors.NewBadRequest("Sorting by distance requires a location")
+		}
+
+		return getDistanceSortExpression(*params.Location), nil
+	case SearchSortNewest:
+		return &newest, nil
+	default:
+		return nil, errors.NewBadRequestf("Unsupported sort: %s", params.Sort)
+	}
+}
+
+// Listings without coordinates, a service area or meeting points have no distance, so they're sorted last
+// instead of giving a null sort key that can't go in a cursor
+func getDistanceSortExpression(location geo.Point) *sortExpression {
+	distanceSQL, distanceVars := getDistanceSQL(location)
+	return &sortExpression{
+		SQL:        "COALESCE(" + distanceSQL + ", ?)::float8",
+		Vars:       append(distanceVars, UNKNOWN_DISTANCE_SORT_KEY),
+		Descending: false,
+	}
+}
+
+// Distance to the closest of the listing's main location, service area and meeting points. LEAST ignores nulls,
+// so listings without a service area or meeting points just use the others.
+func getDistanceSQL(location geo.Point) (string, []interface{}) {
+	return `LEAST(
+		ST_Distance(listings.coordinates::geography, ?::geography),
+		ST_Distance(listings.service_area, ?::geography),
+		(SELECT MIN(ST_Distance(meeting_points.coordinates, ?::geography)) FROM meeting_points WHERE meeting_points.listing_id = listings.id AND meeting_points.deactivated_at IS NULL)
+	)`, []interface{}{location, location, location}
+}
+
+func encodeSearchCursor(cursor searchCursor) (*string, error) {
+	b, err := json.Marshal(cursor)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.encodeSearchCursor)")
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(b)
+	return &encoded, nil
+}
+
+func decodeSearchCursor(encoded string) (*searchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.NewBadRequest("Invalid cursor")
+	}
+
+	var cursor searchCursor
+	err = json.Unmarshal(b, &cursor)
+	if err != nil {
+		return nil, errors.NewBadRequest("Invalid cursor")
+	}
+
+	return &cursor, nil
+}
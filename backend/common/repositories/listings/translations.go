@@ -0,0 +1,309 @@
+package listings
+
+import (
+	"time"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/locales"
+	"go.coaster.io/server/common/models"
+	"golang.org/x/text/language"
+	"gorm.io/gorm"
+)
+
+type TranslationDetails struct {
+	models.ListingTranslation
+	ItinerarySteps []models.ItineraryStepTranslation
+}
+
+func LoadTranslations(db *gorm.DB, listingID int64) ([]TranslationDetails, error) {
+	var translations []models.ListingTranslation
+	result := db.Table("listing_translations").
+		Select("listing_translations.*").
+		Where("listing_translations.listing_id = ?", listingID).
+		Where("listing_translations.deactivated_at IS NULL").
+		Order("listing_translations.locale ASC").
+		Find(&translations)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.LoadTranslations)")
+	}
+
+	stepTranslations, err := loadItineraryStepTranslations(db, []int64{listingID}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.LoadTranslations)")
+	}
+
+	translationDetails := make([]TranslationDetails, len(translations))
+	for i, translation := range translations {
+		translationDetails[i] = TranslationDetails{
+			ListingTranslation: translation,
+			ItinerarySteps:     []models.ItineraryStepTranslation{},
+		}
+
+		for _, stepTranslation := range stepTranslations {
+			if stepTranslation.Locale == translation.Locale {
+				translationDetails[i].ItinerarySteps = append(translationDetails[i].ItinerarySteps, stepTranslation.ItineraryStepTranslation)
+			}
+		}
+	}
+
+	return translationDetails, nil
+}
+
+// Replaces the translation for a locale, including the translations for the listing's itinerary steps
+func UpdateTranslation(db *gorm.DB, listing ListingDetails, locale string, translationInput input.ListingTranslation) (*TranslationDetails, error) {
+	locale, err := locales.Normalize(locale)
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.UpdateTranslation)")
+	}
+
+	if locale == listing.SourceLocale {
+		return nil, errors.NewBadRequest("Translations can't use the same locale as the listing")
+	}
+
+	stepIDs := make(map[int64]bool)
+	for _, step := range listing.ItinerarySteps {
+		stepIDs[step.ID] = true
+	}
+
+	for _, stepInput := range translationInput.ItinerarySteps {
+		if !stepIDs[stepInput.ID] {
+			return nil, errors.NewBadRequestf("Invalid itinerary step ID: %d", stepInput.ID)
+		}
+	}
+
+	translationDetails := TranslationDetails{
+		ListingTranslation: models.ListingTranslation{
+			ListingID:        listing.ID,
+			Locale:           locale,
+			Name:             translationInput.Name,
+			Description:      input.SanitizePtr(translationInput.Description),
+			ShortDescription: translationInput.ShortDescription,
+			Highlights:       translationInput.Highlights,
+		},
+		ItinerarySteps: make([]models.ItineraryStepTranslation, len(translationInput.ItinerarySteps)),
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := deactivateTranslation(tx, listing.ID, locale)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Create(&translationDetails.ListingTranslation)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "(listings.UpdateTranslation) creating translation")
+		}
+
+		for i, stepInput := range translationInput.ItinerarySteps {
+			translationDetails.ItinerarySteps[i] = models.ItineraryStepTranslation{
+				ItineraryStepID: stepInput.ID,
+				Locale:          locale,
+				Title:           stepInput.Title,
+				Description:     stepInput.Description,
+				StepLabel:       stepInput.StepLabel,
+			}
+
+			result := tx.Create(&translationDetails.ItinerarySteps[i])
+			if result.Error != nil {
+				return errors.Wrap(result.Error, "(listings.UpdateTranslation) creating itinerary step translation")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "(listings.UpdateTranslation)")
+	}
+
+	return &translationDetails, nil
+}
+
+func DeleteTranslation(db *gorm.DB, listingID int64, locale string) error {
+	locale, err := locales.Normalize(locale)
+	if err != nil {
+		return errors.Wrap(err, "(listings.DeleteTranslation)")
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return deactivateTranslation(tx, listingID, locale)
+	})
+	if err != nil {
+		return errors.Wrap(err, "(listings.DeleteTranslation)")
+	}
+
+	return nil
+}
+
+// Swaps in the best translation for each listing based on the requested locales. Fields without a
+// translation keep the source text, and the locale that was picked is set on each listing.
+func LocalizeListings(db *gorm.DB, listings []ListingDetails, requested []language.Tag) error {
+	if len(listings) == 0 {
+		return nil
+	}
+
+	listingIDs := make([]int64, len(listings))
+	for i, listing := range listings {
+		listingIDs[i] = listing.ID
+		listings[i].Locale = listing.SourceLocale
+	}
+
+	// Nothing was asked for, so everything is served in its source language
+	if len(requested) == 0 {
+		return nil
+	}
+
+	var translations []models.ListingTranslation
+	result := db.Table("listing_translations").
+		Select("listing_translations.*").
+		Where("listing_translations.listing_id IN ?", listingIDs).
+		Where("listing_translations.deactivated_at IS NULL").
+		Find(&translations)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.LocalizeListings) loading translations")
+	}
+
+	translationsByListing := make(map[int64]map[string]models.ListingTranslation)
+	for _, translation := range translations {
+		if _, ok := translationsByListing[translation.ListingID]; !ok {
+			translationsByListing[translation.ListingID] = make(map[string]models.ListingTranslation)
+		}
+
+		translationsByListing[translation.ListingID][translation.Locale] = translation
+	}
+
+	servedLocales := make(map[string]bool)
+	for i := range listings {
+		listingTranslations, ok := translationsByListing[listings[i].ID]
+		if !ok {
+			continue
+		}
+
+		available := []string{listings[i].SourceLocale}
+		for locale := range listingTranslations {
+			available = append(available, locale)
+		}
+
+		locale := locales.Match(requested, available)
+		if locale == listings[i].SourceLocale {
+			continue
+		}
+
+		applyTranslation(&listings[i], listingTranslations[locale])
+		servedLocales[locale] = true
+	}
+
+	if len(servedLocales) == 0 {
+		return nil
+	}
+
+	localeList := make([]string, 0, len(servedLocales))
+	for locale := range servedLocales {
+		localeList = append(localeList, locale)
+	}
+
+	stepTranslations, err := loadItineraryStepTranslations(db, listingIDs, localeList)
+	if err != nil {
+		return errors.Wrap(err, "(listings.LocalizeListings)")
+	}
+
+	for _, stepTranslation := range stepTranslations {
+		for i := range listings {
+			if listings[i].ID != stepTranslation.ListingID || listings[i].Locale != stepTranslation.Locale {
+				continue
+			}
+
+			for j := range listings[i].ItinerarySteps {
+				if listings[i].ItinerarySteps[j].ID == stepTranslation.ItineraryStepID {
+					applyItineraryStepTranslation(&listings[i].ItinerarySteps[j], stepTranslation.ItineraryStepTranslation)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+type itineraryStepTranslationWithListing struct {
+	models.ItineraryStepTranslation
+	ListingID int64
+}
+
+func loadItineraryStepTranslations(db *gorm.DB, listingIDs []int64, localeList []string) ([]itineraryStepTranslationWithListing, error) {
+	query := db.Table("itinerary_step_translations").
+		Select("itinerary_step_translations.*, itinerary_steps.listing_id").
+		Joins("JOIN itinerary_steps ON itinerary_steps.id = itinerary_step_translations.itinerary_step_id").
+		Where("itinerary_steps.listing_id IN ?", listingIDs).
+		Where("itinerary_steps.deactivated_at IS NULL").
+		Where("itinerary_step_translations.deactivated_at IS NULL").
+		Order("itinerary_steps.step_order ASC, itinerary_steps.id ASC")
+
+	if localeList != nil {
+		query = query.Where("itinerary_step_translations.locale IN ?", localeList)
+	}
+
+	var stepTranslations []itineraryStepTranslationWithListing
+	result := query.Find(&stepTranslations)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(listings.loadItineraryStepTranslations)")
+	}
+
+	return stepTranslations, nil
+}
+
+func deactivateTranslation(db *gorm.DB, listingID int64, locale string) error {
+	currentTime := time.Now()
+	result := db.Model(&models.ListingTranslation{}).
+		Where("listing_id = ?", listingID).
+		Where("locale = ?", locale).
+		Where("deactivated_at IS NULL").
+		Update("deactivated_at", currentTime)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.deactivateTranslation) deactivating translation")
+	}
+
+	result = db.Model(&models.ItineraryStepTranslation{}).
+		Where("itinerary_step_id IN (?)", db.Table("itinerary_steps").Select("id").Where("listing_id = ?", listingID)).
+		Where("locale = ?", locale).
+		Where("deactivated_at IS NULL").
+		Update("deactivated_at", currentTime)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(listings.deactivateTranslation) deactivating itinerary step translations")
+	}
+
+	return nil
+}
+
+func applyTranslation(listing *ListingDetails, translation models.ListingTranslation) {
+	listing.Locale = translation.Locale
+
+	if translation.Name != nil && len(*translation.Name) > 0 {
+		listing.Name = translation.Name
+	}
+
+	if translation.Description != nil && len(*translation.Description) > 0 {
+		listing.Description = translation.Description
+	}
+
+	if translation.ShortDescription != nil && len(*translation.ShortDescription) > 0 {
+		listing.ShortDescription = translation.ShortDescription
+	}
+
+	if len(translation.Highlights) > 0 {
+		listing.Highlights = translation.Highlights
+	}
+}
+
+func applyItineraryStepTranslation(step *models.ItineraryStep, translation models.ItineraryStepTranslation) {
+	if translation.Title != nil && len(*translation.Title) > 0 {
+		step.Title = *translation.Title
+	}
+
+	if translation.Description != nil && len(*translation.Description) > 0 {
+		step.Description = *translation.Description
+	}
+
+	if translation.StepLabel != nil && len(*translation.StepLabel) > 0 {
+		step.StepLabel = *translation.StepLabel
+	}
+}
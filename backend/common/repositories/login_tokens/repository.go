@@ -0,0 +1,112 @@
+package login_tokens
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/sessions"
+	"gorm.io/gorm"
+)
+
+const TOKEN_BITS = 256
+
+// Anyone with access to the inbox can log in, so links are only good for a short time
+const TOKEN_EXPIRATION = time.Minute * 15
+
+// Requests inside the cooldown don't send another email, so the endpoint can't be used to flood
+// someone's inbox
+const SEND_COOLDOWN = time.Minute
+
+func generateToken() (string, error) {
+	b := make([]byte, TOKEN_BITS/8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrap(err, "(login_tokens.generateToken)")
+	}
+
+	return fmt.Sprintf("%x", b), nil
+}
+
+// For the cookie that ties a link to the browser that requested it
+func GenerateDeviceNonce() (string, error) {
+	nonce, err := generateToken()
+	if err != nil {
+		return "", errors.Wrap(err, "(login_tokens.GenerateDeviceNonce)")
+	}
+
+	return nonce, nil
+}
+
+// Returns the raw token to put in the link
+func Create(db *gorm.DB, userID int64, origin string, deviceNonce string) (*string, error) {
+	rawToken, err := generateToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "(login_tokens.Create)")
+	}
+
+	// Stored hashed like sessions, since a leaked token is as good as a session
+	hashedNonce := sessions.HashToken(deviceNonce)
+	loginToken := models.LoginToken{
+		UserID:      userID,
+		Token:       sessions.HashToken(rawToken),
+		Origin:      origin,
+		Expiration:  time.Now().Add(TOKEN_EXPIRATION),
+		DeviceNonce: &hashedNonce,
+	}
+
+	result := db.Create(&loginToken)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(login_tokens.Create)")
+	}
+
+	return &rawToken, nil
+}
+
+func WasSentSince(db *gorm.DB, userID int64, since time.Time) (bool, error) {
+	var count int64
+	result := db.Table("login_tokens").
+		Where("login_tokens.user_id = ?", userID).
+		Where("login_tokens.created_at >= ?", since).
+		Count(&count)
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "(login_tokens.WasSentSince)")
+	}
+
+	return count > 0, nil
+}
+
+func LoadValidByToken(db *gorm.DB, rawToken string) (*models.LoginToken, error) {
+	var loginToken models.LoginToken
+	result := db.Table("login_tokens").
+		Select("login_tokens.*").
+		Where("login_tokens.token = ?", sessions.HashToken(rawToken)).
+		Where("login_tokens.expiration >= ?", time.Now()).
+		Where("login_tokens.deactivated_at IS NULL").
+		Take(&loginToken)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(login_tokens.LoadValidByToken)")
+	}
+
+	return &loginToken, nil
+}
+
+// Marks the token used. Only one of several concurrent requests with the same token succeeds, the
+// rest get gorm.ErrRecordNotFound.
+func Consume(db *gorm.DB, loginToken *models.LoginToken) error {
+	result := db.Table("login_tokens").
+		Where("login_tokens.id = ?", loginToken.ID).
+		Where("login_tokens.deactivated_at IS NULL").
+		Update("deactivated_at", time.Now())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(login_tokens.Consume)")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "(login_tokens.Consume) already used")
+	}
+
+	return nil
+}
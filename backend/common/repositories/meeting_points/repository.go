@@ -0,0 +1,157 @@
+package meeting_points
+
+import (
+	"time"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/models"
+	"gorm.io/gorm"
+)
+
+func createMeetingPoint(db *gorm.DB, listingID int64, meetingPointInput input.MeetingPoint) (*models.MeetingPoint, error) {
+	if meetingPointInput.Name == nil || meetingPointInput.Coordinates == nil {
+		return nil, errors.NewCustomerVisibleError("Missing required fields for new meeting point")
+	}
+
+	err := meetingPointInput.Coordinates.Validate()
+	if err != nil {
+		return nil, errors.NewCustomerVisibleErrorf("Invalid meeting point coordinates: %s", err.Error())
+	}
+
+	meetingPoint := models.MeetingPoint{
+		ListingID:    listingID,
+		Name:         *meetingPointInput.Name,
+		Instructions: meetingPointInput.Instructions,
+		Coordinates:  *meetingPointInput.Coordinates,
+	}
+
+	err = db.Create(&meetingPoint).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "(meeting_points.createMeetingPoint)")
+	}
+
+	return &meetingPoint, nil
+}
+
+func updateMeetingPoint(db *gorm.DB, meetingPoint models.MeetingPoint, meetingPointInput input.MeetingPoint) (*models.MeetingPoint, error) {
+	if meetingPointInput.Name != nil {
+		meetingPoint.Name = *meetingPointInput.Name
+	}
+
+	if meetingPointInput.Instructions != nil {
+		meetingPoint.Instructions = meetingPointInput.Instructions
+	}
+
+	if meetingPointInput.Coordinates != nil {
+		err := meetingPointInput.Coordinates.Validate()
+		if err != nil {
+			return nil, errors.NewCustomerVisibleErrorf("Invalid meeting point coordinates: %s", err.Error())
+		}
+
+		meetingPoint.Coordinates = *meetingPointInput.Coordinates
+	}
+
+	err := db.Save(&meetingPoint).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "(meeting_points.updateMeetingPoint) updating meeting point %+v", meetingPoint)
+	}
+
+	return &meetingPoint, nil
+}
+
+// Replaces the listing's meeting points with the input list. Existing points are updated by ID and any missing
+// from the input are deleted.
+func UpdateMeetingPoints(db *gorm.DB, listingID int64, meetingPointInput []input.MeetingPoint) ([]models.MeetingPoint, error) {
+	existingMeetingPoints, err := LoadMeetingPointsForListing(db, listingID)
+	if err != nil {
+		return nil, errors.Wrap(err, "(meeting_points.UpdateMeetingPoints) loading meeting points for listing")
+	}
+
+	meetingPointIdMap := make(map[int64]models.MeetingPoint)
+	for _, meetingPoint := range existingMeetingPoints {
+		meetingPointIdMap[meetingPoint.ID] = meetingPoint
+	}
+
+	newMeetingPoints := make([]models.MeetingPoint, len(meetingPointInput))
+	updatedMeetingPointIds := make(map[int64]bool)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i, pointInput := range meetingPointInput {
+			if pointInput.ID == nil {
+				newMeetingPoint, err := createMeetingPoint(tx, listingID, pointInput)
+				if err != nil {
+					return errors.Wrap(err, "(meeting_points.UpdateMeetingPoints) creating new meeting point")
+				}
+
+				newMeetingPoints[i] = *newMeetingPoint
+			} else {
+				existingMeetingPoint, ok := meetingPointIdMap[*pointInput.ID]
+				if !ok {
+					return errors.NewCustomerVisibleErrorf("Invalid meeting point ID: %d", *pointInput.ID)
+				}
+
+				updatedMeetingPoint, err := updateMeetingPoint(tx, existingMeetingPoint, pointInput)
+				if err != nil {
+					return errors.Wrap(err, "(meeting_points.UpdateMeetingPoints) updating meeting point")
+				}
+
+				updatedMeetingPointIds[existingMeetingPoint.ID] = true
+				newMeetingPoints[i] = *updatedMeetingPoint
+			}
+		}
+
+		for _, meetingPoint := range existingMeetingPoints {
+			if !updatedMeetingPointIds[meetingPoint.ID] {
+				result := tx.Model(&meetingPoint).Update("deactivated_at", time.Now())
+				if result.Error != nil {
+					return errors.Wrapf(result.Error, "(meeting_points.UpdateMeetingPoints) deleting meeting point %+v", meetingPoint)
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		// Already wrapped inside the transaction
+		return nil, err
+	}
+
+	return newMeetingPoints, nil
+}
+
+func LoadMeetingPointsForListing(db *gorm.DB, listingID int64) ([]models.MeetingPoint, error) {
+	var meetingPoints []models.MeetingPoint
+	result := db.Table("meeting_points").
+		Select("meeting_points.*").
+		Where("meeting_points.listing_id = ?", listingID).
+		Where("meeting_points.deactivated_at IS NULL").
+		Order("meeting_points.id ASC").
+		Find(&meetingPoints)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(meeting_points.LoadMeetingPointsForListing)")
+	}
+
+	return meetingPoints, nil
+}
+
+// Copies the meeting points from one listing to another, used when duplicating a listing
+func CopyForListing(db *gorm.DB, fromListingID int64, toListingID int64) error {
+	meetingPoints, err := LoadMeetingPointsForListing(db, fromListingID)
+	if err != nil {
+		return errors.Wrap(err, "(meeting_points.CopyForListing) loading meeting points")
+	}
+
+	for _, meetingPoint := range meetingPoints {
+		result := db.Create(&models.MeetingPoint{
+			ListingID:    toListingID,
+			Name:         meetingPoint.Name,
+			Instructions: meetingPoint.Instructions,
+			Coordinates:  meetingPoint.Coordinates,
+		})
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "(meeting_points.CopyForListing) creating meeting point")
+		}
+	}
+
+	return nil
+}
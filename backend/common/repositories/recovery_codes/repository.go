@@ -0,0 +1,87 @@
+package recovery_codes
+
+import (
+	"time"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/totp"
+	"gorm.io/gorm"
+)
+
+// Replaces any existing codes for the user. Returns the raw codes, which are shown once and never
+// stored.
+func Generate(db *gorm.DB, userID int64) ([]string, error) {
+	codes, err := totp.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "(recovery_codes.Generate)")
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := DeactivateAllForUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		recoveryCodes := make([]models.RecoveryCode, len(codes))
+		for i, code := range codes {
+			recoveryCodes[i] = models.RecoveryCode{
+				UserID:   userID,
+				CodeHash: totp.HashRecoveryCode(code),
+			}
+		}
+
+		result := tx.Create(&recoveryCodes)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "creating codes")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "(recovery_codes.Generate)")
+	}
+
+	return codes, nil
+}
+
+// Marks the code used. Returns false if it doesn't match an unused code for the user.
+func Use(db *gorm.DB, userID int64, code string) (bool, error) {
+	result := db.Table("recovery_codes").
+		Where("recovery_codes.user_id = ?", userID).
+		Where("recovery_codes.code_hash = ?", totp.HashRecoveryCode(code)).
+		Where("recovery_codes.used_at IS NULL").
+		Where("recovery_codes.deactivated_at IS NULL").
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "(recovery_codes.Use)")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func CountRemaining(db *gorm.DB, userID int64) (int64, error) {
+	var count int64
+	result := db.Table("recovery_codes").
+		Where("recovery_codes.user_id = ?", userID).
+		Where("recovery_codes.used_at IS NULL").
+		Where("recovery_codes.deactivated_at IS NULL").
+		Count(&count)
+	if result.Error != nil {
+		return 0, errors.Wrap(result.Error, "(recovery_codes.CountRemaining)")
+	}
+
+	return count, nil
+}
+
+func DeactivateAllForUser(db *gorm.DB, userID int64) error {
+	result := db.Table("recovery_codes").
+		Where("recovery_codes.user_id = ?", userID).
+		Where("recovery_codes.deactivated_at IS NULL").
+		Update("deactivated_at", time.Now())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(recovery_codes.DeactivateAllForUser)")
+	}
+
+	return nil
+}
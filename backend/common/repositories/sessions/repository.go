@@ -17,6 +17,10 @@ const TOKEN_BITS = 256
 const DAY = time.Duration(24) * time.Hour
 const SESSION_EXPIRATION = time.Duration(14) * DAY
 
+// Someone with a stolen session could otherwise guess passwords or codes through reauthentication
+// forever, so the session is ended after a few wrong ones
+const MAX_REAUTHENTICATION_FAILURES = 5
+
 func generateToken() (*string, error) {
 	b := make([]byte, TOKEN_BITS/8)
 	_, err := rand.Read(b)
@@ -35,29 +39,101 @@ func HashToken(token string) string {
 }
 
 func Create(db *gorm.DB, userID int64) (*string, error) {
-	rawToken, err := generateToken()
+	rawToken, err := create(db, userID, nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "(sessions.Create)")
 	}
 
+	return rawToken, nil
+}
+
+// For logins that finished with a TOTP or recovery code
+func CreateWithSecondFactor(db *gorm.DB, userID int64) (*string, error) {
+	now := time.Now()
+	rawToken, err := create(db, userID, &now)
+	if err != nil {
+		return nil, errors.Wrap(err, "(sessions.CreateWithSecondFactor)")
+	}
+
+	return rawToken, nil
+}
+
+func create(db *gorm.DB, userID int64, secondFactorAt *time.Time) (*string, error) {
+	rawToken, err := generateToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "(sessions.create)")
+	}
+
 	// we store hashed tokens in case the DB is leaked
 	token := HashToken(*rawToken)
 	expiration := time.Now().Add(SESSION_EXPIRATION)
 
 	session := models.Session{
-		Token:      token,
-		UserID:     userID,
-		Expiration: expiration,
+		Token:           token,
+		UserID:          userID,
+		Expiration:      expiration,
+		AuthenticatedAt: time.Now(),
+		SecondFactorAt:  secondFactorAt,
 	}
 
 	result := db.Create(&session)
 	if result.Error != nil {
-		return nil, errors.Wrap(result.Error, "(sessions.Create)")
+		return nil, errors.Wrap(result.Error, "(sessions.create)")
 	}
 
 	return rawToken, nil
 }
 
+// Records that the user just proved who they are again, for endpoints that need a recent login
+func Reauthenticate(db *gorm.DB, session *models.Session, withSecondFactor bool) error {
+	now := time.Now()
+	updates := map[string]interface{}{"authenticated_at": now, "reauthentication_failures": 0}
+	if withSecondFactor {
+		updates["second_factor_at"] = now
+	}
+
+	result := db.Model(session).Updates(updates)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(sessions.Reauthenticate)")
+	}
+
+	return nil
+}
+
+// Returns true if that was one failure too many and the session has been ended
+func RecordReauthenticationFailure(db *gorm.DB, session *models.Session) (bool, error) {
+	result := db.Model(session).Update("reauthentication_failures", gorm.Expr("reauthentication_failures + 1"))
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "(sessions.RecordReauthenticationFailure)")
+	}
+
+	result = db.Table("sessions").
+		Where("sessions.id = ?", session.ID).
+		Where("sessions.reauthentication_failures >= ?", MAX_REAUTHENTICATION_FAILURES).
+		Where("sessions.deactivated_at IS NULL").
+		Update("deactivated_at", time.Now())
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "(sessions.RecordReauthenticationFailure) ending session")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Logs the user out everywhere else, e.g. after turning on two-factor so older sessions that never
+// used it stop working
+func ClearOthers(db *gorm.DB, session *models.Session) error {
+	result := db.Table("sessions").
+		Where("sessions.user_id = ?", session.UserID).
+		Where("sessions.id != ?", session.ID).
+		Where("sessions.deactivated_at IS NULL").
+		Update("deactivated_at", time.Now())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(sessions.ClearOthers)")
+	}
+
+	return nil
+}
+
 func Refresh(db *gorm.DB, session *models.Session) (*models.Session, error) {
 	expiration := time.Now().Add(SESSION_EXPIRATION)
 	result := db.Model(session).Update("expiration", expiration)
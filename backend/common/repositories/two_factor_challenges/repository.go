@@ -0,0 +1,96 @@
+package two_factor_challenges
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/sessions"
+	"gorm.io/gorm"
+)
+
+const TOKEN_BITS = 256
+const CHALLENGE_EXPIRATION = time.Minute * 10
+
+// Six digit codes can be guessed given enough tries, so a challenge stops working after a few
+// attempts and the user has to log in again. Users are also limited across challenges, see
+// users.ReserveTwoFactorAttempt.
+const MAX_ATTEMPTS = 5
+
+// Returns the raw token for the client to send back with the code
+func Create(db *gorm.DB, userID int64) (*string, error) {
+	b := make([]byte, TOKEN_BITS/8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "(two_factor_challenges.Create) generating token")
+	}
+	rawToken := fmt.Sprintf("%x", b)
+
+	challenge := models.TwoFactorChallenge{
+		UserID:     userID,
+		Token:      sessions.HashToken(rawToken),
+		Expiration: time.Now().Add(CHALLENGE_EXPIRATION),
+	}
+
+	result := db.Create(&challenge)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(two_factor_challenges.Create)")
+	}
+
+	return &rawToken, nil
+}
+
+func LoadValidByToken(db *gorm.DB, rawToken string) (*models.TwoFactorChallenge, error) {
+	var challenge models.TwoFactorChallenge
+	result := db.Table("two_factor_challenges").
+		Select("two_factor_challenges.*").
+		Where("two_factor_challenges.token = ?", sessions.HashToken(rawToken)).
+		Where("two_factor_challenges.expiration >= ?", time.Now()).
+		Where("two_factor_challenges.failed_attempts < ?", MAX_ATTEMPTS).
+		Where("two_factor_challenges.deactivated_at IS NULL").
+		Take(&challenge)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(two_factor_challenges.LoadValidByToken)")
+	}
+
+	return &challenge, nil
+}
+
+// Counts an attempt before the code is checked, in one statement so parallel requests can't get
+// more than MAX_ATTEMPTS guesses between them. Returns gorm.ErrRecordNotFound once they're used up.
+func ReserveAttempt(db *gorm.DB, challenge *models.TwoFactorChallenge) error {
+	result := db.Table("two_factor_challenges").
+		Where("two_factor_challenges.id = ?", challenge.ID).
+		Where("two_factor_challenges.failed_attempts < ?", MAX_ATTEMPTS).
+		Where("two_factor_challenges.deactivated_at IS NULL").
+		Update("failed_attempts", gorm.Expr("failed_attempts + 1"))
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(two_factor_challenges.ReserveAttempt)")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "(two_factor_challenges.ReserveAttempt) out of attempts")
+	}
+
+	return nil
+}
+
+// Marks the challenge used. Only one of several concurrent requests with the same challenge
+// succeeds, the rest get gorm.ErrRecordNotFound.
+func Consume(db *gorm.DB, challenge *models.TwoFactorChallenge) error {
+	result := db.Table("two_factor_challenges").
+		Where("two_factor_challenges.id = ?", challenge.ID).
+		Where("two_factor_challenges.deactivated_at IS NULL").
+		Update("deactivated_at", time.Now())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(two_factor_challenges.Consume)")
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.Wrap(gorm.ErrRecordNotFound, "(two_factor_challenges.Consume) already used")
+	}
+
+	return nil
+}
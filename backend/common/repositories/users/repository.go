@@ -2,10 +2,12 @@ package users
 
 import (
 	"strings"
+	"time"
 
 	"go.coaster.io/server/common/emails"
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/events"
+	"go.coaster.io/server/common/images"
 	"go.coaster.io/server/common/input"
 	"go.coaster.io/server/common/models"
 	"go.coaster.io/server/common/oauth"
@@ -18,10 +20,12 @@ import (
 // Maximum of 62^8 guarantees number will be at most 8 digits in base
 const MAX_RANDOM = 218340105584896
 
-func LoadByExternalID(db *gorm.DB, externalID string) (*models.User, error) {
+// External IDs are only unique within a provider
+func LoadByExternalID(db *gorm.DB, provider oauth.OauthProvider, externalID string) (*models.User, error) {
 	var user models.User
 	result := db.Table("users").
 		Joins("JOIN external_profiles ON external_profiles.user_id = users.id").
+		Where("external_profiles.oauth_provider = ?", provider).
 		Where("external_profiles.external_id = ?", externalID).
 		Where("users.deactivated_at IS NULL").
 		Where("external_profiles.deactivated_at IS NULL").
@@ -92,10 +96,12 @@ func UpdateUser(db *gorm.DB, user *models.User, updates input.UserUpdates) (*mod
 	return user, nil
 }
 
-func UpdateProfilePicture(db *gorm.DB, user *models.User, profilePictureUrl string, width int, height int) (*models.User, error) {
+func UpdateProfilePicture(db *gorm.DB, user *models.User, profilePictureUrl string, width int, height int, placeholder images.Placeholder) (*models.User, error) {
 	user.ProfilePictureURL = &profilePictureUrl
 	user.ProfilePictureWidth = &width
 	user.ProfilePictureHeight = &height
+	user.ProfilePictureBlurhash = &placeholder.Blurhash
+	user.ProfilePictureDominantColor = &placeholder.DominantColor
 
 	result := db.Save(user)
 	if result.Error != nil {
@@ -105,6 +111,50 @@ func UpdateProfilePicture(db *gorm.DB, user *models.User, profilePictureUrl stri
 	return user, nil
 }
 
+// Includes deactivated users, since their profile is kept in case they're reactivated
+func LoadAllWithProfilePicture(db *gorm.DB) ([]models.User, error) {
+	var users []models.User
+	result := db.Table("users").
+		Where("users.profile_picture_url IS NOT NULL").
+		Order("users.id ASC").
+		Find(&users)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(users.LoadAllWithProfilePicture)")
+	}
+
+	return users, nil
+}
+
+// Users whose profile picture was uploaded before placeholders were generated, paged by ID
+func LoadMissingProfilePicturePlaceholder(db *gorm.DB, afterID int64, limit int) ([]models.User, error) {
+	var users []models.User
+	result := db.Table("users").
+		Where("users.id > ?", afterID).
+		Where("users.profile_picture_url IS NOT NULL").
+		Where("users.profile_picture_blurhash IS NULL").
+		Where("users.deactivated_at IS NULL").
+		Order("users.id ASC").
+		Limit(limit).
+		Find(&users)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(users.LoadMissingProfilePicturePlaceholder)")
+	}
+
+	return users, nil
+}
+
+func UpdateProfilePicturePlaceholder(db *gorm.DB, user *models.User, placeholder images.Placeholder) error {
+	result := db.Model(user).Updates(map[string]interface{}{
+		"profile_picture_blurhash":       placeholder.Blurhash,
+		"profile_picture_dominant_color": placeholder.DominantColor,
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(users.UpdateProfilePicturePlaceholder)")
+	}
+
+	return nil
+}
+
 func CreateUserFromEmail(db *gorm.DB, email string, firstName string, lastName string, password string) (*models.User, error) {
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -141,15 +191,18 @@ func CreateUserForExternalInfo(db *gorm.DB, externalUserInfo *oauth.ExternalUser
 		FirstName:           externalUserInfo.FirstName,
 		LastName:            externalUserInfo.LastName,
 		Email:               strings.ToLower(externalUserInfo.Email),
-		ProfilePictureURL:   &externalUserInfo.ProfilePictureURL,
-		LoginMethod:         models.LoginMethodGoogle,
+		LoginMethod:         getLoginMethod(externalUserInfo.OauthProvider),
 		IsHost:              false,
-		EmailVerified:       true,
+		EmailVerified:       externalUserInfo.EmailVerified,
 		StripeAccountStatus: models.StripeAccountStatusIncomplete,
 		Currency:            "USD", // TODO: allow other currencies
 		CommissionPercent:   15,    // TODO: allow other take rates
 	}
 
+	if externalUserInfo.ProfilePictureURL != "" {
+		user.ProfilePictureURL = &externalUserInfo.ProfilePictureURL
+	}
+
 	result := db.Create(&user)
 	if result.Error != nil {
 		return nil, errors.Wrap(result.Error, "(users.create)")
@@ -167,7 +220,7 @@ func CreateUserForExternalInfo(db *gorm.DB, externalUserInfo *oauth.ExternalUser
 }
 
 func GetOrCreateForExternalInfo(db *gorm.DB, externalUserInfo *oauth.ExternalUserInfo) (*models.User, error) {
-	existingUser, err := LoadByExternalID(db, externalUserInfo.ExternalID)
+	existingUser, err := LoadByExternalID(db, externalUserInfo.OauthProvider, externalUserInfo.ExternalID)
 	if err != nil && !errors.IsRecordNotFound(err) {
 		return nil, errors.Wrap(err, "(users.GetOrCreateForExternalInfo)")
 	} else if err == nil {
@@ -182,6 +235,17 @@ func GetOrCreateForExternalInfo(db *gorm.DB, externalUserInfo *oauth.ExternalUse
 	return user, nil
 }
 
+func getLoginMethod(provider oauth.OauthProvider) models.LoginMethod {
+	switch provider {
+	case oauth.OauthProviderApple:
+		return models.LoginMethodApple
+	case oauth.OauthProviderFacebook:
+		return models.LoginMethodFacebook
+	default:
+		return models.LoginMethodGoogle
+	}
+}
+
 func SetIsHost(db *gorm.DB, userID int64, isHost bool) error {
 	result := db.Table("users").Where("id = ?", userID).Update("is_host", isHost)
 	if result.Error != nil {
@@ -191,6 +255,121 @@ func SetIsHost(db *gorm.DB, userID int64, isHost bool) error {
 	return nil
 }
 
+func SetEmailVerified(db *gorm.DB, user *models.User) error {
+	user.EmailVerified = true
+	result := db.Model(user).Update("email_verified", true)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(users.SetEmailVerified)")
+	}
+
+	return nil
+}
+
+// Starts enrollment. The secret isn't used for logins until EnableTOTP, so restarting enrollment
+// just replaces it.
+func SetPendingTOTPSecret(db *gorm.DB, user *models.User, encryptedSecret string) error {
+	if user.HasTwoFactor() {
+		return errors.New("(users.SetPendingTOTPSecret) two-factor is already enabled")
+	}
+
+	user.TOTPSecret = &encryptedSecret
+	user.TOTPLastUsedStep = nil
+	result := db.Model(user).Updates(map[string]interface{}{
+		"totp_secret":         encryptedSecret,
+		"totp_last_used_step": nil,
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(users.SetPendingTOTPSecret)")
+	}
+
+	return nil
+}
+
+func EnableTOTP(db *gorm.DB, user *models.User) error {
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	result := db.Model(user).Update("totp_enabled_at", now)
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(users.EnableTOTP)")
+	}
+
+	return nil
+}
+
+func DisableTOTP(db *gorm.DB, user *models.User) error {
+	user.TOTPSecret = nil
+	user.TOTPEnabledAt = nil
+	user.TOTPLastUsedStep = nil
+	result := db.Model(user).Updates(map[string]interface{}{
+		"totp_secret":         nil,
+		"totp_enabled_at":     nil,
+		"totp_last_used_step": nil,
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(users.DisableTOTP)")
+	}
+
+	return nil
+}
+
+// Codes are only six digits, so each user gets a few guesses per window no matter how many
+// challenges or sessions they're spread over
+const MAX_TWO_FACTOR_ATTEMPTS = 10
+const TWO_FACTOR_ATTEMPT_WINDOW = time.Hour
+
+// Counts a code check against the user before it happens, in one statement so parallel requests
+// can't get past the limit. Returns false if the user is out of attempts for now.
+func ReserveTwoFactorAttempt(db *gorm.DB, user *models.User) (bool, error) {
+	now := time.Now()
+	windowStart := now.Add(-TWO_FACTOR_ATTEMPT_WINDOW)
+	result := db.Table("users").
+		Where("users.id = ?", user.ID).
+		Where("users.two_factor_attempts_since IS NULL OR users.two_factor_attempts_since < ? OR users.two_factor_attempts < ?", windowStart, MAX_TWO_FACTOR_ATTEMPTS).
+		Updates(map[string]interface{}{
+			"two_factor_attempts":       gorm.Expr("CASE WHEN two_factor_attempts_since IS NULL OR two_factor_attempts_since < ? THEN 1 ELSE two_factor_attempts + 1 END", windowStart),
+			"two_factor_attempts_since": gorm.Expr("CASE WHEN two_factor_attempts_since IS NULL OR two_factor_attempts_since < ? THEN ? ELSE two_factor_attempts_since END", windowStart, now),
+		})
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "(users.ReserveTwoFactorAttempt)")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// After a correct code, so the user starts with a full set of attempts next time
+func ClearTwoFactorAttempts(db *gorm.DB, user *models.User) error {
+	user.TwoFactorAttempts = 0
+	user.TwoFactorAttemptsSince = nil
+	result := db.Model(user).Updates(map[string]interface{}{
+		"two_factor_attempts":       0,
+		"two_factor_attempts_since": nil,
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(users.ClearTwoFactorAttempts)")
+	}
+
+	return nil
+}
+
+// Records the time step of a code that was just accepted. Returns false if that step or a later one
+// was already used, which means the code is being replayed.
+func UseTOTPStep(db *gorm.DB, user *models.User, step int64) (bool, error) {
+	result := db.Table("users").
+		Where("users.id = ?", user.ID).
+		Where("users.totp_last_used_step IS NULL OR users.totp_last_used_step < ?", step).
+		Update("totp_last_used_step", step)
+	if result.Error != nil {
+		return false, errors.Wrap(result.Error, "(users.UseTOTPStep)")
+	}
+
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	user.TOTPLastUsedStep = &step
+	return true, nil
+}
+
 func JoinWaitlist(db *gorm.DB, email string) error {
 	waitlist := models.Waitlist{
 		Email: email,
@@ -0,0 +1,212 @@
+package wishlists
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const SHARE_TOKEN_BITS = 128
+
+type WishlistDetails struct {
+	models.Wishlist
+	Listings []listings.ListingDetails
+}
+
+func CreateWishlist(db *gorm.DB, userID int64, name string) (*models.Wishlist, error) {
+	wishlist := models.Wishlist{
+		UserID: userID,
+		Name:   name,
+	}
+
+	result := db.Create(&wishlist)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(wishlists.CreateWishlist)")
+	}
+
+	return &wishlist, nil
+}
+
+func LoadAllForUser(db *gorm.DB, userID int64) ([]WishlistDetails, error) {
+	var wishlists []models.Wishlist
+	result := db.Table("wishlists").
+		Select("wishlists.*").
+		Where("wishlists.user_id = ?", userID).
+		Where("wishlists.deactivated_at IS NULL").
+		Order("wishlists.created_at ASC").
+		Find(&wishlists)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(wishlists.LoadAllForUser)")
+	}
+
+	wishlistDetails := make([]WishlistDetails, len(wishlists))
+	for i, wishlist := range wishlists {
+		details, err := LoadDetails(db, wishlist)
+		if err != nil {
+			return nil, errors.Wrap(err, "(wishlists.LoadAllForUser) loading details")
+		}
+
+		wishlistDetails[i] = *details
+	}
+
+	return wishlistDetails, nil
+}
+
+func LoadByIDAndUser(db *gorm.DB, wishlistID int64, userID int64) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	result := db.Table("wishlists").
+		Select("wishlists.*").
+		Where("wishlists.id = ?", wishlistID).
+		Where("wishlists.user_id = ?", userID).
+		Where("wishlists.deactivated_at IS NULL").
+		Take(&wishlist)
+	if result.Error != nil {
+		return nil, errors.Wrapf(result.Error, "(wishlists.LoadByIDAndUser) error for ID %d", wishlistID)
+	}
+
+	return &wishlist, nil
+}
+
+func LoadByShareToken(db *gorm.DB, shareToken string) (*models.Wishlist, error) {
+	var wishlist models.Wishlist
+	result := db.Table("wishlists").
+		Select("wishlists.*").
+		Where("wishlists.share_token = ?", shareToken).
+		Where("wishlists.deactivated_at IS NULL").
+		Take(&wishlist)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(wishlists.LoadByShareToken)")
+	}
+
+	return &wishlist, nil
+}
+
+func LoadDetails(db *gorm.DB, wishlist models.Wishlist) (*WishlistDetails, error) {
+	wishlistListings, err := listings.LoadListingsForWishlist(db, wishlist.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "(wishlists.LoadDetails) loading listings for wishlist %d", wishlist.ID)
+	}
+
+	return &WishlistDetails{
+		wishlist,
+		wishlistListings,
+	}, nil
+}
+
+func UpdateWishlist(db *gorm.DB, wishlist *models.Wishlist, wishlistUpdates input.WishlistUpdates) (*models.Wishlist, error) {
+	if wishlistUpdates.Name != nil {
+		wishlist.Name = *wishlistUpdates.Name
+	}
+
+	if wishlistUpdates.Shared != nil {
+		if *wishlistUpdates.Shared && wishlist.ShareToken == nil {
+			shareToken, err := generateShareToken()
+			if err != nil {
+				return nil, errors.Wrap(err, "(wishlists.UpdateWishlist) generating share token")
+			}
+
+			wishlist.ShareToken = shareToken
+		} else if !*wishlistUpdates.Shared {
+			// Revoking the token invalidates any links that were already sent out
+			wishlist.ShareToken = nil
+		}
+	}
+
+	result := db.Save(wishlist)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(wishlists.UpdateWishlist)")
+	}
+
+	return wishlist, nil
+}
+
+func DeleteWishlist(db *gorm.DB, wishlist *models.Wishlist) error {
+	currentTime := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(wishlist).Update("deactivated_at", currentTime)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "(wishlists.DeleteWishlist)")
+		}
+
+		result = tx.Table("wishlist_listings").
+			Where("wishlist_listings.wishlist_id = ?", wishlist.ID).
+			Where("wishlist_listings.deactivated_at IS NULL").
+			Update("deactivated_at", currentTime)
+		if result.Error != nil {
+			return errors.Wrap(result.Error, "(wishlists.DeleteWishlist) deleting wishlist listings")
+		}
+
+		return nil
+	})
+}
+
+// Saving a listing twice is a no-op, even when both saves happen at once
+func AddListing(db *gorm.DB, wishlistID int64, listingID int64) error {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.WishlistListing{
+		WishlistID: wishlistID,
+		ListingID:  listingID,
+	})
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(wishlists.AddListing)")
+	}
+
+	return nil
+}
+
+func RemoveListing(db *gorm.DB, wishlistID int64, listingID int64) error {
+	result := db.Table("wishlist_listings").
+		Where("wishlist_listings.wishlist_id = ?", wishlistID).
+		Where("wishlist_listings.listing_id = ?", listingID).
+		Where("wishlist_listings.deactivated_at IS NULL").
+		Update("deactivated_at", time.Now())
+	if result.Error != nil {
+		return errors.Wrap(result.Error, "(wishlists.RemoveListing)")
+	}
+
+	return nil
+}
+
+// Returns the subset of the given listing IDs that the user has saved to any of their wishlists
+func LoadSavedListingIDs(db *gorm.DB, userID int64, listingIDs []int64) (map[int64]bool, error) {
+	savedListingIDs := make(map[int64]bool)
+	if len(listingIDs) == 0 {
+		return savedListingIDs, nil
+	}
+
+	var saved []int64
+	result := db.Table("wishlist_listings").
+		Distinct("wishlist_listings.listing_id").
+		Joins("JOIN wishlists ON wishlists.id = wishlist_listings.wishlist_id").
+		Where("wishlists.user_id = ?", userID).
+		Where("wishlist_listings.listing_id IN ?", listingIDs).
+		Where("wishlists.deactivated_at IS NULL").
+		Where("wishlist_listings.deactivated_at IS NULL").
+		Pluck("wishlist_listings.listing_id", &saved)
+	if result.Error != nil {
+		return nil, errors.Wrap(result.Error, "(wishlists.LoadSavedListingIDs)")
+	}
+
+	for _, listingID := range saved {
+		savedListingIDs[listingID] = true
+	}
+
+	return savedListingIDs, nil
+}
+
+func generateShareToken() (*string, error) {
+	b := make([]byte, SHARE_TOKEN_BITS/8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "(wishlists.generateShareToken)")
+	}
+
+	token := fmt.Sprintf("%x", b)
+	return &token, nil
+}
@@ -116,7 +116,7 @@ func CreateCheckoutSession(user *models.User, listing *listings.ListingDetails,
 					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
 						Name: stripe.String(*listing.Name),
 						Images: []*string{
-							stripe.String(images.GetGcsImageUrl(listing.Images[0].StorageID)),
+							stripe.String(images.GetVariantUrl(listing.Images[0], models.ImageVariantCard, models.ImageFormatJPEG)),
 						},
 						Description: stripe.String("You won't be charged until this reservation is confirmed by the trip provider."),
 					},
@@ -0,0 +1,89 @@
+package totp
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/secret"
+)
+
+// Unlike passwords, TOTP secrets have to be readable to check codes, so they're encrypted at rest
+// with a key that isn't in the database. The key is 32 random bytes, base64 encoded.
+const TOTP_PROD_ENCRYPTION_KEY_KEY = "projects/454026596701/secrets/totp-encryption-key/versions/latest"
+const TOTP_DEV_ENCRYPTION_KEY_KEY = "projects/86315250181/secrets/totp-dev-encryption-key/versions/latest"
+
+func EncryptSecret(ctx context.Context, plaintext string) (string, error) {
+	gcm, err := getCipher(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "(totp.EncryptSecret)")
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", errors.Wrap(err, "(totp.EncryptSecret) generating nonce")
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func DecryptSecret(ctx context.Context, ciphertext string) (string, error) {
+	gcm, err := getCipher(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "(totp.DecryptSecret)")
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", errors.Wrap(err, "(totp.DecryptSecret) decoding")
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("(totp.DecryptSecret) ciphertext too short")
+	}
+
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.Wrap(err, "(totp.DecryptSecret) decrypting")
+	}
+
+	return string(plaintext), nil
+}
+
+func getCipher(ctx context.Context) (cipher.AEAD, error) {
+	encodedKey, err := secret.FetchSecret(ctx, getEncryptionKeyKey())
+	if err != nil {
+		return nil, errors.Wrap(err, "(totp.getCipher) fetching key")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(*encodedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "(totp.getCipher) decoding key")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "(totp.getCipher) creating cipher")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "(totp.getCipher) creating GCM")
+	}
+
+	return gcm, nil
+}
+
+func getEncryptionKeyKey() string {
+	if application.IsProd() {
+		return TOTP_PROD_ENCRYPTION_KEY_KEY
+	} else {
+		return TOTP_DEV_ENCRYPTION_KEY_KEY
+	}
+}
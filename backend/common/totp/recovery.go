@@ -0,0 +1,40 @@
+package totp
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"go.coaster.io/server/common/errors"
+)
+
+// Each code works once, for when the user loses their authenticator
+const RECOVERY_CODE_COUNT = 10
+
+// 10 base32 characters is 50 bits, plenty since codes are single use and stored hashed
+const recoveryCodeLength = 10
+
+// Returns codes formatted for display, like "abcde-fghij"
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RECOVERY_CODE_COUNT)
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength*5/8)
+		_, err := rand.Read(b)
+		if err != nil {
+			return nil, errors.Wrap(err, "(totp.GenerateRecoveryCodes)")
+		}
+
+		code := strings.ToLower(encoding.EncodeToString(b))
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+	}
+
+	return codes, nil
+}
+
+// Users type codes back in all sorts of ways, so case, spaces and dashes don't matter
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	h := sha256.Sum256([]byte(normalized))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
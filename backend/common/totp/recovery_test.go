@@ -0,0 +1,38 @@
+package totp_test
+
+import (
+	"go.coaster.io/server/common/totp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recovery codes", func() {
+	It("generates unique codes in the display format", func() {
+		codes, err := totp.GenerateRecoveryCodes()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(codes).To(HaveLen(totp.RECOVERY_CODE_COUNT))
+
+		seen := make(map[string]bool)
+		for _, code := range codes {
+			Expect(code).To(MatchRegexp(`^[a-z2-7]{5}-[a-z2-7]{5}$`))
+			Expect(seen[code]).To(BeFalse())
+			seen[code] = true
+		}
+	})
+
+	DescribeTable("HashRecoveryCode ignores case, spaces and dashes",
+		func(typed string) {
+			Expect(totp.HashRecoveryCode(typed)).To(Equal(totp.HashRecoveryCode("abcde-fghij")))
+		},
+		Entry("as displayed", "abcde-fghij"),
+		Entry("without the dash", "abcdefghij"),
+		Entry("upper case", "ABCDE-FGHIJ"),
+		Entry("space instead of the dash", "abcde fghij"),
+		Entry("extra spaces and dashes", " ab-cde -fg hij "),
+	)
+
+	It("hashes different codes differently", func() {
+		Expect(totp.HashRecoveryCode("abcde-fghij")).NotTo(Equal(totp.HashRecoveryCode("abcde-fghik")))
+	})
+})
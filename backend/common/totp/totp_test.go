@@ -0,0 +1,78 @@
+package totp_test
+
+import (
+	"strings"
+	"time"
+
+	"go.coaster.io/server/common/totp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The SHA-1 secret from RFC 6238 Appendix B, "12345678901234567890", in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+var _ = Describe("TOTP", func() {
+	// The RFC lists 8 digit codes, so these are their last 6 digits
+	DescribeTable("RFC 6238 test vectors",
+		func(unix int64, code string) {
+			step, valid := totp.Validate(rfcSecret, code, time.Unix(unix, 0))
+			Expect(valid).To(BeTrue())
+			Expect(step).To(Equal(unix / 30))
+		},
+		Entry("59", int64(59), "287082"),
+		Entry("1111111109", int64(1111111109), "081804"),
+		Entry("1111111111", int64(1111111111), "050471"),
+		Entry("1234567890", int64(1234567890), "005924"),
+		Entry("2000000000", int64(2000000000), "279037"),
+		Entry("20000000000", int64(20000000000), "353130"),
+	)
+
+	DescribeTable("clock skew",
+		func(offset time.Duration, valid bool) {
+			generatedAt := time.Unix(1111111109, 0)
+			step, ok := totp.Validate(rfcSecret, "081804", generatedAt.Add(offset))
+			Expect(ok).To(Equal(valid))
+			if valid {
+				Expect(step).To(Equal(generatedAt.Unix() / 30))
+			}
+		},
+		Entry("same step", time.Duration(0), true),
+		Entry("one step later", totp.PERIOD, true),
+		Entry("one step earlier", -totp.PERIOD, true),
+		Entry("two steps later", 2*totp.PERIOD, false),
+		Entry("two steps earlier", -2*totp.PERIOD, false),
+	)
+
+	It("ignores spaces in the code and case in the secret", func() {
+		_, valid := totp.Validate(strings.ToLower(rfcSecret), "081 804", time.Unix(1111111109, 0))
+		Expect(valid).To(BeTrue())
+	})
+
+	DescribeTable("rejects malformed input",
+		func(secret string, code string) {
+			_, valid := totp.Validate(secret, code, time.Unix(1111111109, 0))
+			Expect(valid).To(BeFalse())
+		},
+		Entry("wrong code", rfcSecret, "081805"),
+		Entry("full 8 digit code", rfcSecret, "07081804"),
+		Entry("empty code", rfcSecret, ""),
+		Entry("invalid secret", "not base32!", "081804"),
+	)
+
+	It("generates unpadded base32 secrets", func() {
+		secret, err := totp.GenerateSecret()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(secret).To(MatchRegexp(`^[A-Z2-7]{32}$`))
+	})
+
+	It("builds an otpauth URI with the settings authenticator apps need", func() {
+		uri := totp.GetURI(rfcSecret, "test@trycoaster.com")
+		Expect(uri).To(HavePrefix("otpauth://totp/Coaster:test@trycoaster.com?"))
+		Expect(uri).To(ContainSubstring("secret=" + rfcSecret))
+		Expect(uri).To(ContainSubstring("digits=6"))
+		Expect(uri).To(ContainSubstring("period=30"))
+		Expect(uri).To(ContainSubstring("algorithm=SHA1"))
+	})
+})
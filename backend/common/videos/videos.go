@@ -0,0 +1,206 @@
+package videos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+)
+
+// Clips are meant to give a feel for the activity, not replace the photos
+const MAX_VIDEO_SIZE_MB = 100
+const MAX_DURATION_SECONDS = 60
+const MAX_DIMENSION = 3840
+
+var SUPPORTED_VIDEO_TYPES = map[string]bool{"video/mp4": true, "video/quicktime": true, "video/webm": true}
+
+// Seconds into the video to grab the poster frame from, since the first frame is often black
+const POSTER_OFFSET_SECONDS = 1.0
+
+// Hard limits on each ffmpeg run, so a file crafted to make decoding slow can't hold up a request for good.
+// Probing only reads the headers, extracting the poster decodes up to a second of video and stripping
+// metadata copies the streams without re-encoding.
+const PROBE_TIMEOUT = 15 * time.Second
+const EXTRACT_POSTER_TIMEOUT = 30 * time.Second
+const STRIP_METADATA_TIMEOUT = 30 * time.Second
+
+// The container ffmpeg writes for each supported type, so the stripped copy keeps its content type
+var outputFormats = map[string]string{"video/mp4": "mp4", "video/quicktime": "mov", "video/webm": "webm"}
+
+type ProbeResult struct {
+	DurationMs int64
+	Width      int
+	Height     int
+	Projection models.Projection
+}
+
+// Probing and poster extraction use ffmpeg, which is installed in the production image. Without it
+// (e.g. local development) video uploads are rejected.
+func IsSupported() bool {
+	_, ffprobeErr := exec.LookPath("ffprobe")
+	_, ffmpegErr := exec.LookPath("ffmpeg")
+	return ffprobeErr == nil && ffmpegErr == nil
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType    string            `json:"codec_type"`
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		Tags         map[string]string `json:"tags"`
+		SideDataList []struct {
+			SideDataType string  `json:"side_data_type"`
+			Projection   string  `json:"projection"`
+			Rotation     float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+// Reads the duration and display resolution of the video at path, and whether it's a 360° video
+func Probe(ctx context.Context, path string) (*ProbeResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, PROBE_TIMEOUT)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-print_format", "json", "-show_format", "-show_streams", path).Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(ctx.Err(), "(videos.Probe) running ffprobe")
+		}
+
+		// ffprobe fails on anything it can't parse, which almost always means a bad upload
+		return nil, errors.NewBadRequest("The video could not be read")
+	}
+
+	var output ffprobeOutput
+	err = json.Unmarshal(out, &output)
+	if err != nil {
+		return nil, errors.Wrap(err, "(videos.Probe) parsing ffprobe output")
+	}
+
+	duration, err := strconv.ParseFloat(output.Format.Duration, 64)
+	if err != nil {
+		return nil, errors.NewBadRequest("The video has no duration")
+	}
+
+	for _, stream := range output.Streams {
+		if stream.CodecType != "video" {
+			continue
+		}
+
+		result := ProbeResult{
+			DurationMs: int64(math.Round(duration * 1000)),
+			Width:      stream.Width,
+			Height:     stream.Height,
+			Projection: models.ProjectionFlat,
+		}
+
+		// Phones record portrait video as landscape with a rotation, so swap to what viewers will see
+		rotation := 0.0
+		if rotate, ok := stream.Tags["rotate"]; ok {
+			rotation, _ = strconv.ParseFloat(rotate, 64)
+		}
+
+		for _, sideData := range stream.SideDataList {
+			switch sideData.SideDataType {
+			case "Display Matrix":
+				rotation = sideData.Rotation
+			case "Spherical Mapping":
+				if strings.EqualFold(sideData.Projection, "equirectangular") {
+					result.Projection = models.ProjectionEquirectangular
+				}
+			}
+		}
+
+		if math.Mod(math.Abs(rotation), 180) == 90 {
+			result.Width, result.Height = result.Height, result.Width
+		}
+
+		return &result, nil
+	}
+
+	return nil, errors.NewBadRequest("The file doesn't contain a video")
+}
+
+// Grabs a frame to show before the video plays. ffmpeg applies any rotation, so the frame is upright.
+func ExtractPoster(ctx context.Context, path string, durationMs int64) (image.Image, error) {
+	ctx, cancel := context.WithTimeout(ctx, EXTRACT_POSTER_TIMEOUT)
+	defer cancel()
+
+	offset := math.Min(POSTER_OFFSET_SECONDS, float64(durationMs)/2000)
+
+	cmd := exec.CommandContext(
+		ctx,
+		"ffmpeg", "-v", "error",
+		"-ss", strconv.FormatFloat(offset, 'f', 3, 64),
+		"-i", path,
+		"-frames:v", "1",
+		"-f", "image2", "-c:v", "png",
+		"pipe:1",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(ctx.Err(), "(videos.ExtractPoster) running ffmpeg")
+		}
+
+		return nil, errors.Wrapf(err, "(videos.ExtractPoster) running ffmpeg: %s", stderr.String())
+	}
+
+	poster, err := png.Decode(bytes.NewReader(out))
+	if err != nil {
+		return nil, errors.Wrap(err, "(videos.ExtractPoster) decoding frame")
+	}
+
+	return poster, nil
+}
+
+// Writes a copy of the video at path to outPath without the container and stream metadata, which can
+// include the GPS location and device it was recorded on. Only the video and audio streams are kept,
+// since some cameras record location in separate data streams. Rotation and 360° mapping are stored as
+// side data, so they survive the copy.
+func StripMetadata(ctx context.Context, path string, outPath string, contentType string) error {
+	ctx, cancel := context.WithTimeout(ctx, STRIP_METADATA_TIMEOUT)
+	defer cancel()
+
+	format, ok := outputFormats[contentType]
+	if !ok {
+		return errors.Newf("(videos.StripMetadata) unsupported content type %s", contentType)
+	}
+
+	cmd := exec.CommandContext(
+		ctx,
+		"ffmpeg", "-v", "error", "-y",
+		"-i", path,
+		"-map", "0:v", "-map", "0:a?",
+		"-map_metadata", "-1", "-map_chapters", "-1",
+		"-c", "copy",
+		"-f", format,
+		outPath,
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		if ctx.Err() != nil {
+			return errors.Wrap(ctx.Err(), "(videos.StripMetadata) running ffmpeg")
+		}
+
+		return errors.Wrapf(err, "(videos.StripMetadata) running ffmpeg: %s", stderr.String())
+	}
+
+	return nil
+}
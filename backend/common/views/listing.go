@@ -1,8 +1,11 @@
 package views
 
 import (
+	"fmt"
 	"slices"
+	"strings"
 
+	"go.coaster.io/server/common/geo"
 	image_lib "go.coaster.io/server/common/images"
 	"go.coaster.io/server/common/models"
 	"go.coaster.io/server/common/repositories/listings"
@@ -15,6 +18,7 @@ type Listing struct {
 	Price               *int64                     `json:"price,omitempty"`
 	Location            *string                    `json:"location,omitempty"`
 	Coordinates         *Coordinates               `json:"coordinates,omitempty"`
+	ServiceArea         *geo.Polygon               `json:"service_area,omitempty"`
 	PlaceID             *string                    `json:"place_id,omitempty"`
 	City                *string                    `json:"city,omitempty"`
 	Region              *string                    `json:"region,omitempty"`
@@ -30,21 +34,64 @@ type Listing struct {
 	Status              models.ListingStatus       `json:"status"`
 	AvailabilityType    models.AvailabilityType    `json:"availability_type"`
 	AvailabilityDisplay models.AvailabilityDisplay `json:"availability_display"`
+	SourceLocale        string                     `json:"source_locale"`
+
+	// The locale the text fields are in, which differs from the source locale when a translation was served
+	Locale string `json:"locale"`
 
 	Host Host `json:"host"`
 
+	// Photos only, for clients that predate video. Media has the full gallery.
 	Images []Image `json:"images"`
 
+	// Photos and videos in gallery order
+	Media []Media `json:"media"`
+
 	Categories []models.ListingCategoryType `json:"categories"`
 
 	ItinerarySteps []models.ItineraryStep `json:"itinerary_steps"`
+
+	MeetingPoints []models.MeetingPoint `json:"meeting_points"`
+
+	// Only set for authenticated users, whether they have saved this listing to any wishlist
+	Saved bool `json:"saved"`
+
+	// Only set for text searches, an HTML excerpt with matching terms wrapped in <mark> tags
+	Snippet *string `json:"snippet,omitempty"`
+
+	// Only set for location searches, the distance in meters from the searched location
+	DistanceMeters *float64 `json:"distance_meters,omitempty"`
 }
 
 type Image struct {
-	ID     int64  `json:"id"`
-	URL    string `json:"url"`
-	Width  int    `json:"width"`
-	Height int    `json:"height"`
+	ID            int64                         `json:"id"`
+	URL           string                        `json:"url"`
+	Width         int                           `json:"width"`
+	Height        int                           `json:"height"`
+	Variants      []ImageVariant                `json:"variants"`
+	SrcSet        map[models.ImageFormat]string `json:"srcset"` // Ready to use in <source srcset>, keyed by format
+	Blurhash      *string                       `json:"blurhash"`
+	DominantColor *string                       `json:"dominant_color"`
+}
+
+type Media struct {
+	ID         int64             `json:"id"`
+	Type       models.MediaType  `json:"type"`
+	Projection models.Projection `json:"projection"`
+	URL        string            `json:"url"` // The photo itself, or the video file
+	// Only set for videos
+	ContentType *string `json:"content_type,omitempty"`
+	DurationMs  *int64  `json:"duration_ms,omitempty"`
+	// The photo, or the poster frame for videos, with its resized variants
+	Image Image `json:"image"`
+}
+
+type ImageVariant struct {
+	Name   models.ImageVariantName `json:"name"`
+	Format models.ImageFormat      `json:"format"`
+	URL    string                  `json:"url"`
+	Width  int                     `json:"width"`
+	Height int                     `json:"height"`
 }
 
 type Category struct {
@@ -53,14 +100,16 @@ type Category struct {
 }
 
 type Host struct {
-	ID                   int64   `json:"id"`
-	FirstName            string  `json:"first_name"`
-	LastName             string  `json:"last_name"`
-	Email                string  `json:"email"`
-	ProfilePictureURL    *string `json:"profile_picture_url"`
-	ProfilePictureWidth  *int    `json:"profile_picture_width"`
-	ProfilePictureHeight *int    `json:"profile_picture_height"`
-	About                *string `json:"about"`
+	ID                          int64   `json:"id"`
+	FirstName                   string  `json:"first_name"`
+	LastName                    string  `json:"last_name"`
+	Email                       string  `json:"email"`
+	ProfilePictureURL           *string `json:"profile_picture_url"`
+	ProfilePictureWidth         *int    `json:"profile_picture_width"`
+	ProfilePictureHeight        *int    `json:"profile_picture_height"`
+	ProfilePictureBlurhash      *string `json:"profile_picture_blurhash"`
+	ProfilePictureDominantColor *string `json:"profile_picture_dominant_color"`
+	About                       *string `json:"about"`
 }
 
 type Coordinates struct {
@@ -82,6 +131,11 @@ func ConvertListing(listing listings.ListingDetails) Listing {
 	if listing.Coordinates != nil {
 		coordinates = &Coordinates{Latitude: listing.Coordinates.Latitude, Longitude: listing.Coordinates.Longitude}
 	}
+
+	locale := listing.Locale
+	if len(locale) == 0 {
+		locale = listing.SourceLocale
+	}
 	return Listing{
 		ID:                  listing.ID,
 		Name:                listing.Name,
@@ -89,6 +143,7 @@ func ConvertListing(listing listings.ListingDetails) Listing {
 		Price:               listing.Price,
 		Location:            listing.Location,
 		Coordinates:         coordinates,
+		ServiceArea:         listing.ServiceArea,
 		PlaceID:             listing.PlaceID,
 		City:                listing.City,
 		Region:              listing.Region,
@@ -104,45 +159,124 @@ func ConvertListing(listing listings.ListingDetails) Listing {
 		Status:              listing.Status,
 		AvailabilityType:    listing.AvailabilityType,
 		AvailabilityDisplay: listing.AvailabilityDisplay,
+		SourceLocale:        listing.SourceLocale,
+
+		Locale: locale,
 
 		Host: ConvertHost(listing.Host),
 
-		Images: ConvertImages(listing.Images),
+		Images: ConvertImages(filterPhotos(listing.Images)),
+
+		Media: ConvertMedia(listing.Images),
 
 		Categories: ConvertCategories(listing.Categories),
 
 		ItinerarySteps: listing.ItinerarySteps,
+
+		MeetingPoints: listing.MeetingPoints,
 	}
 }
 
 func ConvertHost(user *models.User) Host {
 	// TODO: prevent email when email is not verified
 	return Host{
-		ID:                   user.ID,
-		FirstName:            user.FirstName,
-		LastName:             user.LastName,
-		Email:                user.Email,
-		ProfilePictureURL:    user.ProfilePictureURL,
-		ProfilePictureWidth:  user.ProfilePictureWidth,
-		ProfilePictureHeight: user.ProfilePictureHeight,
-		About:                user.About,
+		ID:                          user.ID,
+		FirstName:                   user.FirstName,
+		LastName:                    user.LastName,
+		Email:                       user.Email,
+		ProfilePictureURL:           user.ProfilePictureURL,
+		ProfilePictureWidth:         user.ProfilePictureWidth,
+		ProfilePictureHeight:        user.ProfilePictureHeight,
+		ProfilePictureBlurhash:      user.ProfilePictureBlurhash,
+		ProfilePictureDominantColor: user.ProfilePictureDominantColor,
+		About:                       user.About,
 	}
 }
 
 func ConvertImages(images []models.ListingImage) []Image {
 	converted := make([]Image, len(images))
 	for i, image := range images {
-		converted[i] = Image{
-			ID:     image.ID,
-			URL:    image_lib.GetGcsImageUrl(image.StorageID),
-			Width:  image.Width,
-			Height: image.Height,
-		}
+		converted[i] = ConvertImage(image)
+	}
+
+	return converted
+}
+
+func ConvertMedia(media []models.ListingImage) []Media {
+	converted := make([]Media, len(media))
+	for i, item := range media {
+		converted[i] = ConvertMediaItem(item)
 	}
 
 	return converted
 }
 
+func ConvertMediaItem(item models.ListingImage) Media {
+	return Media{
+		ID:          item.ID,
+		Type:        item.MediaType,
+		Projection:  item.Projection,
+		URL:         image_lib.GetImageUrl(item.StorageID),
+		ContentType: item.ContentType,
+		DurationMs:  item.DurationMs,
+		Image:       ConvertImage(item),
+	}
+}
+
+func filterPhotos(media []models.ListingImage) []models.ListingImage {
+	photos := []models.ListingImage{}
+	for _, item := range media {
+		if item.MediaType != models.MediaTypeVideo {
+			photos = append(photos, item)
+		}
+	}
+
+	return photos
+}
+
+// For videos this is the poster frame
+func ConvertImage(image models.ListingImage) Image {
+	variants := make([]ImageVariant, len(image.Variants))
+	srcSets := make(map[models.ImageFormat][]string)
+	srcSetWidths := make(map[models.ImageFormat]map[int]bool)
+	for i, variant := range image.Variants {
+		variants[i] = ImageVariant{
+			Name:   variant.Name,
+			Format: variant.Format,
+			URL:    image_lib.GetImageUrl(variant.StorageID),
+			Width:  variant.Width,
+			Height: variant.Height,
+		}
+
+		// Small uploads aren't upscaled, so several variants can share a width and only one can be listed
+		if srcSetWidths[variant.Format] == nil {
+			srcSetWidths[variant.Format] = make(map[int]bool)
+		}
+		if srcSetWidths[variant.Format][variant.Width] {
+			continue
+		}
+
+		srcSetWidths[variant.Format][variant.Width] = true
+		srcSets[variant.Format] = append(srcSets[variant.Format], fmt.Sprintf("%s %dw", variants[i].URL, variant.Width))
+	}
+
+	srcSet := make(map[models.ImageFormat]string)
+	for format, candidates := range srcSets {
+		srcSet[format] = strings.Join(candidates, ", ")
+	}
+
+	return Image{
+		ID:            image.ID,
+		URL:           image_lib.GetImageUrl(image_lib.GetStillStorageID(image)),
+		Width:         image.Width,
+		Height:        image.Height,
+		Variants:      variants,
+		SrcSet:        srcSet,
+		Blurhash:      image.Blurhash,
+		DominantColor: image.DominantColor,
+	}
+}
+
 func ConvertCategories(categories []models.ListingCategory) []models.ListingCategoryType {
 	converted := []models.ListingCategoryType{}
 	for _, category := range categories {
@@ -0,0 +1,78 @@
+package views
+
+import (
+	"time"
+
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+type ListingRevision struct {
+	ID          int64                        `json:"id"`
+	ListingID   int64                        `json:"listing_id"`
+	AuthorID    int64                        `json:"author_id"`
+	ReviewerID  *int64                       `json:"reviewer_id,omitempty"`
+	Status      models.ListingRevisionStatus `json:"status"`
+	Content     models.ListingContent        `json:"content"`
+	Reasons     []string                     `json:"reasons"`
+	Feedback    *string                      `json:"feedback,omitempty"`
+	SubmittedAt *time.Time                   `json:"submitted_at,omitempty"`
+	PublishedAt *time.Time                   `json:"published_at,omitempty"`
+	CreatedAt   time.Time                    `json:"created_at"`
+
+	// Only set when comparing against the live listing
+	Changes []listings.ContentChange `json:"changes,omitempty"`
+}
+
+type RevisionUnderReview struct {
+	Revision ListingRevision `json:"revision"`
+	Live     Listing         `json:"live"`
+}
+
+func ConvertListingRevisions(revisions []models.ListingRevision) []ListingRevision {
+	converted := make([]ListingRevision, len(revisions))
+	for i, revision := range revisions {
+		converted[i] = ConvertListingRevision(revision, nil)
+	}
+
+	return converted
+}
+
+func ConvertListingRevision(revision models.ListingRevision, changes []listings.ContentChange) ListingRevision {
+	var submittedAt *time.Time
+	if revision.SubmittedAt.Valid {
+		submittedAt = &revision.SubmittedAt.Time
+	}
+
+	var publishedAt *time.Time
+	if revision.PublishedAt.Valid {
+		publishedAt = &revision.PublishedAt.Time
+	}
+
+	return ListingRevision{
+		ID:          revision.ID,
+		ListingID:   revision.ListingID,
+		AuthorID:    revision.AuthorID,
+		ReviewerID:  revision.ReviewerID,
+		Status:      revision.Status,
+		Content:     revision.Content,
+		Reasons:     revision.Reasons,
+		Feedback:    revision.Feedback,
+		SubmittedAt: submittedAt,
+		PublishedAt: publishedAt,
+		CreatedAt:   revision.CreatedAt,
+		Changes:     changes,
+	}
+}
+
+func ConvertRevisionsUnderReview(revisionsUnderReview []listings.RevisionUnderReview) []RevisionUnderReview {
+	converted := make([]RevisionUnderReview, len(revisionsUnderReview))
+	for i, revisionUnderReview := range revisionsUnderReview {
+		converted[i] = RevisionUnderReview{
+			Revision: ConvertListingRevision(revisionUnderReview.Revision, revisionUnderReview.Changes),
+			Live:     ConvertListing(revisionUnderReview.Live),
+		}
+	}
+
+	return converted
+}
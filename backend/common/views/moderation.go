@@ -0,0 +1,24 @@
+package views
+
+import (
+	"time"
+
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+type ListingUnderReview struct {
+	Listing     Listing   `json:"listing"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+func ConvertListingsUnderReview(listingsUnderReview []listings.ListingUnderReview) []ListingUnderReview {
+	converted := make([]ListingUnderReview, len(listingsUnderReview))
+	for i, listingUnderReview := range listingsUnderReview {
+		converted[i] = ListingUnderReview{
+			Listing:     ConvertListing(listingUnderReview.ListingDetails),
+			SubmittedAt: listingUnderReview.SubmittedAt,
+		}
+	}
+
+	return converted
+}
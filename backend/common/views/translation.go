@@ -0,0 +1,59 @@
+package views
+
+import (
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+type ListingTranslation struct {
+	Locale           string                     `json:"locale"`
+	Name             *string                    `json:"name"`
+	Description      *string                    `json:"description"`
+	ShortDescription *string                    `json:"short_description"`
+	Highlights       []string                   `json:"highlights"`
+	ItinerarySteps   []ItineraryStepTranslation `json:"itinerary_steps"`
+}
+
+type ItineraryStepTranslation struct {
+	ID          int64   `json:"id"` // The ID of the translated itinerary step
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	StepLabel   *string `json:"step_label"`
+}
+
+func ConvertTranslations(translations []listings.TranslationDetails) []ListingTranslation {
+	converted := make([]ListingTranslation, len(translations))
+	for i, translation := range translations {
+		converted[i] = ConvertTranslation(translation)
+	}
+
+	return converted
+}
+
+func ConvertTranslation(translation listings.TranslationDetails) ListingTranslation {
+	highlights := []string{}
+	highlights = append(highlights, translation.Highlights...)
+
+	return ListingTranslation{
+		Locale:           translation.Locale,
+		Name:             translation.Name,
+		Description:      translation.Description,
+		ShortDescription: translation.ShortDescription,
+		Highlights:       highlights,
+		ItinerarySteps:   convertItineraryStepTranslations(translation.ItinerarySteps),
+	}
+}
+
+func convertItineraryStepTranslations(stepTranslations []models.ItineraryStepTranslation) []ItineraryStepTranslation {
+	converted := make([]ItineraryStepTranslation, len(stepTranslations))
+	for i, stepTranslation := range stepTranslations {
+		converted[i] = ItineraryStepTranslation{
+			ID:          stepTranslation.ItineraryStepID,
+			Title:       stepTranslation.Title,
+			Description: stepTranslation.Description,
+			StepLabel:   stepTranslation.StepLabel,
+		}
+	}
+
+	return converted
+}
@@ -3,31 +3,39 @@ package views
 import "go.coaster.io/server/common/models"
 
 type User struct {
-	ID                   int64                      `json:"id"`
-	FirstName            string                     `json:"first_name"`
-	LastName             string                     `json:"last_name"`
-	Email                string                     `json:"email"`
-	Phone                *string                    `json:"phone"`
-	About                *string                    `json:"about"`
-	ProfilePictureURL    *string                    `json:"profile_picture_url"`
-	ProfilePictureWidth  *int                       `json:"profile_picture_width"`
-	ProfilePictureHeight *int                       `json:"profile_picture_height"`
-	IsHost               bool                       `json:"is_host"`
-	StripeAccountStatus  models.StripeAccountStatus `json:"stripe_account_status"`
+	ID                          int64                      `json:"id"`
+	FirstName                   string                     `json:"first_name"`
+	LastName                    string                     `json:"last_name"`
+	Email                       string                     `json:"email"`
+	Phone                       *string                    `json:"phone"`
+	About                       *string                    `json:"about"`
+	ProfilePictureURL           *string                    `json:"profile_picture_url"`
+	ProfilePictureWidth         *int                       `json:"profile_picture_width"`
+	ProfilePictureHeight        *int                       `json:"profile_picture_height"`
+	ProfilePictureBlurhash      *string                    `json:"profile_picture_blurhash"`
+	ProfilePictureDominantColor *string                    `json:"profile_picture_dominant_color"`
+	IsHost                      bool                       `json:"is_host"`
+	EmailVerified               bool                       `json:"email_verified"`
+	TwoFactorEnabled            bool                       `json:"two_factor_enabled"`
+	StripeAccountStatus         models.StripeAccountStatus `json:"stripe_account_status"`
 }
 
 func ConvertUser(user models.User) User {
 	return User{
-		ID:                   user.ID,
-		FirstName:            user.FirstName,
-		LastName:             user.LastName,
-		Email:                user.Email,
-		Phone:                user.Phone,
-		About:                user.About,
-		ProfilePictureURL:    user.ProfilePictureURL,
-		ProfilePictureWidth:  user.ProfilePictureWidth,
-		ProfilePictureHeight: user.ProfilePictureHeight,
-		IsHost:               user.IsHost,
-		StripeAccountStatus:  user.StripeAccountStatus,
+		ID:                          user.ID,
+		FirstName:                   user.FirstName,
+		LastName:                    user.LastName,
+		Email:                       user.Email,
+		Phone:                       user.Phone,
+		About:                       user.About,
+		ProfilePictureURL:           user.ProfilePictureURL,
+		ProfilePictureWidth:         user.ProfilePictureWidth,
+		ProfilePictureHeight:        user.ProfilePictureHeight,
+		ProfilePictureBlurhash:      user.ProfilePictureBlurhash,
+		ProfilePictureDominantColor: user.ProfilePictureDominantColor,
+		IsHost:                      user.IsHost,
+		EmailVerified:               user.EmailVerified,
+		TwoFactorEnabled:            user.HasTwoFactor(),
+		StripeAccountStatus:         user.StripeAccountStatus,
 	}
 }
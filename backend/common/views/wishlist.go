@@ -0,0 +1,36 @@
+package views
+
+import "go.coaster.io/server/common/repositories/wishlists"
+
+type Wishlist struct {
+	ID         int64   `json:"id"`
+	Name       string  `json:"name"`
+	ShareToken *string `json:"share_token,omitempty"`
+
+	Listings []Listing `json:"listings"`
+}
+
+func ConvertWishlist(wishlist wishlists.WishlistDetails) Wishlist {
+	listings := ConvertListings(wishlist.Listings)
+
+	// Everything in a wishlist is saved by definition
+	for i := range listings {
+		listings[i].Saved = true
+	}
+
+	return Wishlist{
+		ID:         wishlist.ID,
+		Name:       wishlist.Name,
+		ShareToken: wishlist.ShareToken,
+		Listings:   listings,
+	}
+}
+
+func ConvertWishlists(wishlists []wishlists.WishlistDetails) []Wishlist {
+	converted := make([]Wishlist, len(wishlists))
+	for i, wishlist := range wishlists {
+		converted[i] = ConvertWishlist(wishlist)
+	}
+
+	return converted
+}
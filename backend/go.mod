@@ -6,6 +6,7 @@ require (
 	cloud.google.com/go/kms v1.15.5
 	cloud.google.com/go/secretmanager v1.11.4
 	cloud.google.com/go/storage v1.37.0
+	github.com/disintegration/imaging v1.6.2
 	github.com/go-playground/validator v9.31.0+incompatible
 	github.com/golang-jwt/jwt/v5 v5.2.0
 	github.com/golang-migrate/migrate v3.5.4+incompatible
@@ -23,6 +24,8 @@ require (
 	golang.org/x/crypto v0.18.0
 	golang.org/x/image v0.15.0
 	golang.org/x/oauth2 v0.16.0
+	golang.org/x/text v0.14.0
+	golang.org/x/time v0.5.0
 	google.golang.org/api v0.157.0
 	googlemaps.github.io/maps v1.7.0
 	gorm.io/driver/postgres v1.5.4
@@ -102,8 +105,6 @@ require (
 	golang.org/x/net v0.20.0 // indirect
 	golang.org/x/sync v0.6.0 // indirect
 	golang.org/x/sys v0.16.0 // indirect
-	golang.org/x/text v0.14.0 // indirect
-	golang.org/x/time v0.5.0 // indirect
 	golang.org/x/tools v0.17.0 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
 	google.golang.org/genproto v0.0.0-20240123012728-ef4313101c80 // indirect
@@ -1,26 +1,19 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"image"
-	"io"
+	"log"
 	"net/http"
 	"strconv"
 
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
-
-	_ "golang.org/x/image/webp"
-
-	"cloud.google.com/go/storage"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
-	"go.coaster.io/server/common/application"
 	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/images"
+	"go.coaster.io/server/common/models"
 	"go.coaster.io/server/common/repositories/listings"
 	"go.coaster.io/server/common/views"
 )
@@ -45,17 +38,6 @@ func (s ApiService) AddListingImage(auth auth.Authentication, w http.ResponseWri
 		return errors.Wrapf(err, "(api.AddListingImage) loading listing %d for user %d", listingID, auth.User.ID)
 	}
 
-	existingImages, err := listings.LoadImagesForListing(s.db, listingID)
-	if err != nil {
-		return errors.Wrapf(err, "(api.AddListingImage) loading images for listing %d", listingID)
-	}
-	maxRank := 0
-	for _, image := range existingImages {
-		if image.Rank > maxRank {
-			maxRank = image.Rank
-		}
-	}
-
 	file, handler, err := r.FormFile("listing_image")
 	if err != nil {
 		return errors.Wrap(err, "(api.AddListingImage) opening file")
@@ -71,58 +53,103 @@ func (s ApiService) AddListingImage(auth auth.Authentication, w http.ResponseWri
 		return errors.NewBadRequestf("Unsupported image type: %s", contentType)
 	}
 
-	imageCfg, _, err := image.DecodeConfig(file)
+	// 360° photos look like any other equirectangular JPEG, so the client says which ones they are
+	projection := models.ProjectionFlat
+	if r.FormValue("projection") != "" {
+		projection = models.Projection(r.FormValue("projection"))
+		if projection != models.ProjectionFlat && projection != models.ProjectionEquirectangular {
+			return errors.NewBadRequestf("Invalid projection: %s", projection)
+		}
+	}
+
+	// Uploads are re-encoded so they're upright and don't leak metadata like the camera's GPS location
+	processed, err := images.Process(r.Context(), file)
 	if err != nil {
-		return errors.Wrap(err, "(api.AddListingImage) decoding image config")
+		return errors.Wrap(err, "(api.AddListingImage) processing image")
 	}
 
-	file.Seek(0, io.SeekStart)
+	// Anything uploaded is deleted again if a later step fails. If the cleanup fails too, the blob
+	// reconciliation job picks up the orphans.
+	storageID := uuid.New().String()
+	variants, uploaded, err := s.uploadProcessedImage(r.Context(), storageID, processed)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingImage) uploading image")
+	}
 
-	client, err := storage.NewClient(context.TODO())
+	// New images go at the end. This is computed after the uploads to keep the window for concurrent
+	// uploads small, and any that do race just end up ordered by ID.
+	rank, err := listings.GetNextImageRank(s.db, listingID)
 	if err != nil {
-		return errors.Wrap(err, "(api.AddListingImage) opening storage client")
+		s.deleteBlobs(uploaded)
+		return errors.Wrapf(err, "(api.AddListingImage) getting rank for listing %d", listingID)
 	}
-	defer client.Close()
 
-	userImagesBucket := getUserImageBucket()
-	storageID := uuid.New().String()
+	listingImage, err := listings.CreateListingImage(s.db, models.ListingImage{
+		ListingID:     listingID,
+		MediaType:     models.MediaTypeImage,
+		Projection:    projection,
+		StorageID:     storageID,
+		Rank:          rank,
+		Width:         processed.Original.Width,
+		Height:        processed.Original.Height,
+		Variants:      variants,
+		Blurhash:      &processed.Placeholder.Blurhash,
+		DominantColor: &processed.Placeholder.DominantColor,
+	})
+	if err != nil {
+		s.deleteBlobs(uploaded)
+		return errors.Wrap(err, "(api.AddListingImage) saving listing image details to DB")
+	}
 
-	o := client.Bucket(userImagesBucket).Object(storageID)
-	o = o.If(storage.Conditions{DoesNotExist: true}) // TODO: figure out the right conditions here
-	wc := o.NewWriter(context.TODO())
-	wc.ContentType = contentType
-	if _, err = io.Copy(wc, file); err != nil {
-		return errors.Wrap(err, "(api.AddListingImage) copying file to storage")
+	return json.NewEncoder(w).Encode(views.ConvertImage(*listingImage))
+}
+
+// Uploads the original and its variants. On failure, anything already uploaded is deleted again.
+func (s ApiService) uploadProcessedImage(ctx context.Context, storageID string, processed *images.ProcessedImage) (models.ImageVariants, []string, error) {
+	err := s.uploadImage(ctx, storageID, processed.Original)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "(api.uploadProcessedImage) uploading original")
 	}
-	if err := wc.Close(); err != nil {
-		return errors.Wrap(err, "(api.AddListingImage) closing file")
+	uploaded := []string{storageID}
+
+	variants := models.ImageVariants{}
+	for _, variant := range processed.Variants {
+		variantStorageID := images.GetVariantStorageID(storageID, variant.Name, variant.Format)
+		err = s.uploadImage(ctx, variantStorageID, variant.EncodedImage)
+		if err != nil {
+			s.deleteBlobs(uploaded)
+			return nil, nil, errors.Wrapf(err, "(api.uploadProcessedImage) uploading %s %s variant", variant.Name, variant.Format)
+		}
+		uploaded = append(uploaded, variantStorageID)
+
+		variants = append(variants, models.ImageVariant{
+			Name:      variant.Name,
+			Format:    variant.Format,
+			Width:     variant.Width,
+			Height:    variant.Height,
+			StorageID: variantStorageID,
+		})
 	}
 
-	// TODO: do this transactionally or figure out something to handle failures
-	listingImage, err := listings.CreateListingImage(
-		s.db,
-		listingID,
-		storageID,
-		maxRank+1,
-		imageCfg.Width,
-		imageCfg.Height,
-	)
+	return variants, uploaded, nil
+}
+
+func (s ApiService) uploadImage(ctx context.Context, storageID string, encoded images.EncodedImage) error {
+	err := s.blobStore.Upload(ctx, storageID, images.CONTENT_TYPES[encoded.Format], bytes.NewReader(encoded.Data))
 	if err != nil {
-		return errors.Wrap(err, "(api.AddListingImage) saving listing image details to DB")
+		return errors.Wrap(err, "(api.uploadImage)")
 	}
 
-	return json.NewEncoder(w).Encode(views.Image{
-		ID:     listingImage.ID,
-		URL:    images.GetGcsImageUrl(listingImage.StorageID),
-		Width:  imageCfg.Width,
-		Height: imageCfg.Height,
-	})
+	return nil
 }
 
-func getUserImageBucket() string {
-	if application.IsProd() {
-		return "user-images-bucket-us"
-	} else {
-		return "dev-user-images-bucket"
+// Best effort cleanup for blobs that ended up unused, e.g. after a failed request. Runs without the
+// request's context so it still happens when the client has gone away.
+func (s ApiService) deleteBlobs(storageIDs []string) {
+	for _, storageID := range storageIDs {
+		err := s.blobStore.Delete(context.Background(), storageID)
+		if err != nil {
+			log.Printf("(api.deleteBlobs) failed to delete %s, leaving it for reconciliation: %v", storageID, err)
+		}
 	}
 }
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/images"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/videos"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) AddListingVideo(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.AddListingVideo) missing listing ID from AddListingVideo request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) parsing listing ID")
+	}
+
+	// Make sure this user has ownership of this listing or is an admin
+	_, err = listings.LoadByIDAndUser(s.db, listingID, auth.User)
+	if err != nil {
+		return errors.Wrapf(err, "(api.AddListingVideo) loading listing %d for user %d", listingID, auth.User.ID)
+	}
+
+	if !videos.IsSupported() {
+		return errors.NewBadRequest("Video uploads are not available")
+	}
+
+	file, handler, err := r.FormFile("listing_video")
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) opening file")
+	}
+	defer file.Close()
+
+	if (handler.Size / 1024) > (1024 * videos.MAX_VIDEO_SIZE_MB) {
+		return errors.NewBadRequestf("Video must be less than %dMB", videos.MAX_VIDEO_SIZE_MB)
+	}
+
+	contentType := handler.Header.Get("Content-Type")
+	if _, supported := videos.SUPPORTED_VIDEO_TYPES[contentType]; !supported {
+		return errors.NewBadRequestf("Unsupported video type: %s", contentType)
+	}
+
+	// ffmpeg needs to seek around the file, so it can't read from the upload directly
+	tempFile, err := os.CreateTemp("", "listing-video-*")
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) creating temp file")
+	}
+	defer os.Remove(tempFile.Name())
+	defer tempFile.Close()
+
+	_, err = io.Copy(tempFile, file)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) writing temp file")
+	}
+
+	probe, err := videos.Probe(r.Context(), tempFile.Name())
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) probing video")
+	}
+
+	if probe.DurationMs > videos.MAX_DURATION_SECONDS*1000 {
+		return errors.NewBadRequestf("Video must be at most %d seconds long", videos.MAX_DURATION_SECONDS)
+	}
+
+	if probe.Width > videos.MAX_DIMENSION || probe.Height > videos.MAX_DIMENSION {
+		return errors.NewBadRequestf("Video must be at most %dx%d", videos.MAX_DIMENSION, videos.MAX_DIMENSION)
+	}
+
+	poster, err := videos.ExtractPoster(r.Context(), tempFile.Name(), probe.DurationMs)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) extracting poster")
+	}
+
+	processed, err := images.ProcessDecoded(r.Context(), poster)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) processing poster")
+	}
+
+	// Phones tag videos with where they were recorded, which shouldn't be published with the listing
+	strippedFile, err := os.CreateTemp("", "listing-video-stripped-*")
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) creating stripped temp file")
+	}
+	defer os.Remove(strippedFile.Name())
+	defer strippedFile.Close()
+
+	err = videos.StripMetadata(r.Context(), tempFile.Name(), strippedFile.Name(), contentType)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) stripping metadata")
+	}
+
+	// Anything uploaded is deleted again if a later step fails. If the cleanup fails too, the blob
+	// reconciliation job picks up the orphans.
+	storageID := uuid.New().String()
+	err = s.blobStore.Upload(r.Context(), storageID, contentType, strippedFile)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddListingVideo) uploading video")
+	}
+
+	posterStorageID := uuid.New().String()
+	variants, uploaded, err := s.uploadProcessedImage(r.Context(), posterStorageID, processed)
+	if err != nil {
+		s.deleteBlobs([]string{storageID})
+		return errors.Wrap(err, "(api.AddListingVideo) uploading poster")
+	}
+	uploaded = append(uploaded, storageID)
+
+	// Videos share the gallery ordering with images and go at the end too
+	rank, err := listings.GetNextImageRank(s.db, listingID)
+	if err != nil {
+		s.deleteBlobs(uploaded)
+		return errors.Wrapf(err, "(api.AddListingVideo) getting rank for listing %d", listingID)
+	}
+
+	// The dimensions are the video's, while the variants and placeholder come from the poster
+	listingVideo, err := listings.CreateListingImage(s.db, models.ListingImage{
+		ListingID:       listingID,
+		MediaType:       models.MediaTypeVideo,
+		Projection:      probe.Projection,
+		StorageID:       storageID,
+		ContentType:     &contentType,
+		DurationMs:      &probe.DurationMs,
+		PosterStorageID: &posterStorageID,
+		Rank:            rank,
+		Width:           probe.Width,
+		Height:          probe.Height,
+		Variants:        variants,
+		Blurhash:        &processed.Placeholder.Blurhash,
+		DominantColor:   &processed.Placeholder.DominantColor,
+	})
+	if err != nil {
+		s.deleteBlobs(uploaded)
+		return errors.Wrap(err, "(api.AddListingVideo) saving listing video details to DB")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertMediaItem(*listingVideo))
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/repositories/wishlists"
+	"go.coaster.io/server/common/views"
+)
+
+type AddWishlistListingRequest = input.WishlistListing
+
+func (s ApiService) AddWishlistListing(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strWishlistID, ok := vars["wishlistID"]
+	if !ok {
+		return errors.Newf("(api.AddWishlistListing) missing wishlist ID from AddWishlistListing request URL: %s", r.URL.RequestURI())
+	}
+
+	wishlistID, err := strconv.ParseInt(strWishlistID, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddWishlistListing) parsing wishlist ID")
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var addWishlistListingRequest AddWishlistListingRequest
+	err = decoder.Decode(&addWishlistListingRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddWishlistListing) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(addWishlistListingRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddWishlistListing) validating request")
+	}
+
+	wishlist, err := wishlists.LoadByIDAndUser(s.db, wishlistID, auth.User.ID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.AddWishlistListing) loading wishlist")
+		}
+	}
+
+	// Make sure the listing exists and is visible to this user
+	listing, err := listings.LoadByIDAndUser(s.db, addWishlistListingRequest.ListingID, auth.User)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrapf(err, "(api.AddWishlistListing) loading listing %d", addWishlistListingRequest.ListingID)
+		}
+	}
+
+	err = wishlists.AddListing(s.db, wishlist.ID, listing.ID)
+	if err != nil {
+		return errors.Wrapf(err, "(api.AddWishlistListing) adding listing %d to wishlist %d", listing.ID, wishlist.ID)
+	}
+
+	wishlistDetails, err := wishlists.LoadDetails(s.db, *wishlist)
+	if err != nil {
+		return errors.Wrap(err, "(api.AddWishlistListing) loading wishlist details")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertWishlist(*wishlistDetails))
+}
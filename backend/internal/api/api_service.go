@@ -2,6 +2,8 @@ package api
 
 import (
 	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/blobs"
+	"go.coaster.io/server/common/maps"
 	"go.coaster.io/server/internal/router"
 
 	"gorm.io/gorm"
@@ -10,12 +12,16 @@ import (
 type ApiService struct {
 	db          *gorm.DB
 	authService auth.AuthService
+	geocoder    maps.Geocoder
+	blobStore   blobs.Store
 }
 
-func NewApiService(db *gorm.DB, authService auth.AuthService) ApiService {
+func NewApiService(db *gorm.DB, authService auth.AuthService, geocoder maps.Geocoder, blobStore blobs.Store) ApiService {
 	return ApiService{
 		db:          db,
 		authService: authService,
+		geocoder:    geocoder,
+		blobStore:   blobStore,
 	}
 }
 
@@ -26,12 +32,16 @@ func (s ApiService) AuthenticatedRoutes() []router.AuthenticatedRoute {
 			Method:      router.GET,
 			Pattern:     "/check_session",
 			HandlerFunc: s.CheckSession,
+
+			AllowWithoutTwoFactor: true,
 		},
 		{
 			Name:        "Logout",
 			Method:      router.DELETE,
 			Pattern:     "/logout",
 			HandlerFunc: s.Logout,
+
+			AllowWithoutTwoFactor: true,
 		},
 		{
 			Name:        "Create listing",
@@ -45,12 +55,36 @@ func (s ApiService) AuthenticatedRoutes() []router.AuthenticatedRoute {
 			Pattern:     "/listings/{listingID}",
 			HandlerFunc: s.DeleteListing,
 		},
+		{
+			Name:        "Import listings",
+			Method:      router.POST,
+			Pattern:     "/listings/import",
+			HandlerFunc: s.ImportListings,
+		},
+		{
+			Name:        "Export listings",
+			Method:      router.GET,
+			Pattern:     "/listings/export",
+			HandlerFunc: s.ExportListings,
+		},
+		{
+			Name:        "Duplicate listing",
+			Method:      router.POST,
+			Pattern:     "/listings/{listingID}/duplicate",
+			HandlerFunc: s.DuplicateListing,
+		},
 		{
 			Name:        "Add listing image",
 			Method:      router.POST,
 			Pattern:     "/listings/{listingID}/image",
 			HandlerFunc: s.AddListingImage,
 		},
+		{
+			Name:        "Add listing video",
+			Method:      router.POST,
+			Pattern:     "/listings/{listingID}/video",
+			HandlerFunc: s.AddListingVideo,
+		},
 		{
 			Name:        "Delete listing image",
 			Method:      router.DELETE,
@@ -63,6 +97,12 @@ func (s ApiService) AuthenticatedRoutes() []router.AuthenticatedRoute {
 			Pattern:     "/listings/{listingID}/images",
 			HandlerFunc: s.UpdateListingImages,
 		},
+		{
+			Name:        "Move listing image",
+			Method:      router.POST,
+			Pattern:     "/listings/{listingID}/image/{imageID}/move",
+			HandlerFunc: s.MoveListingImage,
+		},
 		{
 			Name:        "Update listing",
 			Method:      router.POST,
@@ -87,6 +127,48 @@ func (s ApiService) AuthenticatedRoutes() []router.AuthenticatedRoute {
 			Pattern:     "/user",
 			HandlerFunc: s.UpdateUser,
 		},
+		{
+			Name:        "Send email verification",
+			Method:      router.POST,
+			Pattern:     "/user/send_verification",
+			HandlerFunc: s.SendEmailVerification,
+		},
+		{
+			Name:        "Reauthenticate",
+			Method:      router.POST,
+			Pattern:     "/user/reauthenticate",
+			HandlerFunc: s.Reauthenticate,
+
+			AllowWithoutTwoFactor: true,
+		},
+		{
+			Name:        "Enroll two-factor",
+			Method:      router.POST,
+			Pattern:     "/user/two_factor",
+			HandlerFunc: s.EnrollTwoFactor,
+
+			AllowWithoutTwoFactor: true,
+		},
+		{
+			Name:        "Confirm two-factor",
+			Method:      router.POST,
+			Pattern:     "/user/two_factor/confirm",
+			HandlerFunc: s.ConfirmTwoFactor,
+
+			AllowWithoutTwoFactor: true,
+		},
+		{
+			Name:        "Disable two-factor",
+			Method:      router.DELETE,
+			Pattern:     "/user/two_factor",
+			HandlerFunc: s.DisableTwoFactor,
+		},
+		{
+			Name:        "Regenerate recovery codes",
+			Method:      router.POST,
+			Pattern:     "/user/two_factor/recovery_codes",
+			HandlerFunc: s.RegenerateRecoveryCodes,
+		},
 		{
 			Name:        "Update profile picture",
 			Method:      router.POST,
@@ -129,6 +211,108 @@ func (s ApiService) AuthenticatedRoutes() []router.AuthenticatedRoute {
 			Pattern:     "/listings/{listingID}/itinerary_steps",
 			HandlerFunc: s.UpdateItinerarySteps,
 		},
+		{
+			Name:        "Move itinerary step",
+			Method:      router.POST,
+			Pattern:     "/listings/{listingID}/itinerary_steps/{stepID}/move",
+			HandlerFunc: s.MoveItineraryStep,
+		},
+		{
+			Name:        "Update meeting points",
+			Method:      router.POST,
+			Pattern:     "/listings/{listingID}/meeting_points",
+			HandlerFunc: s.UpdateMeetingPoints,
+		},
+		{
+			Name:        "Get listing translations",
+			Method:      router.GET,
+			Pattern:     "/listings/{listingID}/translations",
+			HandlerFunc: s.GetListingTranslations,
+		},
+		{
+			Name:        "Update listing translation",
+			Method:      router.POST,
+			Pattern:     "/listings/{listingID}/translations/{locale}",
+			HandlerFunc: s.UpdateListingTranslation,
+		},
+		{
+			Name:        "Delete listing translation",
+			Method:      router.DELETE,
+			Pattern:     "/listings/{listingID}/translations/{locale}",
+			HandlerFunc: s.DeleteListingTranslation,
+		},
+		{
+			Name:        "Get listing revision",
+			Method:      router.GET,
+			Pattern:     "/listings/{listingID}/revision",
+			HandlerFunc: s.GetListingRevision,
+		},
+		{
+			Name:        "Submit listing revision",
+			Method:      router.POST,
+			Pattern:     "/listings/{listingID}/revision/submit",
+			HandlerFunc: s.SubmitListingRevision,
+		},
+		{
+			Name:        "Discard listing revision",
+			Method:      router.DELETE,
+			Pattern:     "/listings/{listingID}/revision",
+			HandlerFunc: s.DiscardListingRevision,
+		},
+		{
+			Name:        "Get listing revisions",
+			Method:      router.GET,
+			Pattern:     "/listings/{listingID}/revisions",
+			HandlerFunc: s.GetListingRevisions,
+		},
+		{
+			Name:        "Rollback listing revision",
+			Method:      router.POST,
+			Pattern:     "/listings/{listingID}/revisions/{revisionID}/rollback",
+			HandlerFunc: s.RollbackListingRevision,
+		},
+		{
+			Name:        "Get listings under review",
+			Method:      router.GET,
+			Pattern:     "/admin/listings/review",
+			HandlerFunc: s.GetListingsUnderReview,
+		},
+		{
+			Name:        "Approve listing",
+			Method:      router.POST,
+			Pattern:     "/admin/listings/{listingID}/approve",
+			HandlerFunc: s.ApproveListing,
+		},
+		{
+			Name:        "Reject listing",
+			Method:      router.POST,
+			Pattern:     "/admin/listings/{listingID}/reject",
+			HandlerFunc: s.RejectListing,
+		},
+		{
+			Name:        "Get listing status events",
+			Method:      router.GET,
+			Pattern:     "/admin/listings/{listingID}/status_events",
+			HandlerFunc: s.GetListingStatusEvents,
+		},
+		{
+			Name:        "Get revisions under review",
+			Method:      router.GET,
+			Pattern:     "/admin/revisions/review",
+			HandlerFunc: s.GetRevisionsUnderReview,
+		},
+		{
+			Name:        "Approve listing revision",
+			Method:      router.POST,
+			Pattern:     "/admin/revisions/{revisionID}/approve",
+			HandlerFunc: s.ApproveListingRevision,
+		},
+		{
+			Name:        "Reject listing revision",
+			Method:      router.POST,
+			Pattern:     "/admin/revisions/{revisionID}/reject",
+			HandlerFunc: s.RejectListingRevision,
+		},
 		{
 			Name:        "Get payout methods",
 			Method:      router.GET,
@@ -165,6 +349,48 @@ func (s ApiService) AuthenticatedRoutes() []router.AuthenticatedRoute {
 			Pattern:     "/user_bookings/{bookingReference}",
 			HandlerFunc: s.GetUserBooking,
 		},
+		{
+			Name:        "Get wishlists",
+			Method:      router.GET,
+			Pattern:     "/wishlists",
+			HandlerFunc: s.GetWishlists,
+		},
+		{
+			Name:        "Create wishlist",
+			Method:      router.POST,
+			Pattern:     "/wishlists",
+			HandlerFunc: s.CreateWishlist,
+		},
+		{
+			Name:        "Get wishlist",
+			Method:      router.GET,
+			Pattern:     "/wishlists/{wishlistID}",
+			HandlerFunc: s.GetWishlist,
+		},
+		{
+			Name:        "Update wishlist",
+			Method:      router.PATCH,
+			Pattern:     "/wishlists/{wishlistID}",
+			HandlerFunc: s.UpdateWishlist,
+		},
+		{
+			Name:        "Delete wishlist",
+			Method:      router.DELETE,
+			Pattern:     "/wishlists/{wishlistID}",
+			HandlerFunc: s.DeleteWishlist,
+		},
+		{
+			Name:        "Add wishlist listing",
+			Method:      router.POST,
+			Pattern:     "/wishlists/{wishlistID}/listings",
+			HandlerFunc: s.AddWishlistListing,
+		},
+		{
+			Name:        "Remove wishlist listing",
+			Method:      router.DELETE,
+			Pattern:     "/wishlists/{wishlistID}/listings/{listingID}",
+			HandlerFunc: s.RemoveWishlistListing,
+		},
 	}
 }
 
@@ -182,6 +408,12 @@ func (s ApiService) UnauthenticatedRoutes() []router.UnauthenticatedRoute {
 			Pattern:     "/oauth_login",
 			HandlerFunc: s.OAuthLogin,
 		},
+		{
+			Name:        "OAuth Login (form post)",
+			Method:      router.POST,
+			Pattern:     "/oauth_login",
+			HandlerFunc: s.OAuthLogin,
+		},
 		{
 			Name:        "Search listings",
 			Method:      router.GET,
@@ -194,12 +426,30 @@ func (s ApiService) UnauthenticatedRoutes() []router.UnauthenticatedRoute {
 			Pattern:     "/listing_metadata",
 			HandlerFunc: s.GetAllListingMetadata,
 		},
+		{
+			Name:        "Get listing feed",
+			Method:      router.GET,
+			Pattern:     "/listing_feed",
+			HandlerFunc: s.GetListingFeed,
+		},
 		{
 			Name:        "Get listing",
 			Method:      router.GET,
 			Pattern:     "/listings/{listingID}",
 			HandlerFunc: s.GetListing,
 		},
+		{
+			Name:        "Get nearby listings",
+			Method:      router.GET,
+			Pattern:     "/listings/{listingID}/nearby",
+			HandlerFunc: s.GetNearbyListings,
+		},
+		{
+			Name:        "Autocomplete location",
+			Method:      router.GET,
+			Pattern:     "/locations/autocomplete",
+			HandlerFunc: s.AutocompleteLocation,
+		},
 		{
 			Name:        "Get tag",
 			Method:      router.GET,
@@ -236,12 +486,36 @@ func (s ApiService) UnauthenticatedRoutes() []router.UnauthenticatedRoute {
 			Pattern:     "/reset_password",
 			HandlerFunc: s.ResetPassword,
 		},
+		{
+			Name:        "Verify email",
+			Method:      router.POST,
+			Pattern:     "/verify_email",
+			HandlerFunc: s.VerifyEmail,
+		},
 		{
 			Name:        "Email login",
 			Method:      router.POST,
 			Pattern:     "/login",
 			HandlerFunc: s.EmailLogin,
 		},
+		{
+			Name:        "Send magic link",
+			Method:      router.POST,
+			Pattern:     "/login/send_link",
+			HandlerFunc: s.SendMagicLink,
+		},
+		{
+			Name:        "Magic link login",
+			Method:      router.POST,
+			Pattern:     "/login/link",
+			HandlerFunc: s.MagicLinkLogin,
+		},
+		{
+			Name:        "Verify two-factor",
+			Method:      router.POST,
+			Pattern:     "/login/two_factor",
+			HandlerFunc: s.VerifyTwoFactor,
+		},
 		{
 			Name:        "Join waitlist",
 			Method:      router.POST,
@@ -254,5 +528,17 @@ func (s ApiService) UnauthenticatedRoutes() []router.UnauthenticatedRoute {
 			Pattern:     "/webhooks/stripe",
 			HandlerFunc: s.WebhookStripe,
 		},
+		{
+			Name:        "Get shared wishlist",
+			Method:      router.GET,
+			Pattern:     "/shared_wishlists/{shareToken}",
+			HandlerFunc: s.GetSharedWishlist,
+		},
+		{
+			Name:        "Get blob",
+			Method:      router.GET,
+			Pattern:     "/blobs/{storageID}",
+			HandlerFunc: s.GetBlob,
+		},
 	}
 }
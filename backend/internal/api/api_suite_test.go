@@ -3,6 +3,8 @@ package api_test
 import (
 	"testing"
 
+	"go.coaster.io/server/common/blobs"
+	"go.coaster.io/server/common/maps"
 	"go.coaster.io/server/common/test"
 	"go.coaster.io/server/internal/api"
 
@@ -23,7 +25,11 @@ func TestHandlers(t *testing.T) {
 
 var _ = BeforeSuite(func() {
 	db, cleanup = test.SetupDatabase()
-	service = api.NewApiService(db, test.MockAuthService{})
+	geocoder, err := maps.NewLocalGeocoder()
+	Expect(err).NotTo(HaveOccurred())
+	blobStore, err := blobs.NewLocalStore(GinkgoT().TempDir())
+	Expect(err).NotTo(HaveOccurred())
+	service = api.NewApiService(db, test.MockAuthService{}, geocoder, blobStore)
 })
 
 var _ = AfterSuite((func() {
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/emails"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+type ModerationTemplateArgs struct {
+	FirstName   string
+	ListingName string
+	ListingID   string
+	Reasons     []string
+	Feedback    *string
+	Domain      string
+}
+
+func (s ApiService) ApproveListing(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if !auth.User.IsAdmin {
+		return errors.NotFound
+	}
+
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.ApproveListing) missing listing ID from ApproveListing request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.ApproveListing)")
+	}
+
+	listing, err := listings.LoadDetailsByIDAndUser(s.db, listingID, auth.User)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.ApproveListing) loading listing")
+		}
+	}
+
+	err = listings.ApproveListing(s.db, &listing.Listing, auth.User.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.ApproveListing) approving listing")
+	}
+
+	// The listing is already published, so don't fail the request if the email can't be sent
+	err = sendListingApprovedEmail(*listing)
+	if err != nil {
+		log.Printf("(api.ApproveListing) sending email for listing %d: %+v", listing.ID, err)
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListing(*listing))
+}
+
+func sendListingApprovedEmail(listing listings.ListingDetails) error {
+	templateArgs := ModerationTemplateArgs{
+		FirstName:   listing.Host.FirstName,
+		ListingName: getListingNameForEmail(listing),
+		ListingID:   fmt.Sprintf("%d", listing.ID),
+		Domain:      getSupplierDomain(),
+	}
+
+	var html bytes.Buffer
+	LISTING_APPROVED_TEMPLATE.Execute(&html, templateArgs)
+
+	var plain bytes.Buffer
+	LISTING_APPROVED_PLAIN_TEMPLATE.Execute(&plain, templateArgs)
+
+	err := emails.SendEmail("Coaster <support@trycoaster.com>", listing.Host.Email, "Your listing is live", html.String(), plain.String())
+	if err != nil {
+		return errors.Wrap(err, "(api.sendListingApprovedEmail) sending email")
+	}
+
+	return nil
+}
+
+func getListingNameForEmail(listing listings.ListingDetails) string {
+	if listing.Name == nil {
+		return "your listing"
+	}
+
+	return *listing.Name
+}
+
+func getSupplierDomain() string {
+	if application.IsProd() {
+		return "https://supplier.trycoaster.com"
+	} else {
+		return "http://localhost:3000"
+	}
+}
+
+var LISTING_APPROVED_TEMPLATE = template.Must(template.New("listing_approved").Parse(LISTING_APPROVED_TEMPLATE_STRING))
+
+const LISTING_APPROVED_TEMPLATE_STRING = `
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<meta http-equiv="Content-Type" content="text/html charset=UTF-8" />
+<html lang="en">
+
+  <head></head>
+  <div id="email-preview" style="display:none;overflow:hidden;line-height:1px;opacity:0;max-height:0;max-width:0">
+		Your Coaster listing is live
+  </div>
+
+  <body style="background-color:#f6f9fc;padding:10px 0">
+    <table align="center" role="presentation" cellSpacing="0" cellPadding="0" border="0" width="100%" style="max-width:37.5em;background-color:#ffffff;border:1px solid #f0f0f0;padding:45px">
+      <tr style="width:100%">
+        <td><img alt="Coaster" src="https://www.trycoaster.com/long-logo.png" height="40" style="display:block;outline:none;border:none;text-decoration:none" />
+          <table align="center" border="0" cellPadding="0" cellSpacing="0" role="presentation" width="100%">
+            <tbody>
+              <tr>
+                <td>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Hi {{.FirstName}},</p>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Good news! We've reviewed {{.ListingName}} and it's now live on Coaster.</p>
+									<a href="{{.Domain}}/listings/{{.ListingID}}" target="_blank" style="background-color:#3673aa;border-radius:4px;color:#fff;font-family:&#x27;Open Sans&#x27;, &#x27;Helvetica Neue&#x27;, Arial;font-size:15px;text-decoration:none;text-align:center;display:inline-block;width:210px;padding:0px 0px;line-height:100%;max-width:100%"><span><!--[if mso]><i style="letter-spacing: undefinedpx;mso-font-width:-100%;mso-text-raise:0" hidden>&nbsp;</i><![endif]--></span><span style="background-color:#3673aa;border-radius:4px;color:#fff;font-family:&#x27;Open Sans&#x27;, &#x27;Helvetica Neue&#x27;, Arial;font-size:15px;text-decoration:none;text-align:center;display:inline-block;width:210px;padding:14px 7px;max-width:100%;line-height:120%;text-transform:none;mso-padding-alt:0px;mso-text-raise:0">View listing</span><span><!--[if mso]><i style="letter-spacing: undefinedpx;mso-font-width:-100%" hidden>&nbsp;</i><![endif]--></span></a>
+                </td>
+              </tr>
+            </tbody>
+          </table>
+        </td>
+      </tr>
+    </table>
+		<div style="width:100%;text-align:center;color:#404040;margin-top:12px;font-size:14px">Coaster, 2261 Market Street STE 5450, San Francisco, CA 94114</div>
+  </body>
+
+</html>
+`
+
+var LISTING_APPROVED_PLAIN_TEMPLATE = template.Must(template.New("listing_approved_plain").Parse(LISTING_APPROVED_PLAIN_TEMPLATE_STRING))
+
+const LISTING_APPROVED_PLAIN_TEMPLATE_STRING = `
+	Hi {{.FirstName}},
+
+	Good news! We've reviewed {{.ListingName}} and it's now live on Coaster: {{.Domain}}/listings/{{.ListingID}}
+`
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) ApproveListingRevision(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if !auth.User.IsAdmin {
+		return errors.NotFound
+	}
+
+	vars := mux.Vars(r)
+	strRevisionId, ok := vars["revisionID"]
+	if !ok {
+		return errors.Newf("(api.ApproveListingRevision) missing revision ID from ApproveListingRevision request URL: %s", r.URL.RequestURI())
+	}
+
+	revisionID, err := strconv.ParseInt(strRevisionId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.ApproveListingRevision)")
+	}
+
+	revision, err := listings.LoadRevisionByID(s.db, revisionID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.ApproveListingRevision) loading revision")
+		}
+	}
+
+	listing, err := listings.LoadByIDAndUser(s.db, revision.ListingID, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.ApproveListingRevision) loading listing")
+	}
+
+	err = listings.ApproveRevision(s.db, listing, revision, auth.User.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.ApproveListingRevision) approving revision")
+	}
+
+	listingDetails, err := listings.LoadDetailsByIDAndUser(s.db, listing.ID, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.ApproveListingRevision) loading listing details")
+	}
+
+	// The changes are already live, so don't fail the request if the email can't be sent
+	err = sendListingApprovedEmail(*listingDetails)
+	if err != nil {
+		log.Printf("(api.ApproveListingRevision) sending email for revision %d: %+v", revision.ID, err)
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListingRevision(*revision, nil))
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/ratelimit"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+const MAX_LOCATION_SUGGESTIONS = 5
+
+// Each request is billed by Google, and the endpoint is open to anonymous users. Typing a place name
+// takes a request per keystroke, so this leaves plenty of room for real searches.
+var autocompleteLimiter = ratelimit.NewLimiter(60, 20)
+
+type LocationSuggestion struct {
+	PlaceID       string `json:"place_id"`
+	Description   string `json:"description"`
+	MainText      string `json:"main_text"`
+	SecondaryText string `json:"secondary_text"`
+	ListingCount  int64  `json:"listing_count"`
+}
+
+func (s ApiService) AutocompleteLocation(w http.ResponseWriter, r *http.Request) error {
+	query := strings.TrimSpace(r.URL.Query().Get("query"))
+	if len(query) == 0 {
+		return json.NewEncoder(w).Encode([]LocationSuggestion{})
+	}
+
+	if !autocompleteLimiter.Allow(ratelimit.GetClientIP(r)) {
+		return errors.Wrap(errors.NewTooManyRequests("Too many searches. Please wait a moment and try again."), "(api.AutocompleteLocation)")
+	}
+
+	// The frontend sends the same token for every keystroke until a place is picked
+	sessionToken := r.URL.Query().Get("session_token")
+	if len(sessionToken) > 0 {
+		_, err := uuid.Parse(sessionToken)
+		if err != nil {
+			return errors.NewBadRequestf("Invalid session_token: %s", sessionToken)
+		}
+	}
+
+	placeSuggestions, err := s.geocoder.Autocomplete(query, sessionToken)
+	if err != nil {
+		return errors.Wrap(err, "(api.AutocompleteLocation) getting suggestions")
+	}
+
+	suggestions := make([]LocationSuggestion, len(placeSuggestions))
+	for i, placeSuggestion := range placeSuggestions {
+		suggestions[i] = LocationSuggestion{
+			PlaceID:       placeSuggestion.PlaceID,
+			Description:   placeSuggestion.Description,
+			MainText:      placeSuggestion.MainText,
+			SecondaryText: placeSuggestion.SecondaryText,
+		}
+	}
+
+	suggestionTerms := make([][]string, len(placeSuggestions))
+	for i, placeSuggestion := range placeSuggestions {
+		suggestionTerms[i] = placeSuggestion.Terms
+	}
+
+	if r.URL.Query().Get("bias") == "listings" {
+		listingLocations, err := listings.LoadPublishedLocations(s.db)
+		if err != nil {
+			return errors.Wrap(err, "(api.AutocompleteLocation) loading listing locations")
+		}
+
+		biasTowardListings(suggestions, suggestionTerms, listingLocations)
+	}
+
+	if len(suggestions) > MAX_LOCATION_SUGGESTIONS {
+		suggestions = suggestions[:MAX_LOCATION_SUGGESTIONS]
+	}
+
+	return json.NewEncoder(w).Encode(suggestions)
+}
+
+// Moves suggestions for places where we have listings to the top, keeping the provider's order otherwise.
+// A listing counts toward a suggestion if its city, and its region if it has one, are each exactly one of
+// the suggestion's terms. Substring matches would let "CA" match any description containing those letters.
+func biasTowardListings(suggestions []LocationSuggestion, suggestionTerms [][]string, listingLocations []listings.ListingLocation) {
+	for i := range suggestions {
+		terms := make(map[string]bool, len(suggestionTerms[i]))
+		for _, term := range suggestionTerms[i] {
+			terms[strings.ToLower(strings.TrimSpace(term))] = true
+		}
+
+		for _, listingLocation := range listingLocations {
+			city := strings.ToLower(strings.TrimSpace(listingLocation.City))
+			if len(city) == 0 || !terms[city] {
+				continue
+			}
+
+			region := strings.ToLower(strings.TrimSpace(listingLocation.Region))
+			if len(region) > 0 && !terms[region] {
+				continue
+			}
+
+			suggestions[i].ListingCount += listingLocation.Count
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].ListingCount > suggestions[j].ListingCount
+	})
+}
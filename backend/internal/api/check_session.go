@@ -10,10 +10,13 @@ import (
 
 type CheckSessionResponse struct {
 	User views.User `json:"user"`
+	// Admins who haven't used two-factor in this session can't do anything else until they do
+	TwoFactorRequired bool `json:"two_factor_required"`
 }
 
 func (s ApiService) CheckSession(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
 	return json.NewEncoder(w).Encode(CheckSessionResponse{
-		User: views.ConvertUser(*auth.User),
+		User:              views.ConvertUser(*auth.User),
+		TwoFactorRequired: auth.NeedsTwoFactor(),
 	})
 }
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/recovery_codes"
+	"go.coaster.io/server/common/repositories/sessions"
+	"go.coaster.io/server/common/repositories/users"
+)
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	// Only ever shown once
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// Finishes enrollment once the user shows their app is generating the right codes
+func (s ApiService) ConfirmTwoFactor(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	decoder := json.NewDecoder(r.Body)
+	var confirmTwoFactorRequest ConfirmTwoFactorRequest
+	err := decoder.Decode(&confirmTwoFactorRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.ConfirmTwoFactor) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(confirmTwoFactorRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.ConfirmTwoFactor) validating request")
+	}
+
+	if auth.User.HasTwoFactor() {
+		return errors.NewBadRequest("Two-factor authentication is already enabled")
+	}
+
+	if auth.User.TOTPSecret == nil {
+		return errors.NewBadRequest("Two-factor setup hasn't been started")
+	}
+
+	valid, err := s.checkTOTPCode(r.Context(), auth.User, confirmTwoFactorRequest.Code)
+	if err != nil {
+		return errors.Wrap(err, "(api.ConfirmTwoFactor)")
+	}
+
+	if !valid {
+		return errors.NewBadRequest("Invalid code")
+	}
+
+	err = users.EnableTOTP(s.db, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.ConfirmTwoFactor) enabling two-factor")
+	}
+
+	codes, err := recovery_codes.Generate(s.db, auth.User.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.ConfirmTwoFactor) generating recovery codes")
+	}
+
+	// This session just used a code, while any others never did and are logged out
+	err = sessions.Reauthenticate(s.db, auth.Session, true)
+	if err != nil {
+		return errors.Wrap(err, "(api.ConfirmTwoFactor) updating session")
+	}
+
+	err = sessions.ClearOthers(s.db, auth.Session)
+	if err != nil {
+		return errors.Wrap(err, "(api.ConfirmTwoFactor) clearing other sessions")
+	}
+
+	return json.NewEncoder(w).Encode(RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/go-playground/validator"
+	"go.coaster.io/server/common/application"
 	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/database"
 	"go.coaster.io/server/common/errors"
@@ -38,6 +39,11 @@ func (s ApiService) CreateCheckoutLink(auth auth.Authentication, w http.Response
 		return errors.Wrap(err, "(api.CreateCheckoutLink) validating request")
 	}
 
+	err = requireVerifiedEmail(auth.User, application.RequireVerifiedEmailForCheckout())
+	if err != nil {
+		return errors.Wrap(err, "(api.CreateCheckoutLink)")
+	}
+
 	listing, err := listings.LoadDetailsByIDAndUser(s.db, createCheckoutLinkRequest.ListingID, auth.User)
 	if err != nil {
 		return errors.Wrap(err, "(api.CreateCheckoutLink) loading listing")
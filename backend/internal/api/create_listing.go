@@ -8,7 +8,6 @@ import (
 	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/input"
-	"go.coaster.io/server/common/maps"
 	"go.coaster.io/server/common/models"
 	"go.coaster.io/server/common/repositories/listings"
 	"go.coaster.io/server/common/views"
@@ -31,7 +30,7 @@ func (s ApiService) CreateListing(auth auth.Authentication, w http.ResponseWrite
 	}
 
 	if createListingRequest.Location != nil {
-		place, err := maps.GetPlaceFromQuery(*createListingRequest.Location)
+		place, err := s.geocoder.GetPlaceFromQuery(*createListingRequest.Location)
 		if err != nil {
 			return errors.Wrapf(err, "(api.CreateListing) getting location from query for %s", *createListingRequest.Location)
 		}
@@ -40,7 +39,7 @@ func (s ApiService) CreateListing(auth auth.Authentication, w http.ResponseWrite
 		createListingRequest.Coordinates = &place.Coordinates
 		createListingRequest.PlaceID = &place.PlaceID
 
-		placeDetails, err := maps.GetPlaceDetails(place.PlaceID, place.Coordinates)
+		placeDetails, err := s.geocoder.GetPlaceDetails(place.PlaceID, place.Coordinates)
 		if err != nil {
 			return errors.Wrapf(err, "(api.CreateListing) getting place details for %s", *createListingRequest.Location)
 		}
@@ -13,6 +13,11 @@ import (
 )
 
 func (s ApiService) CreatePayoutMethod(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	err := requireRecentAuthentication(auth)
+	if err != nil {
+		return errors.Wrap(err, "(api.CreatePayoutMethod)")
+	}
+
 	var stripeAccountID string
 	if auth.User.StripeAccountID == nil {
 		user, err := createStripeAccount(s.db, auth.User)
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
 	"strings"
 
@@ -59,6 +60,12 @@ func (s ApiService) CreateUser(w http.ResponseWriter, r *http.Request) error {
 		return errors.Wrap(err, "(api.CreateUser) error creating user by email")
 	}
 
+	// The account works without verifying, and the user can ask for another email later
+	err = s.sendVerificationEmail(user)
+	if err != nil {
+		log.Printf("(api.CreateUser) sending verification email for user %d: %+v", user.ID, err)
+	}
+
 	sessionToken, err := sessions.Create(s.db, user.ID)
 	if err != nil {
 		return errors.Wrap(err, "(api.CreateUser) could not create session")
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/repositories/wishlists"
+	"go.coaster.io/server/common/views"
+)
+
+type CreateWishlistRequest = input.Wishlist
+
+func (s ApiService) CreateWishlist(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	decoder := json.NewDecoder(r.Body)
+	var createWishlistRequest CreateWishlistRequest
+	err := decoder.Decode(&createWishlistRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.CreateWishlist) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(createWishlistRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.CreateWishlist) validating request")
+	}
+
+	wishlist, err := wishlists.CreateWishlist(s.db, auth.User.ID, createWishlistRequest.Name)
+	if err != nil {
+		return errors.Wrap(err, "(api.CreateWishlist) creating wishlist")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertWishlist(wishlists.WishlistDetails{
+		Wishlist: *wishlist,
+		Listings: []listings.ListingDetails{},
+	}))
+}
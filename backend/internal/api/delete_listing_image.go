@@ -1,11 +1,9 @@
 package api
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 
-	"cloud.google.com/go/storage"
 	"github.com/gorilla/mux"
 	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/errors"
@@ -41,16 +39,15 @@ func (s ApiService) DeleteListingImage(auth auth.Authentication, w http.Response
 		return errors.Wrapf(err, "(api.DeleteListingImage) loading listing %d for user %d", listingID, auth.User.ID)
 	}
 
-	// No need to update rank of other listings since the order stays the same
-	if len(listing.Images) <= 3 && listing.Status != models.ListingStatusDraft {
-		return errors.NewCustomerVisibleError("You must have at least 3 images for your listing.")
-	}
-
 	var listingImage *models.ListingImage
-	for _, image := range listing.Images {
+	photoCount := 0
+	for i, image := range listing.Images {
 		if image.ID == imageID {
-			listingImage = &image
-			break
+			listingImage = &listing.Images[i]
+		}
+
+		if image.MediaType != models.MediaTypeVideo {
+			photoCount++
 		}
 	}
 
@@ -58,24 +55,28 @@ func (s ApiService) DeleteListingImage(auth auth.Authentication, w http.Response
 		return errors.NewCustomerVisibleError("Image not found.")
 	}
 
-	// TODO: do this transactionally or figure out something to handle failures
+	// Videos don't count towards the minimum, so they can always be removed. No need to update rank of
+	// other listings since the order stays the same.
+	if listingImage.MediaType != models.MediaTypeVideo && photoCount <= 3 && listing.Status != models.ListingStatusDraft {
+		return errors.NewCustomerVisibleError("You must have at least 3 images for your listing.")
+	}
+
 	err = listings.DeleteListingImage(s.db, listingImage)
 	if err != nil {
 		return errors.Wrapf(err, "(api.DeleteListingImage) deleting image %d for listing %d", imageID, listingID)
 	}
 
-	client, err := storage.NewClient(context.TODO())
-	if err != nil {
-		return errors.Wrap(err, "(api.DeleteListingImage) opening storage client")
+	// The image is already gone as far as the listing is concerned, so failing to delete the blobs
+	// shouldn't fail the request
+	storageIDs := []string{listingImage.StorageID}
+	if listingImage.PosterStorageID != nil {
+		storageIDs = append(storageIDs, *listingImage.PosterStorageID)
 	}
-	defer client.Close()
-
-	userImagesBucket := getUserImageBucket()
-
-	err = client.Bucket(userImagesBucket).Object(listingImage.StorageID).Delete(context.TODO())
-	if err != nil {
-		return errors.Wrapf(err, "(api.DeleteListingImage) deleting image %s", listingImage.StorageID)
+	for _, variant := range listingImage.Variants {
+		storageIDs = append(storageIDs, variant.StorageID)
 	}
 
+	s.deleteBlobs(storageIDs)
+
 	return nil
 }
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+func (s ApiService) DeleteListingTranslation(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.DeleteListingTranslation) missing listing ID from DeleteListingTranslation request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.DeleteListingTranslation)")
+	}
+
+	locale, ok := vars["locale"]
+	if !ok {
+		return errors.Newf("(api.DeleteListingTranslation) missing locale from DeleteListingTranslation request URL: %s", r.URL.RequestURI())
+	}
+
+	listing, err := s.loadHostedListing(auth.User, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.DeleteListingTranslation)")
+	}
+
+	err = listings.DeleteTranslation(s.db, listing.ID, locale)
+	if err != nil {
+		return errors.Wrap(err, "(api.DeleteListingTranslation) deleting translation")
+	}
+
+	return nil
+}
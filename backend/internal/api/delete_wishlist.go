@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/wishlists"
+)
+
+func (s ApiService) DeleteWishlist(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strWishlistID, ok := vars["wishlistID"]
+	if !ok {
+		return errors.Newf("(api.DeleteWishlist) missing wishlist ID from DeleteWishlist request URL: %s", r.URL.RequestURI())
+	}
+
+	wishlistID, err := strconv.ParseInt(strWishlistID, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.DeleteWishlist) parsing wishlist ID")
+	}
+
+	// Make sure this user owns the wishlist
+	wishlist, err := wishlists.LoadByIDAndUser(s.db, wishlistID, auth.User.ID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.DeleteWishlist) loading wishlist")
+		}
+	}
+
+	err = wishlists.DeleteWishlist(s.db, wishlist)
+	if err != nil {
+		return errors.Wrapf(err, "(api.DeleteWishlist) deleting wishlist %d", wishlistID)
+	}
+
+	return nil
+}
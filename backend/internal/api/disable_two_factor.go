@@ -0,0 +1,37 @@
+package api
+
+import (
+	"net/http"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/recovery_codes"
+	"go.coaster.io/server/common/repositories/users"
+)
+
+func (s ApiService) DisableTwoFactor(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if auth.User.IsAdmin {
+		return errors.NewBadRequest("Admin accounts must keep two-factor authentication enabled")
+	}
+
+	if !auth.User.HasTwoFactor() {
+		return errors.NewBadRequest("Two-factor authentication isn't enabled")
+	}
+
+	err := requireRecentAuthentication(auth)
+	if err != nil {
+		return errors.Wrap(err, "(api.DisableTwoFactor)")
+	}
+
+	err = users.DisableTOTP(s.db, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.DisableTwoFactor) disabling two-factor")
+	}
+
+	err = recovery_codes.DeactivateAllForUser(s.db, auth.User.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.DisableTwoFactor) deactivating recovery codes")
+	}
+
+	return nil
+}
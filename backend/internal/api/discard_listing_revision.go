@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+func (s ApiService) DiscardListingRevision(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.DiscardListingRevision) missing listing ID from DiscardListingRevision request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.DiscardListingRevision)")
+	}
+
+	listing, err := s.loadHostedListing(auth.User, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.DiscardListingRevision)")
+	}
+
+	revision, err := listings.LoadOpenRevision(s.db, listing.ID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.DiscardListingRevision) loading revision")
+		}
+	}
+
+	err = listings.DiscardRevision(s.db, revision)
+	if err != nil {
+		return errors.Wrap(err, "(api.DiscardListingRevision) discarding revision")
+	}
+
+	return nil
+}
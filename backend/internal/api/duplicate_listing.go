@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/images"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) DuplicateListing(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.DuplicateListing) missing listing ID from DuplicateListing request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.DuplicateListing) parsing listing ID")
+	}
+
+	listing, err := s.loadHostedListing(auth.User, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.DuplicateListing)")
+	}
+
+	// Each listing gets its own copy of the images so deleting one from the copy doesn't affect the original
+	copiedMedia := make(map[int64]listings.CopiedMedia)
+	copied := []string{}
+	for _, image := range listing.Images {
+		storageID := uuid.New().String()
+		err := s.blobStore.Copy(r.Context(), image.StorageID, storageID)
+		if err != nil {
+			s.deleteBlobs(copied)
+			return errors.Wrapf(err, "(api.DuplicateListing) copying image %s", image.StorageID)
+		}
+		copied = append(copied, storageID)
+
+		copiedImage := listings.CopiedMedia{StorageID: storageID}
+		if image.PosterStorageID != nil {
+			posterStorageID := uuid.New().String()
+			err := s.blobStore.Copy(r.Context(), *image.PosterStorageID, posterStorageID)
+			if err != nil {
+				s.deleteBlobs(copied)
+				return errors.Wrapf(err, "(api.DuplicateListing) copying video poster %s", *image.PosterStorageID)
+			}
+			copied = append(copied, posterStorageID)
+			copiedImage.PosterStorageID = &posterStorageID
+		}
+
+		// Variants are named after the still, which for videos is the poster
+		stillStorageID := images.GetStillStorageID(models.ListingImage{StorageID: copiedImage.StorageID, PosterStorageID: copiedImage.PosterStorageID})
+		for _, variant := range image.Variants {
+			variantStorageID := images.GetVariantStorageID(stillStorageID, variant.Name, variant.Format)
+			err := s.blobStore.Copy(r.Context(), variant.StorageID, variantStorageID)
+			if err != nil {
+				s.deleteBlobs(copied)
+				return errors.Wrapf(err, "(api.DuplicateListing) copying image variant %s", variant.StorageID)
+			}
+			copied = append(copied, variantStorageID)
+		}
+
+		copiedMedia[image.ID] = copiedImage
+	}
+
+	duplicate, err := listings.DuplicateListing(s.db, *listing, copiedMedia)
+	if err != nil {
+		s.deleteBlobs(copied)
+		return errors.Wrap(err, "(api.DuplicateListing) duplicating listing")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListing(*duplicate))
+}
@@ -6,9 +6,7 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator"
-	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/errors"
-	"go.coaster.io/server/common/repositories/sessions"
 	"go.coaster.io/server/common/repositories/users"
 	"go.coaster.io/server/common/views"
 	"golang.org/x/crypto/bcrypt"
@@ -20,7 +18,10 @@ type EmailLoginRequest struct {
 }
 
 type EmailLoginResponse struct {
-	User views.User `json:"user"`
+	User *views.User `json:"user,omitempty"`
+	// Set instead of User when the user has two-factor enabled. Send it to VerifyTwoFactor with a
+	// code to finish logging in.
+	TwoFactorChallenge *string `json:"two_factor_challenge,omitempty"`
 }
 
 func (s ApiService) EmailLogin(w http.ResponseWriter, r *http.Request) error {
@@ -52,14 +53,10 @@ func (s ApiService) EmailLogin(w http.ResponseWriter, r *http.Request) error {
 		return errors.NewBadRequest("invalid password")
 	}
 
-	sessionToken, err := sessions.Create(s.db, user.ID)
+	response, err := s.logIn(w, user)
 	if err != nil {
-		return errors.Wrap(err, "(api.EmailLogin) could not create session")
+		return errors.Wrap(err, "(api.EmailLogin)")
 	}
 
-	auth.AddSessionCookie(w, *sessionToken)
-
-	return json.NewEncoder(w).Encode(EmailLoginResponse{
-		User: views.ConvertUser(*user),
-	})
+	return json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/users"
+	"go.coaster.io/server/common/totp"
+)
+
+type EnrollTwoFactorResponse struct {
+	// For users who can't scan the QR code
+	Secret string `json:"secret"`
+	// Rendered as a QR code for authenticator apps
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+// Starts setting up two-factor. It isn't enabled until the user confirms a code from their app.
+func (s ApiService) EnrollTwoFactor(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	err := requireRecentAuthentication(auth)
+	if err != nil {
+		return errors.Wrap(err, "(api.EnrollTwoFactor)")
+	}
+
+	if auth.User.HasTwoFactor() {
+		return errors.NewBadRequest("Two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return errors.Wrap(err, "(api.EnrollTwoFactor) generating secret")
+	}
+
+	encryptedSecret, err := totp.EncryptSecret(r.Context(), secret)
+	if err != nil {
+		return errors.Wrap(err, "(api.EnrollTwoFactor) encrypting secret")
+	}
+
+	err = users.SetPendingTOTPSecret(s.db, auth.User, encryptedSecret)
+	if err != nil {
+		return errors.Wrap(err, "(api.EnrollTwoFactor) saving secret")
+	}
+
+	return json.NewEncoder(w).Encode(EnrollTwoFactorResponse{
+		Secret:     secret,
+		OtpauthURI: totp.GetURI(secret, auth.User.Email),
+	})
+}
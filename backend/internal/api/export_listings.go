@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/listing_files"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+var EXPORT_CONTENT_TYPES = map[listing_files.Format]string{
+	listing_files.FormatCSV:  "text/csv",
+	listing_files.FormatJSON: "application/json",
+}
+
+// Exports the host's listings in the same format accepted by ImportListings
+func (s ApiService) ExportListings(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	formatParam := r.URL.Query().Get("format")
+	if len(formatParam) == 0 {
+		formatParam = string(listing_files.FormatCSV)
+	}
+
+	format, err := listing_files.ParseFormat(formatParam)
+	if err != nil {
+		return errors.Wrap(err, "(api.ExportListings) parsing format")
+	}
+
+	hostedListings, err := listings.LoadAllByUserID(s.db, auth.User.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.ExportListings) loading listings")
+	}
+
+	w.Header().Set("Content-Type", EXPORT_CONTENT_TYPES[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"listings.%s\"", format))
+
+	err = listing_files.Write(format, w, hostedListings)
+	if err != nil {
+		return errors.Wrap(err, "(api.ExportListings) writing listings")
+	}
+
+	return nil
+}
@@ -46,7 +46,7 @@ func (s ApiService) GetAvailability(w http.ResponseWriter, r *http.Request) erro
 		return errors.NewCustomerVisibleError("Invalid end date")
 	}
 
-	auth, err := s.authService.GetAuthentication(r)
+	auth, err := s.getOptionalAuthentication(r)
 	if err != nil {
 		return errors.Wrap(err, "(api.GetAvailability) unexpected authentication error")
 	}
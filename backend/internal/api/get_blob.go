@@ -0,0 +1,47 @@
+package api
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/blobs"
+	"go.coaster.io/server/common/errors"
+)
+
+// Serves uploads from the local store in development. Other stores are served by their own host.
+func (s ApiService) GetBlob(w http.ResponseWriter, r *http.Request) error {
+	if !blobs.UseLocalStore() {
+		return errors.NotFound
+	}
+
+	vars := mux.Vars(r)
+	storageID, ok := vars["storageID"]
+	if !ok {
+		return errors.Newf("(api.GetBlob) missing storage ID from GetBlob request URL: %s", r.URL.RequestURI())
+	}
+
+	blob, err := s.blobStore.Download(r.Context(), storageID)
+	if err != nil {
+		if errors.Is(err, blobs.ErrNotFound) {
+			return errors.NotFound
+		}
+
+		return errors.Wrapf(err, "(api.GetBlob) downloading %s", storageID)
+	}
+	defer blob.Close()
+
+	// The local store doesn't keep content types, so sniff them from the data
+	reader := bufio.NewReader(blob)
+	header, _ := reader.Peek(512)
+
+	w.Header().Set("Content-Type", http.DetectContentType(header))
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	_, err = io.Copy(w, reader)
+	if err != nil {
+		return errors.Wrapf(err, "(api.GetBlob) writing %s", storageID)
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/locales"
 	"go.coaster.io/server/common/repositories/listings"
 	"go.coaster.io/server/common/views"
 )
@@ -23,7 +24,7 @@ func (s ApiService) GetListing(w http.ResponseWriter, r *http.Request) error {
 		return errors.Wrap(err, "(api.GetListing)")
 	}
 
-	auth, err := s.authService.GetAuthentication(r)
+	auth, err := s.getOptionalAuthentication(r)
 	if err != nil {
 		return errors.Wrap(err, "(api.GetListing) unexpected authentication error")
 	}
@@ -41,5 +42,38 @@ func (s ApiService) GetListing(w http.ResponseWriter, r *http.Request) error {
 		}
 	}
 
-	return json.NewEncoder(w).Encode(views.ConvertListing(*listing))
+	// The host and admins edit the listing from this response, so they get the source text unless they
+	// explicitly ask for a locale. Otherwise a save would write the translation over the original.
+	localizedListings := []listings.ListingDetails{*listing}
+	isEditor := auth.User != nil && (auth.User.ID == listing.UserID || auth.User.IsAdmin)
+	if isEditor && !r.URL.Query().Has("locale") {
+		err = listings.LocalizeListings(s.db, localizedListings, nil)
+	} else {
+		err = s.localizeListings(r, localizedListings)
+	}
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListing) localizing listing")
+	}
+
+	w.Header().Set("Content-Language", localizedListings[0].Locale)
+	w.Header().Set("Vary", "Accept-Language")
+
+	listingViews := []views.Listing{views.ConvertListing(localizedListings[0])}
+	err = s.markSavedListings(auth.User, listingViews)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListing) marking saved listing")
+	}
+
+	return json.NewEncoder(w).Encode(listingViews[0])
+}
+
+// Serves each listing in the best available translation for the locale param or Accept-Language header
+func (s ApiService) localizeListings(r *http.Request, listingDetails []listings.ListingDetails) error {
+	requested := locales.ParseRequested(r.URL.Query().Get("locale"), r.Header.Get("Accept-Language"))
+	err := listings.LocalizeListings(s.db, listingDetails, requested)
+	if err != nil {
+		return errors.Wrap(err, "(api.localizeListings)")
+	}
+
+	return nil
 }
@@ -0,0 +1,21 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+// Every published listing in one response for the commerce feeds, which need the full catalog
+// rather than a page of search results
+func (s ApiService) GetListingFeed(w http.ResponseWriter, r *http.Request) error {
+	publishedListings, err := listings.LoadAllPublished(s.db)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingFeed) loading published listings")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListings(publishedListings))
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+// Returns the open revision for a published listing along with the changes from the live version
+func (s ApiService) GetListingRevision(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.GetListingRevision) missing listing ID from GetListingRevision request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingRevision)")
+	}
+
+	listing, err := s.loadHostedListing(auth.User, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingRevision)")
+	}
+
+	revision, err := listings.LoadOpenRevision(s.db, listing.ID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.GetListingRevision) loading revision")
+		}
+	}
+
+	changes, err := listings.DiffContent(listings.GetListingContent(listing.Listing, listing.Categories), revision.Content)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingRevision) diffing revision")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListingRevision(*revision, changes))
+}
+
+// Loads a listing that only its host and admins are allowed to manage
+func (s ApiService) loadHostedListing(user *models.User, listingID int64) (*listings.ListingDetails, error) {
+	listing, err := listings.LoadDetailsByIDAndUser(s.db, listingID, user)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil, errors.NotFound
+		} else {
+			return nil, errors.Wrap(err, "(api.loadHostedListing) loading listing")
+		}
+	}
+
+	if listing.UserID != user.ID && !user.IsAdmin {
+		return nil, errors.NotFound
+	}
+
+	return listing, nil
+}
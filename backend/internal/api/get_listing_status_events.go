@@ -0,0 +1,36 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+func (s ApiService) GetListingStatusEvents(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if !auth.User.IsAdmin {
+		return errors.NotFound
+	}
+
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.GetListingStatusEvents) missing listing ID from GetListingStatusEvents request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingStatusEvents)")
+	}
+
+	statusEvents, err := listings.LoadStatusEvents(s.db, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingStatusEvents) loading status events")
+	}
+
+	return json.NewEncoder(w).Encode(statusEvents)
+}
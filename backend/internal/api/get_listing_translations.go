@@ -0,0 +1,38 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) GetListingTranslations(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.GetListingTranslations) missing listing ID from GetListingTranslations request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingTranslations)")
+	}
+
+	listing, err := s.loadHostedListing(auth.User, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingTranslations)")
+	}
+
+	translations, err := listings.LoadTranslations(s.db, listing.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingTranslations) loading translations")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertTranslations(translations))
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) GetListingsUnderReview(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	// Hide admin endpoints from everyone else
+	if !auth.User.IsAdmin {
+		return errors.NotFound
+	}
+
+	listingsUnderReview, err := listings.LoadUnderReview(s.db)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetListingsUnderReview) loading listings")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListingsUnderReview(listingsUnderReview))
+}
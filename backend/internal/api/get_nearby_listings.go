@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+const DEFAULT_NEARBY_LIMIT = 8
+
+func (s ApiService) GetNearbyListings(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.GetNearbyListings) missing listing ID from GetNearbyListings request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetNearbyListings)")
+	}
+
+	limit := int64(DEFAULT_NEARBY_LIMIT)
+	limitParam, err := parseOptionalInt(r, "limit")
+	if err != nil {
+		return errors.Wrap(err, "(api.GetNearbyListings) parsing limit")
+	}
+
+	if limitParam != nil {
+		limit = *limitParam
+	}
+
+	sameCategories := r.URL.Query().Get("same_categories") == "true"
+
+	auth, err := s.getOptionalAuthentication(r)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetNearbyListings) unexpected authentication error")
+	}
+
+	listing, err := listings.LoadDetailsByIDAndUser(
+		s.db,
+		listingID,
+		auth.User,
+	)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.GetNearbyListings) loading listing")
+		}
+	}
+
+	nearbyResults, err := listings.LoadNearby(s.db, *listing, sameCategories, int(limit))
+	if err != nil {
+		return errors.Wrap(err, "(api.GetNearbyListings) loading nearby listings")
+	}
+
+	err = s.localizeListings(r, nearbyResults.Listings)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetNearbyListings) localizing listings")
+	}
+
+	listingViews := convertSearchResults(*nearbyResults)
+	err = s.markSavedListings(auth.User, listingViews)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetNearbyListings) marking saved listings")
+	}
+
+	return json.NewEncoder(w).Encode(listingViews)
+}
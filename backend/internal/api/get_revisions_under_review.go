@@ -0,0 +1,24 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) GetRevisionsUnderReview(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if !auth.User.IsAdmin {
+		return errors.NotFound
+	}
+
+	revisionsUnderReview, err := listings.LoadRevisionsUnderReview(s.db)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetRevisionsUnderReview) loading revisions")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertRevisionsUnderReview(revisionsUnderReview))
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/wishlists"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) GetSharedWishlist(w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	shareToken, ok := vars["shareToken"]
+	if !ok {
+		return errors.Newf("(api.GetSharedWishlist) missing share token from GetSharedWishlist request URL: %s", r.URL.RequestURI())
+	}
+
+	auth, err := s.getOptionalAuthentication(r)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetSharedWishlist) unexpected authentication error")
+	}
+
+	wishlist, err := wishlists.LoadByShareToken(s.db, shareToken)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.GetSharedWishlist) loading wishlist")
+		}
+	}
+
+	wishlistDetails, err := wishlists.LoadDetails(s.db, *wishlist)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetSharedWishlist) loading wishlist details")
+	}
+
+	err = s.localizeListings(r, wishlistDetails.Listings)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetSharedWishlist) localizing listings")
+	}
+
+	wishlistView := views.ConvertWishlist(*wishlistDetails)
+
+	// The viewer is not necessarily the owner, so saved state needs to reflect their own wishlists
+	err = s.markSavedListings(auth.User, wishlistView.Listings)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetSharedWishlist) marking saved listings")
+	}
+
+	return json.NewEncoder(w).Encode(wishlistView)
+}
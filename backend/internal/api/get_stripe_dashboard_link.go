@@ -11,6 +11,11 @@ import (
 )
 
 func (s ApiService) GetStripeDashboardLink(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	err := requireRecentAuthentication(auth)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetStripeDashboardLink)")
+	}
+
 	if auth.User.StripeAccountID == nil || auth.User.StripeAccountStatus != models.StripeAccountStatusComplete {
 		return errors.NewBadRequest("Must setup Stripe account first.")
 	}
@@ -8,6 +8,7 @@ import (
 	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/images"
+	"go.coaster.io/server/common/models"
 	booking_lib "go.coaster.io/server/common/repositories/bookings"
 	"go.coaster.io/server/common/repositories/listings"
 	"go.coaster.io/server/common/repositories/payments"
@@ -47,7 +48,7 @@ func (s ApiService) GetUserBooking(auth auth.Authentication, w http.ResponseWrit
 		Listing:  listing.Listing,
 		Payments: payments,
 		BookingImage: booking_lib.BookingImage{
-			URL:    images.GetGcsImageUrl(listing.Images[0].StorageID),
+			URL:    images.GetVariantUrl(listing.Images[0], models.ImageVariantCard, models.ImageFormatJPEG),
 			Width:  listing.Images[0].Width,
 			Height: listing.Images[0].Height,
 		},
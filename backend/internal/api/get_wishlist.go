@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/wishlists"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) GetWishlist(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strWishlistID, ok := vars["wishlistID"]
+	if !ok {
+		return errors.Newf("(api.GetWishlist) missing wishlist ID from GetWishlist request URL: %s", r.URL.RequestURI())
+	}
+
+	wishlistID, err := strconv.ParseInt(strWishlistID, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetWishlist) parsing wishlist ID")
+	}
+
+	wishlist, err := wishlists.LoadByIDAndUser(s.db, wishlistID, auth.User.ID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.GetWishlist) loading wishlist")
+		}
+	}
+
+	wishlistDetails, err := wishlists.LoadDetails(s.db, *wishlist)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetWishlist) loading wishlist details")
+	}
+
+	err = s.localizeListings(r, wishlistDetails.Listings)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetWishlist) localizing listings")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertWishlist(*wishlistDetails))
+}
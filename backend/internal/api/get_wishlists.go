@@ -0,0 +1,20 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/wishlists"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) GetWishlists(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	userWishlists, err := wishlists.LoadAllForUser(s.db, auth.User.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.GetWishlists) loading wishlists")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertWishlists(userWishlists))
+}
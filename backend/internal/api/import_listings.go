@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/listing_files"
+	"go.coaster.io/server/common/maps"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+const MAX_IMPORT_FILE_SIZE = 1024 * 1024
+const MAX_IMPORT_ROWS = 100
+
+type ImportListingsResponse struct {
+	DryRun   bool              `json:"dry_run"`
+	Imported bool              `json:"imported"` // False if any row had errors, in which case nothing was created
+	Rows     []ImportRowResult `json:"rows"`
+}
+
+type importedLocation struct {
+	Place        maps.Place
+	PlaceDetails maps.PlaceDetails
+}
+
+type ImportRowResult struct {
+	Row       int      `json:"row"`
+	Name      *string  `json:"name"`
+	Errors    []string `json:"errors"`
+	ListingID *int64   `json:"listing_id,omitempty"`
+}
+
+func (s ApiService) ImportListings(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	file, handler, err := r.FormFile("listings_file")
+	if err != nil {
+		return errors.Wrap(err, "(api.ImportListings) opening file")
+	}
+	defer file.Close()
+
+	if handler.Size > MAX_IMPORT_FILE_SIZE {
+		return errors.NewBadRequest("File must be less than 1MB")
+	}
+
+	formatParam := r.URL.Query().Get("format")
+	if len(formatParam) == 0 {
+		formatParam = strings.TrimPrefix(filepath.Ext(handler.Filename), ".")
+	}
+
+	format, err := listing_files.ParseFormat(formatParam)
+	if err != nil {
+		return errors.Wrap(err, "(api.ImportListings) parsing format")
+	}
+
+	rows, err := listing_files.Parse(format, file)
+	if err != nil {
+		return errors.Wrap(err, "(api.ImportListings) parsing file")
+	}
+
+	if len(rows) == 0 {
+		return errors.NewBadRequest("The file doesn't contain any listings")
+	}
+
+	if len(rows) > MAX_IMPORT_ROWS {
+		return errors.NewBadRequestf("Files can contain at most %d listings", MAX_IMPORT_ROWS)
+	}
+
+	// Hosts often have several trips from the same place, so only look up each location once
+	locations := make(map[string]importedLocation)
+
+	validate := validator.New()
+	hasErrors := false
+	rowResults := make([]ImportRowResult, len(rows))
+	listingInputs := make([]input.Listing, len(rows))
+	for i, row := range rows {
+		rowResults[i] = ImportRowResult{
+			Row:    row.Number,
+			Name:   row.Listing.Name,
+			Errors: []string{},
+		}
+
+		listingInputs[i], err = s.validateImportRow(validate, row, locations)
+		if err != nil {
+			messages, ok := getImportErrorMessages(err)
+			if !ok {
+				// Geocoder and database failures aren't the host's fault, so fail the whole import
+				return errors.Wrapf(err, "(api.ImportListings) validating row %d", row.Number)
+			}
+
+			rowResults[i].Errors = messages
+			hasErrors = true
+		}
+	}
+
+	if dryRun || hasErrors {
+		return json.NewEncoder(w).Encode(ImportListingsResponse{
+			DryRun:   dryRun,
+			Imported: false,
+			Rows:     rowResults,
+		})
+	}
+
+	importedListings, err := listings.ImportListings(s.db, auth.User.ID, listingInputs)
+	if err != nil {
+		return errors.Wrap(err, "(api.ImportListings) importing listings")
+	}
+
+	for i := range importedListings {
+		rowResults[i].ListingID = &importedListings[i].ID
+	}
+
+	return json.NewEncoder(w).Encode(ImportListingsResponse{
+		DryRun:   false,
+		Imported: true,
+		Rows:     rowResults,
+	})
+}
+
+// Checks a row with the same rules used when creating and updating listings, filling in the location details
+func (s ApiService) validateImportRow(validate *validator.Validate, row listing_files.Row, locations map[string]importedLocation) (input.Listing, error) {
+	if row.Error != nil {
+		return row.Listing, row.Error
+	}
+
+	listingInput := row.Listing
+	err := validate.Struct(listingInput)
+	if err != nil {
+		return listingInput, err
+	}
+
+	if listingInput.Location != nil {
+		location, ok := locations[*listingInput.Location]
+		if !ok {
+			place, err := s.geocoder.GetPlaceFromQuery(*listingInput.Location)
+			if err != nil {
+				return listingInput, errors.Wrapf(err, "(api.validateImportRow) getting location from query for %s", *listingInput.Location)
+			}
+
+			placeDetails, err := s.geocoder.GetPlaceDetails(place.PlaceID, place.Coordinates)
+			if err != nil {
+				return listingInput, errors.Wrapf(err, "(api.validateImportRow) getting place details for %s", *listingInput.Location)
+			}
+
+			location = importedLocation{Place: *place, PlaceDetails: *placeDetails}
+			locations[*listingInput.Location] = location
+		}
+
+		listingInput.Location = &location.Place.Name
+		listingInput.Coordinates = &location.Place.Coordinates
+		listingInput.PlaceID = &location.Place.PlaceID
+		listingInput.City = &location.PlaceDetails.City
+		listingInput.Region = &location.PlaceDetails.Region
+		listingInput.Country = &location.PlaceDetails.Country
+		listingInput.PostalCode = location.PlaceDetails.PostalCode
+	}
+
+	err = listings.ValidateListingInput(listingInput)
+	if err != nil {
+		return listingInput, err
+	}
+
+	return listingInput, nil
+}
+
+// Returns false for errors that aren't caused by the row's contents
+func getImportErrorMessages(err error) ([]string, bool) {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		messages := make([]string, len(validationErrors))
+		for i, fieldError := range validationErrors {
+			messages[i] = fmt.Sprintf("%s is not valid (%s)", fieldError.Field(), fieldError.Tag())
+		}
+
+		return messages, true
+	}
+
+	var httpError *errors.HttpError
+	if errors.As(err, &httpError) {
+		return []string{httpError.Error()}, true
+	}
+
+	var customerVisibleError *errors.CustomerVisibleError
+	if errors.As(err, &customerVisibleError) {
+		return []string{customerVisibleError.Error()}, true
+	}
+
+	return nil, false
+}
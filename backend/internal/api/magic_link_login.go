@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/login_tokens"
+	"go.coaster.io/server/common/repositories/sessions"
+	"go.coaster.io/server/common/repositories/users"
+)
+
+type MagicLinkLoginRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+func (s ApiService) MagicLinkLogin(w http.ResponseWriter, r *http.Request) error {
+	decoder := json.NewDecoder(r.Body)
+	var magicLinkLoginRequest MagicLinkLoginRequest
+	err := decoder.Decode(&magicLinkLoginRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.MagicLinkLogin) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(magicLinkLoginRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.MagicLinkLogin) validating request")
+	}
+
+	loginToken, err := login_tokens.LoadValidByToken(s.db, magicLinkLoginRequest.Token)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NewBadRequest("This link is invalid or has expired")
+		}
+
+		return errors.Wrap(err, "(api.MagicLinkLogin) loading token")
+	}
+
+	// The link only works on the site it was requested from. The token isn't used up, so the right
+	// site can still use it.
+	if r.Header.Get("Origin") != loginToken.Origin {
+		return errors.Wrapf(errors.BadRequest, "(api.MagicLinkLogin) token for %s used from %s", loginToken.Origin, r.Header.Get("Origin"))
+	}
+
+	// It also only works in the browser that asked for it, so a forwarded or intercepted link is useless
+	nonceCookie, err := r.Cookie(auth.LOGIN_NONCE_COOKIE_NAME)
+	if err != nil && !errors.IsCookieNotFound(err) {
+		return errors.Wrap(err, "(api.MagicLinkLogin) reading nonce cookie")
+	}
+
+	if nonceCookie == nil || loginToken.DeviceNonce == nil || sessions.HashToken(nonceCookie.Value) != *loginToken.DeviceNonce {
+		return errors.NewBadRequest("Please open this link in the browser you requested it from")
+	}
+
+	err = login_tokens.Consume(s.db, loginToken)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NewBadRequest("This link is invalid or has expired")
+		}
+
+		return errors.Wrap(err, "(api.MagicLinkLogin) using token")
+	}
+
+	user, err := users.LoadUserByID(s.db, loginToken.UserID)
+	if err != nil {
+		return errors.Wrap(err, "(api.MagicLinkLogin) loading user")
+	}
+
+	if user.Blocked {
+		return errors.Wrap(errors.Forbidden, "(api.MagicLinkLogin)")
+	}
+
+	// The link was emailed, so using it proves the user owns the address
+	if !user.EmailVerified {
+		err = users.SetEmailVerified(s.db, user)
+		if err != nil {
+			return errors.Wrap(err, "(api.MagicLinkLogin) verifying email")
+		}
+	}
+
+	auth.DeleteLoginNonceCookie(w)
+
+	response, err := s.logIn(w, user)
+	if err != nil {
+		return errors.Wrap(err, "(api.MagicLinkLogin)")
+	}
+
+	return json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/itinerary_steps"
+	"go.coaster.io/server/common/repositories/listings"
+)
+
+type MoveItineraryStepRequest struct {
+	AfterID *int64 `json:"after_id"` // Omit to move the step to the front
+}
+
+func (s ApiService) MoveItineraryStep(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.MoveItineraryStep) missing listing ID from MoveItineraryStep request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveItineraryStep) parsing listing ID")
+	}
+
+	strStepId, ok := vars["stepID"]
+	if !ok {
+		return errors.Newf("(api.MoveItineraryStep) missing step ID from MoveItineraryStep request URL: %s", r.URL.RequestURI())
+	}
+
+	stepID, err := strconv.ParseInt(strStepId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveItineraryStep) parsing step ID")
+	}
+
+	var moveItineraryStepRequest MoveItineraryStepRequest
+	err = json.NewDecoder(r.Body).Decode(&moveItineraryStepRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveItineraryStep) decoding request")
+	}
+
+	// Make sure this user has ownership of this listing or is an admin
+	_, err = listings.LoadByIDAndUser(s.db, listingID, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveItineraryStep) validating ownership of listing")
+	}
+
+	err = itinerary_steps.MoveItineraryStep(s.db, listingID, stepID, moveItineraryStepRequest.AfterID)
+	if err != nil {
+		return errors.Wrapf(err, "(api.MoveItineraryStep) moving step %d for listing %d", stepID, listingID)
+	}
+
+	itinerarySteps, err := itinerary_steps.LoadItineraryForListing(s.db, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveItineraryStep) loading itinerary steps")
+	}
+
+	return json.NewEncoder(w).Encode(itinerarySteps)
+}
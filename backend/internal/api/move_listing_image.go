@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+type MoveListingImageRequest struct {
+	AfterID *int64 `json:"after_id"` // Omit to move the image to the front
+}
+
+func (s ApiService) MoveListingImage(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.MoveListingImage) missing listing ID from MoveListingImage request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveListingImage) parsing listing ID")
+	}
+
+	strImageId, ok := vars["imageID"]
+	if !ok {
+		return errors.Newf("(api.MoveListingImage) missing image ID from MoveListingImage request URL: %s", r.URL.RequestURI())
+	}
+
+	imageID, err := strconv.ParseInt(strImageId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveListingImage) parsing image ID")
+	}
+
+	var moveListingImageRequest MoveListingImageRequest
+	err = json.NewDecoder(r.Body).Decode(&moveListingImageRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveListingImage) decoding request")
+	}
+
+	// Make sure this user has ownership of this listing or is an admin
+	_, err = listings.LoadByIDAndUser(s.db, listingID, auth.User)
+	if err != nil {
+		return errors.Wrapf(err, "(api.MoveListingImage) loading listing %d for user %d", listingID, auth.User.ID)
+	}
+
+	err = listings.MoveImage(s.db, listingID, imageID, moveListingImageRequest.AfterID)
+	if err != nil {
+		return errors.Wrapf(err, "(api.MoveListingImage) moving image %d for listing %d", imageID, listingID)
+	}
+
+	listingDetails, err := listings.LoadDetailsByIDAndUser(s.db, listingID, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.MoveListingImage) loading listing details")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListing(*listingDetails))
+}
@@ -3,56 +3,63 @@ package api
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/oauth"
 	"go.coaster.io/server/common/repositories/sessions"
+	"go.coaster.io/server/common/repositories/two_factor_challenges"
 	"go.coaster.io/server/common/repositories/users"
 )
 
+// Providers redirect here with the code in the query, except Apple, which posts it as a form
 func (s ApiService) OAuthLogin(w http.ResponseWriter, r *http.Request) error {
-	if !r.URL.Query().Has("state") {
+	state := r.FormValue("state")
+	if state == "" {
 		return errors.Newf("(api.OAuthLogin) missing state from OAuth Login request URL: %s", r.URL.RequestURI())
 	}
 
-	if !r.URL.Query().Has("code") {
+	code := r.FormValue("code")
+	if code == "" {
 		return errors.Newf("(api.OAuthLogin) missing code from OAuth Login request URL: %s", r.URL.RequestURI())
 	}
 
-	state := r.URL.Query().Get("state")
-	code := r.URL.Query().Get("code")
-
-	provider, origin, err := oauth.ValidateState(state)
+	claims, err := oauth.ValidateState(state)
 	if err != nil {
 		return errors.Wrap(err, "(api.OAuthLogin)")
 	}
 
-	var externalUserInfo *oauth.ExternalUserInfo
-	switch *provider {
-	case oauth.OauthProviderGoogle:
-		externalUserInfo, err = oauth.FetchGoogleInfo(code)
-	default:
-		return errors.Newf("(api.OAuthLogin) unexpected provider %s", *provider)
+	provider, err := oauth.GetProvider(claims.Provider)
+	if err != nil {
+		return errors.Wrap(err, "(api.OAuthLogin)")
 	}
+
+	externalUserInfo, err := provider.Exchange(r.Context(), oauth.Callback{Code: code, User: r.FormValue("user")}, claims.Nonce)
 	if err != nil {
 		return errors.Wrap(err, "(api.OAuthLogin)")
 	}
 
 	// separately check for existing user to bypass allowlist for domains
-	user, err := users.LoadByExternalID(s.db, externalUserInfo.ExternalID)
+	user, err := users.LoadByExternalID(s.db, externalUserInfo.OauthProvider, externalUserInfo.ExternalID)
 	if err != nil && !errors.IsRecordNotFound(err) {
 		return errors.Wrap(err, "(api.OAuthLogin)")
 	}
 
-	// try loading user by email— we trust OAuth to give us the correct email
+	// try loading user by email— only if the provider verified it, otherwise anyone could claim an
+	// account by registering its email with the provider
 	if user == nil {
 		email := strings.ToLower(externalUserInfo.Email)
-		user, err = users.LoadByEmail(s.db, email)
+		existingUser, err := users.LoadByEmail(s.db, email)
 		if err != nil && !errors.IsRecordNotFound(err) {
 			return errors.Wrap(err, "(api.OAuthLogin) checking for matching user by email")
 		}
+
+		if existingUser != nil && !externalUserInfo.EmailVerified {
+			return errors.Wrap(errors.NewBadRequest("An account with this email already exists. Please log in the way you did before."), "(api.OAuthLogin)")
+		}
+		user = existingUser
 	}
 
 	// no user exists yet, so if the domain is not allowed then redirect
@@ -63,13 +70,24 @@ func (s ApiService) OAuthLogin(w http.ResponseWriter, r *http.Request) error {
 		}
 	}
 
+	// The frontend finishes logging in with a code and the challenge from the URL
+	if user.HasTwoFactor() {
+		challenge, err := two_factor_challenges.Create(s.db, user.ID)
+		if err != nil {
+			return errors.Wrap(err, "(api.OAuthLogin) creating two-factor challenge")
+		}
+
+		http.Redirect(w, r, getTwoFactorRedirect(claims.Origin, *challenge), http.StatusFound)
+		return nil
+	}
+
 	sessionToken, err := sessions.Create(s.db, user.ID)
 	if err != nil {
 		return errors.Wrap(err, "(api.OAuthLogin) creating session token")
 	}
 
 	auth.AddSessionCookie(w, *sessionToken)
-	http.Redirect(w, r, getOauthSuccessRedirect(*origin), http.StatusFound)
+	http.Redirect(w, r, getOauthSuccessRedirect(claims.Origin), http.StatusFound)
 
 	return nil
 }
@@ -77,3 +95,7 @@ func (s ApiService) OAuthLogin(w http.ResponseWriter, r *http.Request) error {
 func getOauthSuccessRedirect(origin string) string {
 	return fmt.Sprintf("%s/oauth-callback", origin)
 }
+
+func getTwoFactorRedirect(origin string, challenge string) string {
+	return fmt.Sprintf("%s/two-factor?challenge=%s", origin, url.QueryEscape(challenge))
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/sessions"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Users with two-factor confirm with a code, everyone else with their password
+type ReauthenticateRequest struct {
+	Password     string `json:"password"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// Lets the user through requireRecentAuthentication for a while without logging in again
+func (s ApiService) Reauthenticate(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	decoder := json.NewDecoder(r.Body)
+	var reauthenticateRequest ReauthenticateRequest
+	err := decoder.Decode(&reauthenticateRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.Reauthenticate) decoding request")
+	}
+
+	if auth.User.HasTwoFactor() {
+		if reauthenticateRequest.Code == "" && reauthenticateRequest.RecoveryCode == "" {
+			return errors.NewBadRequest("Enter the code from your authenticator app")
+		}
+
+		valid, err := s.checkSecondFactor(r.Context(), auth.User, reauthenticateRequest.Code, reauthenticateRequest.RecoveryCode)
+		if err != nil {
+			return errors.Wrap(err, "(api.Reauthenticate)")
+		}
+
+		if !valid {
+			return s.rejectReauthentication(w, auth, errors.NewBadRequest("Invalid code"))
+		}
+	} else {
+		// Google accounts have no password, so they reauthenticate by logging in again
+		if auth.User.HashedPassword == nil {
+			return errors.NewBadRequest("Please log in again to continue")
+		}
+
+		err = bcrypt.CompareHashAndPassword([]byte(*auth.User.HashedPassword), []byte(reauthenticateRequest.Password))
+		if err != nil {
+			return s.rejectReauthentication(w, auth, errors.NewBadRequest("invalid password"))
+		}
+	}
+
+	err = sessions.Reauthenticate(s.db, auth.Session, auth.User.HasTwoFactor())
+	if err != nil {
+		return errors.Wrap(err, "(api.Reauthenticate)")
+	}
+
+	return nil
+}
+
+// Counts the wrong password or code against the session. Once there have been too many the session
+// is ended and the user has to log in again.
+func (s ApiService) rejectReauthentication(w http.ResponseWriter, a auth.Authentication, rejection error) error {
+	ended, err := sessions.RecordReauthenticationFailure(s.db, a.Session)
+	if err != nil {
+		return errors.Wrap(err, "(api.rejectReauthentication)")
+	}
+
+	if ended {
+		auth.DeleteSessionCookie(w)
+		return errors.Wrap(errors.Unauthorized, "(api.rejectReauthentication) too many failures")
+	}
+
+	return rejection
+}
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/recovery_codes"
+)
+
+// Replaces all of the user's recovery codes, e.g. when they've used most of them
+func (s ApiService) RegenerateRecoveryCodes(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if !auth.User.HasTwoFactor() {
+		return errors.NewBadRequest("Two-factor authentication isn't enabled")
+	}
+
+	err := requireRecentAuthentication(auth)
+	if err != nil {
+		return errors.Wrap(err, "(api.RegenerateRecoveryCodes)")
+	}
+
+	codes, err := recovery_codes.Generate(s.db, auth.User.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.RegenerateRecoveryCodes) generating recovery codes")
+	}
+
+	return json.NewEncoder(w).Encode(RecoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+}
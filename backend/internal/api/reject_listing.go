@@ -0,0 +1,171 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/emails"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+type RejectListingRequest = input.ListingRejection
+
+var REJECTION_REASON_DESCRIPTIONS = map[models.RejectionReason]string{
+	models.RejectionReasonIncompleteInformation: "Some required information is missing",
+	models.RejectionReasonLowQualityImages:      "The photos need to be higher quality",
+	models.RejectionReasonInaccurateDescription: "The description doesn't accurately describe the trip",
+	models.RejectionReasonPricing:               "The pricing needs to be updated",
+	models.RejectionReasonPolicyViolation:       "The listing doesn't meet our policies",
+	models.RejectionReasonDuplicate:             "This is a duplicate of another listing",
+	models.RejectionReasonOther:                 "Other",
+}
+
+func (s ApiService) RejectListing(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if !auth.User.IsAdmin {
+		return errors.NotFound
+	}
+
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.RejectListing) missing listing ID from RejectListing request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListing)")
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var rejectListingRequest RejectListingRequest
+	err = decoder.Decode(&rejectListingRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListing) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(rejectListingRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListing) validating request")
+	}
+
+	listing, err := listings.LoadDetailsByIDAndUser(s.db, listingID, auth.User)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.RejectListing) loading listing")
+		}
+	}
+
+	err = listings.RejectListing(s.db, &listing.Listing, auth.User.ID, rejectListingRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListing) rejecting listing")
+	}
+
+	// The listing is already back in draft, so don't fail the request if the email can't be sent
+	err = sendListingRejectedEmail(*listing, rejectListingRequest)
+	if err != nil {
+		log.Printf("(api.RejectListing) sending email for listing %d: %+v", listing.ID, err)
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListing(*listing))
+}
+
+func sendListingRejectedEmail(listing listings.ListingDetails, rejection input.ListingRejection) error {
+	reasons := make([]string, len(rejection.Reasons))
+	for i, reason := range rejection.Reasons {
+		reasons[i] = REJECTION_REASON_DESCRIPTIONS[reason]
+	}
+
+	templateArgs := ModerationTemplateArgs{
+		FirstName:   listing.Host.FirstName,
+		ListingName: getListingNameForEmail(listing),
+		ListingID:   fmt.Sprintf("%d", listing.ID),
+		Reasons:     reasons,
+		Feedback:    rejection.Feedback,
+		Domain:      getSupplierDomain(),
+	}
+
+	var html bytes.Buffer
+	LISTING_REJECTED_TEMPLATE.Execute(&html, templateArgs)
+
+	var plain bytes.Buffer
+	LISTING_REJECTED_PLAIN_TEMPLATE.Execute(&plain, templateArgs)
+
+	err := emails.SendEmail("Coaster <support@trycoaster.com>", listing.Host.Email, "Your listing needs changes", html.String(), plain.String())
+	if err != nil {
+		return errors.Wrap(err, "(api.sendListingRejectedEmail) sending email")
+	}
+
+	return nil
+}
+
+var LISTING_REJECTED_TEMPLATE = template.Must(template.New("listing_rejected").Parse(LISTING_REJECTED_TEMPLATE_STRING))
+
+const LISTING_REJECTED_TEMPLATE_STRING = `
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<meta http-equiv="Content-Type" content="text/html charset=UTF-8" />
+<html lang="en">
+
+  <head></head>
+  <div id="email-preview" style="display:none;overflow:hidden;line-height:1px;opacity:0;max-height:0;max-width:0">
+		Your Coaster listing needs changes
+  </div>
+
+  <body style="background-color:#f6f9fc;padding:10px 0">
+    <table align="center" role="presentation" cellSpacing="0" cellPadding="0" border="0" width="100%" style="max-width:37.5em;background-color:#ffffff;border:1px solid #f0f0f0;padding:45px">
+      <tr style="width:100%">
+        <td><img alt="Coaster" src="https://www.trycoaster.com/long-logo.png" height="40" style="display:block;outline:none;border:none;text-decoration:none" />
+          <table align="center" border="0" cellPadding="0" cellSpacing="0" role="presentation" width="100%">
+            <tbody>
+              <tr>
+                <td>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Hi {{.FirstName}},</p>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Thanks for submitting {{.ListingName}}. Before we can publish it, a few things need to be updated:</p>
+                  <ul style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">
+                    {{range .Reasons}}<li>{{.}}</li>{{end}}
+                  </ul>
+                  {{if .Feedback}}<p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">{{.Feedback}}</p>{{end}}
+									<a href="{{.Domain}}/listings/{{.ListingID}}/edit" target="_blank" style="background-color:#3673aa;border-radius:4px;color:#fff;font-family:&#x27;Open Sans&#x27;, &#x27;Helvetica Neue&#x27;, Arial;font-size:15px;text-decoration:none;text-align:center;display:inline-block;width:210px;padding:0px 0px;line-height:100%;max-width:100%"><span><!--[if mso]><i style="letter-spacing: undefinedpx;mso-font-width:-100%;mso-text-raise:0" hidden>&nbsp;</i><![endif]--></span><span style="background-color:#3673aa;border-radius:4px;color:#fff;font-family:&#x27;Open Sans&#x27;, &#x27;Helvetica Neue&#x27;, Arial;font-size:15px;text-decoration:none;text-align:center;display:inline-block;width:210px;padding:14px 7px;max-width:100%;line-height:120%;text-transform:none;mso-padding-alt:0px;mso-text-raise:0">Edit listing</span><span><!--[if mso]><i style="letter-spacing: undefinedpx;mso-font-width:-100%" hidden>&nbsp;</i><![endif]--></span></a>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Once you've made the changes, submit the listing again and we'll take another look.</p>
+                </td>
+              </tr>
+            </tbody>
+          </table>
+        </td>
+      </tr>
+    </table>
+		<div style="width:100%;text-align:center;color:#404040;margin-top:12px;font-size:14px">Coaster, 2261 Market Street STE 5450, San Francisco, CA 94114</div>
+  </body>
+
+</html>
+`
+
+var LISTING_REJECTED_PLAIN_TEMPLATE = template.Must(template.New("listing_rejected_plain").Parse(LISTING_REJECTED_PLAIN_TEMPLATE_STRING))
+
+const LISTING_REJECTED_PLAIN_TEMPLATE_STRING = `
+	Hi {{.FirstName}},
+
+	Thanks for submitting {{.ListingName}}. Before we can publish it, a few things need to be updated:
+	{{range .Reasons}}
+	- {{.}}{{end}}
+	{{if .Feedback}}
+	{{.Feedback}}
+	{{end}}
+	Edit your listing here: {{.Domain}}/listings/{{.ListingID}}/edit
+
+	Once you've made the changes, submit the listing again and we'll take another look.
+`
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+type RejectListingRevisionRequest = RejectListingRequest
+
+func (s ApiService) RejectListingRevision(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if !auth.User.IsAdmin {
+		return errors.NotFound
+	}
+
+	vars := mux.Vars(r)
+	strRevisionId, ok := vars["revisionID"]
+	if !ok {
+		return errors.Newf("(api.RejectListingRevision) missing revision ID from RejectListingRevision request URL: %s", r.URL.RequestURI())
+	}
+
+	revisionID, err := strconv.ParseInt(strRevisionId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListingRevision)")
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var rejectListingRevisionRequest RejectListingRevisionRequest
+	err = decoder.Decode(&rejectListingRevisionRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListingRevision) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(rejectListingRevisionRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListingRevision) validating request")
+	}
+
+	revision, err := listings.LoadRevisionByID(s.db, revisionID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.RejectListingRevision) loading revision")
+		}
+	}
+
+	err = listings.RejectRevision(s.db, revision, auth.User.ID, rejectListingRevisionRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListingRevision) rejecting revision")
+	}
+
+	listing, err := listings.LoadDetailsByIDAndUser(s.db, revision.ListingID, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.RejectListingRevision) loading listing")
+	}
+
+	// The revision is already back with the host, so don't fail the request if the email can't be sent
+	err = sendListingRejectedEmail(*listing, rejectListingRevisionRequest)
+	if err != nil {
+		log.Printf("(api.RejectListingRevision) sending email for revision %d: %+v", revision.ID, err)
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListingRevision(*revision, nil))
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/wishlists"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) RemoveWishlistListing(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strWishlistID, ok := vars["wishlistID"]
+	if !ok {
+		return errors.Newf("(api.RemoveWishlistListing) missing wishlist ID from RemoveWishlistListing request URL: %s", r.URL.RequestURI())
+	}
+
+	wishlistID, err := strconv.ParseInt(strWishlistID, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.RemoveWishlistListing) parsing wishlist ID")
+	}
+
+	strListingID, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.RemoveWishlistListing) missing listing ID from RemoveWishlistListing request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingID, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.RemoveWishlistListing) parsing listing ID")
+	}
+
+	wishlist, err := wishlists.LoadByIDAndUser(s.db, wishlistID, auth.User.ID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.RemoveWishlistListing) loading wishlist")
+		}
+	}
+
+	err = wishlists.RemoveListing(s.db, wishlist.ID, listingID)
+	if err != nil {
+		return errors.Wrapf(err, "(api.RemoveWishlistListing) removing listing %d from wishlist %d", listingID, wishlist.ID)
+	}
+
+	wishlistDetails, err := wishlists.LoadDetails(s.db, *wishlist)
+	if err != nil {
+		return errors.Wrap(err, "(api.RemoveWishlistListing) loading wishlist details")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertWishlist(*wishlistDetails))
+}
@@ -55,6 +55,25 @@ func (s ApiService) ResetPassword(w http.ResponseWriter, r *http.Request) error
 		return errors.Wrap(err, "(api.ResetPassword) updating password")
 	}
 
+	// The reset link was emailed, so using it proves the user owns the address
+	if !user.EmailVerified {
+		err = users.SetEmailVerified(s.db, user)
+		if err != nil {
+			return errors.Wrap(err, "(api.ResetPassword) verifying email")
+		}
+	}
+
+	// A reset link alone isn't enough to get into an account with two-factor, so these users get a
+	// challenge to finish logging in instead of a session
+	if user.HasTwoFactor() {
+		response, err := s.logIn(w, user)
+		if err != nil {
+			return errors.Wrap(err, "(api.ResetPassword)")
+		}
+
+		return json.NewEncoder(w).Encode(response)
+	}
+
 	sessionToken, err := sessions.Create(s.db, user.ID)
 	if err != nil {
 		return errors.Wrap(err, "(api.ResetPassword) could not create session")
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) RollbackListingRevision(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.RollbackListingRevision) missing listing ID from RollbackListingRevision request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.RollbackListingRevision)")
+	}
+
+	strRevisionId, ok := vars["revisionID"]
+	if !ok {
+		return errors.Newf("(api.RollbackListingRevision) missing revision ID from RollbackListingRevision request URL: %s", r.URL.RequestURI())
+	}
+
+	revisionID, err := strconv.ParseInt(strRevisionId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.RollbackListingRevision)")
+	}
+
+	listing, err := s.loadHostedListing(auth.User, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.RollbackListingRevision)")
+	}
+
+	target, err := listings.LoadRevisionByID(s.db, revisionID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.RollbackListingRevision) loading revision")
+		}
+	}
+
+	if target.ListingID != listing.ID {
+		return errors.NotFound
+	}
+
+	revision, err := listings.RollbackToRevision(s.db, *listing, *target, auth.User.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.RollbackListingRevision) rolling back")
+	}
+
+	updatedListing, err := listings.LoadDetailsByIDAndUser(s.db, listing.ID, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.RollbackListingRevision) loading listing")
+	}
+
+	// Published listings return the preview with the restored content, which still has to be reviewed
+	if revision != nil {
+		return json.NewEncoder(w).Encode(views.ConvertListing(listings.ApplyRevision(*updatedListing, *revision)))
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListing(*updatedListing))
+}
@@ -7,184 +7,385 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/geo"
 	"go.coaster.io/server/common/maps"
 	"go.coaster.io/server/common/models"
-	"go.coaster.io/server/common/repositories/availability_rules"
 	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/repositories/wishlists"
 	"go.coaster.io/server/common/views"
 )
 
+type SearchListingsResponse struct {
+	Listings   []views.Listing `json:"listings"`
+	TotalCount int64           `json:"total_count"`
+	NextCursor *string         `json:"next_cursor"`
+
+	// Only included when requested with facets=true
+	Facets *listings.SearchFacets `json:"facets,omitempty"`
+
+	// Only included for map searches at low zoom levels, in which case no individual listings are returned
+	Clusters []listings.SearchCluster `json:"clusters,omitempty"`
+}
+
 func (s ApiService) SearchListings(w http.ResponseWriter, r *http.Request) error {
 	queryParam := r.URL.Query().Get("query")
 	locationParam := r.URL.Query().Get("location")
+	placeIDParam := r.URL.Query().Get("place_id")
+	sessionTokenParam := r.URL.Query().Get("session_token")
 	radiusParam := r.URL.Query().Get("radius")
 	categoryParam := r.URL.Query().Get("categories")
 
-	var filteredListings []listings.ListingDetails
-	var err error
+	searchParams, err := getPaginationParams(r)
+	if err != nil {
+		return errors.Wrap(err, "(api.SearchListings) parsing pagination params")
+	}
+
+	err = addFilterParams(r, searchParams)
+	if err != nil {
+		return errors.Wrap(err, "(api.SearchListings) parsing filter params")
+	}
+
 	if len(queryParam) > 0 {
 		cleanedQuery := strings.Trim(queryParam, " ")
-		filteredListings, err = listings.LoadListingsForQuery(s.db, cleanedQuery)
+		searchParams.Query = &cleanedQuery
+	}
+
+	if len(placeIDParam) > 0 || len(locationParam) > 0 {
+		err = s.addLocationParams(searchParams, placeIDParam, sessionTokenParam, locationParam, radiusParam)
 		if err != nil {
-			return errors.Wrap(err, "(api.SearchListings) loading listings by query")
+			return errors.Wrap(err, "(api.SearchListings) getting location params")
 		}
-	} else if len(locationParam) > 0 {
-		filteredListings, err = s.loadByLocation(locationParam, radiusParam)
+	}
+
+	if len(categoryParam) > 0 {
+		searchParams.Categories, err = parseCategories(categoryParam)
 		if err != nil {
-			return errors.Wrap(err, "(api.SearchListings) loading listings filtered by location")
+			return errors.Wrap(err, "(api.SearchListings) parsing categories")
 		}
 
-		if len(categoryParam) > 0 {
-			filteredListings, err = s.filterByCategory(filteredListings, categoryParam)
-			if err != nil {
-				return errors.Wrap(err, "(api.SearchListings) filtering listings by category")
-			}
+		// An empty category list with no other search terms is used by the homepage to load featured listings
+		if len(searchParams.Categories) == 0 && searchParams.Query == nil && searchParams.Location == nil {
+			searchParams.Categories = []models.ListingCategoryType{models.CategoryFeatured}
 		}
-	} else if len(categoryParam) > 0 {
-		filteredListings, err = s.loadByCategory(categoryParam)
+	}
+
+	boundsParam := r.URL.Query().Get("bounds")
+	if len(boundsParam) > 0 {
+		searchParams.Bounds, err = parseBounds(boundsParam)
 		if err != nil {
-			return errors.Wrap(err, "(api.SearchListings) loading listings filtered by category")
+			return errors.Wrap(err, "(api.SearchListings) parsing bounds")
 		}
-	} else {
-		// TODO: paginate this eventually
-		filteredListings, err = listings.LoadAllPublished(s.db)
+
+		zoom, err := parseOptionalInt(r, "zoom")
+		if err != nil {
+			return errors.Wrap(err, "(api.SearchListings) parsing zoom")
+		}
+
+		if zoom != nil && listings.ShouldCluster(int(*zoom)) {
+			return s.searchListingClusters(w, r, *searchParams, int(*zoom))
+		}
+	}
+
+	searchResults, err := listings.Search(s.db, *searchParams)
+	if err != nil {
+		return errors.Wrap(err, "(api.SearchListings) searching listings")
+	}
+
+	var facets *listings.SearchFacets
+	if r.URL.Query().Get("facets") == "true" {
+		facets, err = listings.LoadSearchFacets(s.db, *searchParams)
+		if err != nil {
+			return errors.Wrap(err, "(api.SearchListings) loading facets")
+		}
+	}
+
+	auth, err := s.getOptionalAuthentication(r)
+	if err != nil {
+		return errors.Wrap(err, "(api.SearchListings) unexpected authentication error")
+	}
+
+	err = s.localizeListings(r, searchResults.Listings)
+	if err != nil {
+		return errors.Wrap(err, "(api.SearchListings) localizing listings")
+	}
+
+	listingViews := convertSearchResults(*searchResults)
+	err = s.markSavedListings(auth.User, listingViews)
+	if err != nil {
+		return errors.Wrap(err, "(api.SearchListings) marking saved listings")
+	}
+
+	return json.NewEncoder(w).Encode(SearchListingsResponse{
+		Listings:   listingViews,
+		TotalCount: searchResults.TotalCount,
+		NextCursor: searchResults.NextCursor,
+		Facets:     facets,
+	})
+}
+
+func (s ApiService) searchListingClusters(w http.ResponseWriter, r *http.Request, searchParams listings.SearchParams, zoom int) error {
+	clusters, err := listings.LoadSearchClusters(s.db, searchParams, zoom)
+	if err != nil {
+		return errors.Wrap(err, "(api.searchListingClusters) loading clusters")
+	}
+
+	var facets *listings.SearchFacets
+	if r.URL.Query().Get("facets") == "true" {
+		facets, err = listings.LoadSearchFacets(s.db, searchParams)
 		if err != nil {
-			return errors.Wrap(err, "(api.SearchListings) getting all published listings")
+			return errors.Wrap(err, "(api.searchListingClusters) loading facets")
 		}
 	}
 
-	startDateParam := r.URL.Query().Get("start_date")
-	endDateParam := r.URL.Query().Get("end_date")
-	filteredListings, err = s.filterByAvailability(filteredListings, startDateParam, endDateParam)
+	totalCount := int64(0)
+	for _, cluster := range clusters {
+		totalCount += cluster.Count
+	}
+
+	return json.NewEncoder(w).Encode(SearchListingsResponse{
+		Listings:   []views.Listing{},
+		TotalCount: totalCount,
+		Facets:     facets,
+		Clusters:   clusters,
+	})
+}
+
+func convertSearchResults(searchResults listings.SearchResults) []views.Listing {
+	listingViews := views.ConvertListings(searchResults.Listings)
+	for i := range listingViews {
+		// Snippets are taken from the source text, so leave them out rather than mix languages
+		if snippet, ok := searchResults.Snippets[listingViews[i].ID]; ok && listingViews[i].Locale == listingViews[i].SourceLocale {
+			listingViews[i].Snippet = &snippet
+		}
+
+		if distance, ok := searchResults.Distances[listingViews[i].ID]; ok {
+			listingViews[i].DistanceMeters = &distance
+		}
+	}
+
+	return listingViews
+}
+
+// Sets the saved flag on each listing based on the user's wishlists. No-op for anonymous users.
+func (s ApiService) markSavedListings(user *models.User, listingViews []views.Listing) error {
+	if user == nil {
+		return nil
+	}
+
+	listingIDs := make([]int64, len(listingViews))
+	for i, listing := range listingViews {
+		listingIDs[i] = listing.ID
+	}
+
+	savedListingIDs, err := wishlists.LoadSavedListingIDs(s.db, user.ID, listingIDs)
 	if err != nil {
-		return errors.Wrap(err, "(api.SearchListings) getting listings filtered by availability")
+		return errors.Wrap(err, "(api.markSavedListings) loading saved listing IDs")
+	}
+
+	for i := range listingViews {
+		listingViews[i].Saved = savedListingIDs[listingViews[i].ID]
 	}
 
-	return json.NewEncoder(w).Encode(views.ConvertListings(filteredListings))
+	return nil
 }
 
-func (s ApiService) loadByLocation(locationParam string, radiusParam string) ([]listings.ListingDetails, error) {
+func getPaginationParams(r *http.Request) (*listings.SearchParams, error) {
+	searchParams := listings.SearchParams{
+		Sort: listings.SearchSort(r.URL.Query().Get("sort")),
+	}
+
+	limitParam := r.URL.Query().Get("limit")
+	if len(limitParam) > 0 {
+		limit, err := strconv.Atoi(limitParam)
+		if err != nil {
+			return nil, errors.NewBadRequestf("Invalid limit: %s", limitParam)
+		}
+
+		searchParams.Limit = limit
+	}
+
+	cursorParam := r.URL.Query().Get("cursor")
+	if len(cursorParam) > 0 {
+		searchParams.Cursor = &cursorParam
+	}
+
+	return &searchParams, nil
+}
+
+// A place ID chosen from autocomplete takes precedence over free text, since free text is resolved to the first match.
+// The autocomplete session token comes with it so looking up the place ends that session.
+func (s ApiService) addLocationParams(searchParams *listings.SearchParams, placeIDParam string, sessionTokenParam string, locationParam string, radiusParam string) error {
 	var radius int64
 	var err error
 	if len(radiusParam) > 0 {
 		radius, err = strconv.ParseInt(radiusParam, 10, 64)
 		if err != nil {
-			return nil, errors.Wrap(err, "(api.getListingsForLocation) converting radius")
+			return errors.Wrap(err, "(api.addLocationParams) converting radius")
 		}
 	} else {
 		radius = 100_000 // 100km default radius
 	}
 
-	place, err := maps.GetPlaceFromQuery(locationParam)
-	if err != nil {
-		return nil, errors.Wrap(err, "(api.getListingsForLocation) getting location from query")
+	var place *maps.Place
+	if len(placeIDParam) > 0 {
+		if len(sessionTokenParam) > 0 {
+			_, err = uuid.Parse(sessionTokenParam)
+			if err != nil {
+				return errors.NewBadRequestf("Invalid session_token: %s", sessionTokenParam)
+			}
+		}
+
+		place, err = s.geocoder.GetPlace(placeIDParam, sessionTokenParam)
+		if err != nil {
+			return errors.Wrap(err, "(api.addLocationParams) getting location from place ID")
+		}
+	} else {
+		place, err = s.geocoder.GetPlaceFromQuery(locationParam)
+		if err != nil {
+			return errors.Wrap(err, "(api.addLocationParams) getting location from query")
+		}
 	}
 
-	return listings.LoadListingsWithinRadius(
-		s.db,
-		place.Coordinates,
-		radius,
-	)
+	searchParams.Location = &place.Coordinates
+	searchParams.Radius = radius
+
+	return nil
 }
 
-func (s ApiService) loadByCategory(categoryParam string) ([]listings.ListingDetails, error) {
+func parseCategories(categoryParam string) ([]models.ListingCategoryType, error) {
 	var categories []models.ListingCategoryType
 	err := json.Unmarshal([]byte(categoryParam), &categories)
 	if err != nil {
-		return nil, errors.Wrap(err, "(api.filterByCategory) unmarshalling categories")
+		return nil, errors.Wrap(err, "(api.parseCategories) unmarshalling categories")
 	}
 
-	if len(categories) == 0 {
-		return listings.LoadFeatured(s.db)
+	return categories, nil
+}
+
+func addFilterParams(r *http.Request, searchParams *listings.SearchParams) error {
+	var err error
+	searchParams.MinPrice, err = parseOptionalInt(r, "min_price")
+	if err != nil {
+		return err
 	}
 
-	return listings.LoadListingsByCategory(
-		s.db,
-		categories,
-	)
-}
+	searchParams.MaxPrice, err = parseOptionalInt(r, "max_price")
+	if err != nil {
+		return err
+	}
 
-func (s ApiService) filterByCategory(unfiltered []listings.ListingDetails, categoryParam string) ([]listings.ListingDetails, error) {
-	var categories []models.ListingCategory
-	err := json.Unmarshal([]byte(categoryParam), &categories)
+	searchParams.Guests, err = parseOptionalInt(r, "guests")
 	if err != nil {
-		return nil, errors.Wrap(err, "(api.filterByCategory) unmarshalling categories")
+		return err
 	}
 
-	if len(categories) == 0 {
-		return unfiltered, nil
+	searchParams.MinDuration, err = parseOptionalInt(r, "min_duration")
+	if err != nil {
+		return err
 	}
 
-	var filteredByCategory []listings.ListingDetails
-	for _, listing := range unfiltered {
-		for _, category := range categories {
-			for _, listingCategory := range listing.Categories {
-				if listingCategory == category {
-					filteredByCategory = append(filteredByCategory, listing)
-				}
-			}
-		}
+	searchParams.MaxDuration, err = parseOptionalInt(r, "max_duration")
+	if err != nil {
+		return err
+	}
+
+	searchParams.Countries, err = parseOptionalStrings(r, "countries")
+	if err != nil {
+		return err
+	}
+
+	searchParams.Regions, err = parseOptionalStrings(r, "regions")
+	if err != nil {
+		return err
 	}
 
-	return filteredByCategory, nil
+	searchParams.StartDate, err = parseOptionalDate(r, "start_date")
+	if err != nil {
+		return err
+	}
+
+	searchParams.EndDate, err = parseOptionalDate(r, "end_date")
+	if err != nil {
+		return err
+	}
+
+	return nil
 }
 
-func (s ApiService) filterByAvailability(unfiltered []listings.ListingDetails, startDateParam string, endDateParam string) ([]listings.ListingDetails, error) {
-	var startDate, endDate time.Time
-	var err error
-	if len(startDateParam) == 0 {
-		return unfiltered, nil
-	} else {
-		startDate, err = time.Parse(time.DateOnly, startDateParam)
-		if err != nil {
-			return nil, errors.Wrap(err, "(api.filterByAvailability) parsing start date")
-		}
+func parseOptionalInt(r *http.Request, param string) (*int64, error) {
+	value := r.URL.Query().Get(param)
+	if len(value) == 0 {
+		return nil, nil
 	}
 
-	if len(endDateParam) == 0 {
-		endDate = startDate
-	} else {
-		endDate, err = time.Parse(time.DateOnly, endDateParam)
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, errors.NewBadRequestf("Invalid %s: %s", param, value)
+	}
+
+	return &parsed, nil
+}
+
+// Expects "west,south,east,north" in degrees
+func parseBounds(boundsParam string) (*geo.BoundingBox, error) {
+	parts := strings.Split(boundsParam, ",")
+	if len(parts) != 4 {
+		return nil, errors.NewBadRequestf("Invalid bounds: %s", boundsParam)
+	}
+
+	values := make([]float64, len(parts))
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
 		if err != nil {
-			return nil, errors.Wrap(err, "(api.filterByAvailability) parsing end date")
+			return nil, errors.NewBadRequestf("Invalid bounds: %s", boundsParam)
 		}
+
+		values[i] = value
 	}
 
-	if startDate.Before(time.Now()) {
-		return nil, errors.New("(api.filterByAvailability) start date must be in the future")
+	bounds := geo.BoundingBox{
+		West:  values[0],
+		South: values[1],
+		East:  values[2],
+		North: values[3],
 	}
 
-	if endDate.Before(time.Now()) {
-		return nil, errors.New("(api.filterByAvailability) end date must be in the future")
+	err := bounds.Validate()
+	if err != nil {
+		return nil, errors.NewBadRequestf("Invalid bounds: %s", err.Error())
 	}
 
-	if endDate.Before(startDate) {
-		return nil, errors.New("(api.filterByAvailability) end date must be after start date")
+	return &bounds, nil
+}
+
+// Expects a JSON array, like the categories param
+func parseOptionalStrings(r *http.Request, param string) ([]string, error) {
+	value := r.URL.Query().Get(param)
+	if len(value) == 0 {
+		return nil, nil
 	}
 
-	var filteredByAvailability []listings.ListingDetails
-	for _, listing := range unfiltered {
-		availabilityRules, err := availability_rules.LoadForListing(
-			s.db,
-			listing.ID,
-		)
-		if err != nil {
-			return nil, errors.Wrap(err, "(api.filterByAvailability) loading availability for listing")
-		}
+	var parsed []string
+	err := json.Unmarshal([]byte(value), &parsed)
+	if err != nil {
+		return nil, errors.NewBadRequestf("Invalid %s: %s", param, value)
+	}
 
-		for _, rule := range availabilityRules {
-			hasAvailability, err := rule.HasAvailabilityInRange(s.db, startDate, endDate, listing.Listing)
-			if err != nil {
-				return nil, errors.Wrap(err, "(api.filterByAvailability) checking availability for rule")
-			}
+	return parsed, nil
+}
 
-			if hasAvailability {
-				filteredByAvailability = append(filteredByAvailability, listing)
-				break
-			}
-		}
+func parseOptionalDate(r *http.Request, param string) (*time.Time, error) {
+	value := r.URL.Query().Get(param)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, errors.NewBadRequestf("Invalid %s: %s", param, value)
 	}
 
-	return filteredByAvailability, nil
+	return &parsed, nil
 }
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"time"
+
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/emails"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/email_verification_tokens"
+)
+
+type EmailVerificationTemplateArgs struct {
+	FirstName string
+	Token     string
+	Domain    string
+}
+
+func (s ApiService) SendEmailVerification(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	if auth.User.EmailVerified {
+		return errors.NewBadRequest("Your email is already verified")
+	}
+
+	sent, err := email_verification_tokens.LoadSentSince(s.db, auth.User.ID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return errors.Wrap(err, "(api.SendEmailVerification) loading sent tokens")
+	}
+
+	// Sorted newest first
+	if len(sent) > 0 && time.Since(sent[0].CreatedAt) < email_verification_tokens.RESEND_COOLDOWN {
+		return errors.NewTooManyRequests("Please wait a minute before requesting another email")
+	}
+
+	if len(sent) >= email_verification_tokens.MAX_SENDS_PER_DAY {
+		return errors.NewTooManyRequests("Too many verification emails sent, please try again tomorrow")
+	}
+
+	err = s.sendVerificationEmail(auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.SendEmailVerification)")
+	}
+
+	return nil
+}
+
+func (s ApiService) sendVerificationEmail(user *models.User) error {
+	token, err := email_verification_tokens.Create(s.db, user)
+	if err != nil {
+		return errors.Wrap(err, "(api.sendVerificationEmail) creating token")
+	}
+
+	var domain string
+	if application.IsProd() {
+		domain = "https://www.trycoaster.com"
+	} else {
+		domain = "http://localhost:3000"
+	}
+
+	templateArgs := EmailVerificationTemplateArgs{
+		FirstName: user.FirstName,
+		Token:     *token,
+		Domain:    domain,
+	}
+
+	var html bytes.Buffer
+	EMAIL_VERIFICATION_TEMPLATE.Execute(&html, templateArgs)
+
+	var plain bytes.Buffer
+	EMAIL_VERIFICATION_PLAIN_TEMPLATE.Execute(&plain, templateArgs)
+
+	err = emails.SendEmail("Coaster <support@trycoaster.com>", user.Email, "Verify your email", html.String(), plain.String())
+	if err != nil {
+		return errors.Wrap(err, "(api.sendVerificationEmail) sending email")
+	}
+
+	return nil
+}
+
+// Returns an error for unverified users when the setting requires a verified email for this action
+func requireVerifiedEmail(user *models.User, required bool) error {
+	if required && !user.EmailVerified {
+		return errors.NewForbidden("Please verify your email address first")
+	}
+
+	return nil
+}
+
+var EMAIL_VERIFICATION_TEMPLATE = template.Must(template.New("email_verification").Parse(EMAIL_VERIFICATION_TEMPLATE_STRING))
+
+const EMAIL_VERIFICATION_TEMPLATE_STRING = `
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<meta http-equiv="Content-Type" content="text/html charset=UTF-8" />
+<html lang="en">
+
+  <head></head>
+  <div id="email-preview" style="display:none;overflow:hidden;line-height:1px;opacity:0;max-height:0;max-width:0">
+		Verify your email for Coaster
+  </div>
+
+  <body style="background-color:#f6f9fc;padding:10px 0">
+    <table align="center" role="presentation" cellSpacing="0" cellPadding="0" border="0" width="100%" style="max-width:37.5em;background-color:#ffffff;border:1px solid #f0f0f0;padding:45px">
+      <tr style="width:100%">
+        <td><img alt="Coaster" src="https://www.trycoaster.com/long-logo.png" height="40" style="display:block;outline:none;border:none;text-decoration:none" />
+          <table align="center" border="0" cellPadding="0" cellSpacing="0" role="presentation" width="100%">
+            <tbody>
+              <tr>
+                <td>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Hi {{.FirstName}},</p>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Thanks for signing up for Coaster! Please confirm this is your email address:</p>
+									<a href="{{.Domain}}/verify-email?token={{.Token}}" target="_blank" style="background-color:#3673aa;border-radius:4px;color:#fff;font-family:&#x27;Open Sans&#x27;, &#x27;Helvetica Neue&#x27;, Arial;font-size:15px;text-decoration:none;text-align:center;display:inline-block;width:210px;padding:0px 0px;line-height:100%;max-width:100%"><span><!--[if mso]><i style="letter-spacing: undefinedpx;mso-font-width:-100%;mso-text-raise:0" hidden>&nbsp;</i><![endif]--></span><span style="background-color:#3673aa;border-radius:4px;color:#fff;font-family:&#x27;Open Sans&#x27;, &#x27;Helvetica Neue&#x27;, Arial;font-size:15px;text-decoration:none;text-align:center;display:inline-block;width:210px;padding:14px 7px;max-width:100%;line-height:120%;text-transform:none;mso-padding-alt:0px;mso-text-raise:0">Verify email</span><span><!--[if mso]><i style="letter-spacing: undefinedpx;mso-font-width:-100%" hidden>&nbsp;</i><![endif]--></span></a>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">If you didn&#x27;t create a Coaster account, just ignore and delete this message.</p>
+                </td>
+              </tr>
+            </tbody>
+          </table>
+        </td>
+      </tr>
+    </table>
+		<div style="width:100%;text-align:center;color:#404040;margin-top:12px;font-size:14px">Coaster, 2261 Market Street STE 5450, San Francisco, CA 94114</div>
+  </body>
+
+</html>
+`
+
+var EMAIL_VERIFICATION_PLAIN_TEMPLATE = template.Must(template.New("email_verification_plain").Parse(EMAIL_VERIFICATION_PLAIN_TEMPLATE_STRING))
+
+const EMAIL_VERIFICATION_PLAIN_TEMPLATE_STRING = `
+	Hi {{.FirstName}},
+
+	Thanks for signing up for Coaster! Please confirm this is your email address: {{.Domain}}/verify-email?token={{.Token}}
+
+	If you didn't create a Coaster account, just ignore and delete this message.
+
+	Coaster, 2261 Market Street STE 5450, San Francisco, CA 94114
+`
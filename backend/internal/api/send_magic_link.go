@@ -0,0 +1,168 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/emails"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/login_tokens"
+	"go.coaster.io/server/common/repositories/users"
+)
+
+type SendMagicLinkRequest struct {
+	Email  string `json:"email" validate:"required,email"`
+	Origin string `json:"origin" validate:"required"`
+}
+
+type MagicLinkTemplateArgs struct {
+	FirstName string
+	Token     string
+	Origin    string
+}
+
+// Responds the same way whether or not there's an account for the email, so it can't be used to
+// find out who has one
+func (s ApiService) SendMagicLink(w http.ResponseWriter, r *http.Request) error {
+	decoder := json.NewDecoder(r.Body)
+	var sendMagicLinkRequest SendMagicLinkRequest
+	err := decoder.Decode(&sendMagicLinkRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.SendMagicLink) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(sendMagicLinkRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.SendMagicLink) validating request")
+	}
+
+	if !isOriginAllowed(sendMagicLinkRequest.Origin) {
+		return errors.Wrapf(errors.BadRequest, "(api.SendMagicLink) origin not allowed: %s", sendMagicLinkRequest.Origin)
+	}
+
+	// Set whether or not the account exists, so the response doesn't give it away
+	deviceNonce, err := login_tokens.GenerateDeviceNonce()
+	if err != nil {
+		return errors.Wrap(err, "(api.SendMagicLink)")
+	}
+	auth.AddLoginNonceCookie(w, deviceNonce)
+
+	email := strings.ToLower(sendMagicLinkRequest.Email)
+	user, err := users.LoadByEmail(s.db, email)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return nil
+		}
+
+		return errors.Wrap(err, "(api.SendMagicLink) loading user by email")
+	}
+
+	if user.Blocked {
+		return nil
+	}
+
+	recentlySent, err := login_tokens.WasSentSince(s.db, user.ID, time.Now().Add(-login_tokens.SEND_COOLDOWN))
+	if err != nil {
+		return errors.Wrap(err, "(api.SendMagicLink) checking for recent links")
+	}
+
+	if recentlySent {
+		return nil
+	}
+
+	token, err := login_tokens.Create(s.db, user.ID, sendMagicLinkRequest.Origin, deviceNonce)
+	if err != nil {
+		return errors.Wrap(err, "(api.SendMagicLink) creating token")
+	}
+
+	// Sent in the background so the response doesn't take noticeably longer when the account exists
+	go func() {
+		err := sendMagicLinkEmail(*user, *token, sendMagicLinkRequest.Origin)
+		if err != nil {
+			log.Printf("(api.SendMagicLink) sending email for user %d: %+v", user.ID, err)
+		}
+	}()
+
+	return nil
+}
+
+func sendMagicLinkEmail(user models.User, token string, origin string) error {
+	templateArgs := MagicLinkTemplateArgs{
+		FirstName: user.FirstName,
+		Token:     token,
+		Origin:    origin,
+	}
+
+	var html bytes.Buffer
+	MAGIC_LINK_TEMPLATE.Execute(&html, templateArgs)
+
+	var plain bytes.Buffer
+	MAGIC_LINK_PLAIN_TEMPLATE.Execute(&plain, templateArgs)
+
+	err := emails.SendEmail("Coaster <support@trycoaster.com>", user.Email, "Your Coaster login link", html.String(), plain.String())
+	if err != nil {
+		return errors.Wrap(err, "(api.sendMagicLinkEmail) sending email")
+	}
+
+	return nil
+}
+
+var MAGIC_LINK_TEMPLATE = template.Must(template.New("magic_link").Parse(MAGIC_LINK_TEMPLATE_STRING))
+
+const MAGIC_LINK_TEMPLATE_STRING = `
+<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">
+<meta http-equiv="Content-Type" content="text/html charset=UTF-8" />
+<html lang="en">
+
+  <head></head>
+  <div id="email-preview" style="display:none;overflow:hidden;line-height:1px;opacity:0;max-height:0;max-width:0">
+		Your Coaster login link
+  </div>
+
+  <body style="background-color:#f6f9fc;padding:10px 0">
+    <table align="center" role="presentation" cellSpacing="0" cellPadding="0" border="0" width="100%" style="max-width:37.5em;background-color:#ffffff;border:1px solid #f0f0f0;padding:45px">
+      <tr style="width:100%">
+        <td><img alt="Coaster" src="https://www.trycoaster.com/long-logo.png" height="40" style="display:block;outline:none;border:none;text-decoration:none" />
+          <table align="center" border="0" cellPadding="0" cellSpacing="0" role="presentation" width="100%">
+            <tbody>
+              <tr>
+                <td>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Hi {{.FirstName}},</p>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">Here&#x27;s your link to log in to Coaster. It expires in 15 minutes, can only be used once and only works in the browser you requested it from.</p>
+									<a href="{{.Origin}}/magic-login?token={{.Token}}" target="_blank" style="background-color:#3673aa;border-radius:4px;color:#fff;font-family:&#x27;Open Sans&#x27;, &#x27;Helvetica Neue&#x27;, Arial;font-size:15px;text-decoration:none;text-align:center;display:inline-block;width:210px;padding:0px 0px;line-height:100%;max-width:100%"><span><!--[if mso]><i style="letter-spacing: undefinedpx;mso-font-width:-100%;mso-text-raise:0" hidden>&nbsp;</i><![endif]--></span><span style="background-color:#3673aa;border-radius:4px;color:#fff;font-family:&#x27;Open Sans&#x27;, &#x27;Helvetica Neue&#x27;, Arial;font-size:15px;text-decoration:none;text-align:center;display:inline-block;width:210px;padding:14px 7px;max-width:100%;line-height:120%;text-transform:none;mso-padding-alt:0px;mso-text-raise:0">Log in</span><span><!--[if mso]><i style="letter-spacing: undefinedpx;mso-font-width:-100%" hidden>&nbsp;</i><![endif]--></span></a>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">If you didn&#x27;t try to log in, just ignore and delete this message.</p>
+                  <p style="font-size:16px;line-height:26px;margin:16px 0;font-family:&#x27;Open Sans&#x27;, &#x27;HelveticaNeue-Light&#x27;, &#x27;Helvetica Neue Light&#x27;, &#x27;Helvetica Neue&#x27;, Helvetica, Arial, &#x27;Lucida Grande&#x27;, sans-serif;font-weight:300;color:#404040">To keep your account secure, please don&#x27;t forward this email to anyone.</p>
+                </td>
+              </tr>
+            </tbody>
+          </table>
+        </td>
+      </tr>
+    </table>
+		<div style="width:100%;text-align:center;color:#404040;margin-top:12px;font-size:14px">Coaster, 2261 Market Street STE 5450, San Francisco, CA 94114</div>
+  </body>
+
+</html>
+`
+
+var MAGIC_LINK_PLAIN_TEMPLATE = template.Must(template.New("magic_link_plain").Parse(MAGIC_LINK_PLAIN_TEMPLATE_STRING))
+
+const MAGIC_LINK_PLAIN_TEMPLATE_STRING = `
+	Hi {{.FirstName}},
+
+	Here's your link to log in to Coaster. It expires in 15 minutes, can only be used once and only works in the browser you requested it from: {{.Origin}}/magic-login?token={{.Token}}
+
+	If you didn't try to log in, just ignore and delete this message.
+	To keep your account secure, please don't forward this email to anyone.
+
+	Coaster, 2261 Market Street STE 5450, San Francisco, CA 94114
+`
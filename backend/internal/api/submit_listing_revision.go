@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/application"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+func (s ApiService) SubmitListingRevision(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.SubmitListingRevision) missing listing ID from SubmitListingRevision request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.SubmitListingRevision)")
+	}
+
+	listing, err := s.loadHostedListing(auth.User, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.SubmitListingRevision)")
+	}
+
+	err = requireVerifiedEmail(auth.User, application.RequireVerifiedEmailForHosting())
+	if err != nil {
+		return errors.Wrap(err, "(api.SubmitListingRevision)")
+	}
+
+	revision, err := listings.LoadOpenRevision(s.db, listing.ID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.SubmitListingRevision) loading revision")
+		}
+	}
+
+	err = listings.SubmitRevision(s.db, revision)
+	if err != nil {
+		return errors.Wrap(err, "(api.SubmitListingRevision) submitting revision")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertListingRevision(*revision, nil))
+}
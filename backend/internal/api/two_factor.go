@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/recovery_codes"
+	"go.coaster.io/server/common/repositories/sessions"
+	"go.coaster.io/server/common/repositories/two_factor_challenges"
+	"go.coaster.io/server/common/repositories/users"
+	"go.coaster.io/server/common/totp"
+	"go.coaster.io/server/common/views"
+)
+
+// Finishes the first login step. Users with two-factor get a challenge to send back with their code
+// instead of a session.
+func (s ApiService) logIn(w http.ResponseWriter, user *models.User) (*EmailLoginResponse, error) {
+	if user.HasTwoFactor() {
+		challenge, err := two_factor_challenges.Create(s.db, user.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "(api.logIn) creating two-factor challenge")
+		}
+
+		return &EmailLoginResponse{TwoFactorChallenge: challenge}, nil
+	}
+
+	sessionToken, err := sessions.Create(s.db, user.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "(api.logIn) could not create session")
+	}
+
+	auth.AddSessionCookie(w, *sessionToken)
+
+	userView := views.ConvertUser(*user)
+	return &EmailLoginResponse{User: &userView}, nil
+}
+
+// Checks a TOTP code, or a recovery code if one is given. Each code only works once, and each user
+// only gets a few tries per window however they're made.
+func (s ApiService) checkSecondFactor(ctx context.Context, user *models.User, code string, recoveryCode string) (bool, error) {
+	if !user.HasTwoFactor() {
+		return false, errors.Newf("(api.checkSecondFactor) user %d doesn't have two-factor enabled", user.ID)
+	}
+
+	allowed, err := users.ReserveTwoFactorAttempt(s.db, user)
+	if err != nil {
+		return false, errors.Wrap(err, "(api.checkSecondFactor)")
+	}
+
+	if !allowed {
+		return false, errors.Wrap(errors.NewTooManyRequests("Too many attempts. Please wait a while and try again."), "(api.checkSecondFactor)")
+	}
+
+	var valid bool
+	if recoveryCode != "" {
+		valid, err = recovery_codes.Use(s.db, user.ID, recoveryCode)
+		if err != nil {
+			return false, errors.Wrap(err, "(api.checkSecondFactor) using recovery code")
+		}
+	} else {
+		valid, err = s.checkTOTPCode(ctx, user, code)
+		if err != nil {
+			return false, errors.Wrap(err, "(api.checkSecondFactor)")
+		}
+	}
+
+	if valid {
+		err = users.ClearTwoFactorAttempts(s.db, user)
+		if err != nil {
+			return false, errors.Wrap(err, "(api.checkSecondFactor)")
+		}
+	}
+
+	return valid, nil
+}
+
+func (s ApiService) checkTOTPCode(ctx context.Context, user *models.User, code string) (bool, error) {
+	if user.TOTPSecret == nil {
+		return false, errors.Newf("(api.checkTOTPCode) user %d has no TOTP secret", user.ID)
+	}
+
+	secret, err := totp.DecryptSecret(ctx, *user.TOTPSecret)
+	if err != nil {
+		return false, errors.Wrap(err, "(api.checkTOTPCode)")
+	}
+
+	step, valid := totp.Validate(secret, code, time.Now())
+	if !valid {
+		return false, nil
+	}
+
+	fresh, err := users.UseTOTPStep(s.db, user, step)
+	if err != nil {
+		return false, errors.Wrap(err, "(api.checkTOTPCode)")
+	}
+
+	return fresh, nil
+}
+
+// For endpoints that control money or account security. Clients prompt the user to reauthenticate
+// and retry when they get errors.ReauthenticationRequired.
+func requireRecentAuthentication(auth auth.Authentication) error {
+	if !auth.IsRecentlyAuthenticated() {
+		return errors.ReauthenticationRequired
+	}
+
+	return nil
+}
+
+// For routes that work without logging in but show more to some users. The router only enforces
+// two-factor on authenticated routes, so admins who haven't used it in this session get what any
+// other user would.
+func (s ApiService) getOptionalAuthentication(r *http.Request) (*auth.Authentication, error) {
+	authentication, err := s.authService.GetAuthentication(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "(api.getOptionalAuthentication)")
+	}
+
+	if authentication.NeedsTwoFactor() {
+		user := *authentication.User
+		user.IsAdmin = false
+		authentication.User = &user
+	}
+
+	return authentication, nil
+}
@@ -0,0 +1,58 @@
+package api_test
+
+import (
+	"go.coaster.io/server/common/models"
+	"go.coaster.io/server/common/repositories/users"
+	"go.coaster.io/server/common/test"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// checkTOTPCode only accepts a code if its step is newer than the last one used
+var _ = Describe("TOTP replay protection", func() {
+	It("only accepts each step once, in order", func() {
+		user := test.CreateUser(db)
+
+		fresh, err := users.UseTOTPStep(db, user, 100)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fresh).To(BeTrue())
+		Expect(*user.TOTPLastUsedStep).To(Equal(int64(100)))
+
+		fresh, err = users.UseTOTPStep(db, user, 100)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fresh).To(BeFalse())
+
+		fresh, err = users.UseTOTPStep(db, user, 99)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fresh).To(BeFalse())
+
+		fresh, err = users.UseTOTPStep(db, user, 101)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fresh).To(BeTrue())
+		Expect(*user.TOTPLastUsedStep).To(Equal(int64(101)))
+	})
+})
+
+var _ = Describe("Two-factor attempt limit", func() {
+	It("stops allowing code checks once the user is out of attempts", func() {
+		user := &models.User{FirstName: "Limited", LastName: "User", Email: "limited@trycoaster.com"}
+		Expect(db.Create(user).Error).NotTo(HaveOccurred())
+
+		for i := 0; i < users.MAX_TWO_FACTOR_ATTEMPTS; i++ {
+			allowed, err := users.ReserveTwoFactorAttempt(db, user)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(allowed).To(BeTrue())
+		}
+
+		allowed, err := users.ReserveTwoFactorAttempt(db, user)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowed).To(BeFalse())
+
+		Expect(users.ClearTwoFactorAttempts(db, user)).To(Succeed())
+
+		allowed, err = users.ReserveTwoFactorAttempt(db, user)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowed).To(BeTrue())
+	})
+})
@@ -7,10 +7,11 @@ import (
 
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/application"
 	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/errors"
 	"go.coaster.io/server/common/input"
-	"go.coaster.io/server/common/maps"
+	"go.coaster.io/server/common/models"
 	"go.coaster.io/server/common/repositories/listings"
 	"go.coaster.io/server/common/views"
 )
@@ -48,8 +49,19 @@ func (s ApiService) UpdateListing(auth auth.Authentication, w http.ResponseWrite
 		return errors.Wrapf(err, "(api.UpdateListing) loading listing %d for user %d", listingID, auth.User.ID)
 	}
 
+	if listing.UserID != auth.User.ID && !auth.User.IsAdmin {
+		return errors.NotFound
+	}
+
+	if updateListingRequest.Status != nil && *updateListingRequest.Status == models.ListingStatusUnderReview {
+		err = requireVerifiedEmail(auth.User, application.RequireVerifiedEmailForHosting())
+		if err != nil {
+			return errors.Wrap(err, "(api.UpdateListing)")
+		}
+	}
+
 	if updateListingRequest.Location != nil {
-		place, err := maps.GetPlaceFromQuery(*updateListingRequest.Location)
+		place, err := s.geocoder.GetPlaceFromQuery(*updateListingRequest.Location)
 		if err != nil {
 			return errors.Wrapf(err, "(api.UpdateListing) getting location from query for %s", *updateListingRequest.Location)
 		}
@@ -59,7 +71,7 @@ func (s ApiService) UpdateListing(auth auth.Authentication, w http.ResponseWrite
 		updateListingRequest.Coordinates = &place.Coordinates
 		updateListingRequest.PlaceID = &place.PlaceID
 
-		placeDetails, err := maps.GetPlaceDetails(place.PlaceID, place.Coordinates)
+		placeDetails, err := s.geocoder.GetPlaceDetails(place.PlaceID, place.Coordinates)
 		if err != nil {
 			return errors.Wrap(err, "(api.UpdateListing) getting place details")
 		}
@@ -70,10 +82,28 @@ func (s ApiService) UpdateListing(auth auth.Authentication, w http.ResponseWrite
 		updateListingRequest.PostalCode = placeDetails.PostalCode
 	}
 
+	// Edits to a live listing go to a revision that has to be reviewed before it's published. Taking
+	// the listing offline still applies right away.
+	unpublishing := updateListingRequest.Status != nil && *updateListingRequest.Status == models.ListingStatusDraft
+	if listing.Status == models.ListingStatusPublished && !unpublishing {
+		liveListing, err := listings.LoadDetailsByIDAndUser(s.db, listingID, auth.User)
+		if err != nil {
+			return errors.Wrap(err, "(api.UpdateListing) loading listing details")
+		}
+
+		revision, err := listings.UpdateRevision(s.db, *liveListing, updateListingRequest, auth.User.ID)
+		if err != nil {
+			return errors.Wrap(err, "(api.UpdateListing) updating revision")
+		}
+
+		return json.NewEncoder(w).Encode(views.ConvertListing(listings.ApplyRevision(*liveListing, *revision)))
+	}
+
 	listingDetails, err := listings.UpdateListing(
 		s.db,
 		listing,
 		updateListingRequest,
+		auth.User.ID,
 	)
 	if err != nil {
 		return errors.Wrap(err, "(api.UpdateListing) updating listing")
@@ -42,12 +42,14 @@ func (s ApiService) UpdateListingImages(auth auth.Authentication, w http.Respons
 		return errors.Wrapf(err, "(api.DeleteListingImage) loading listing %d for user %d", listingID, auth.User.ID)
 	}
 
-	// TODO: do this transactionally or figure out something to handle failures
-	for idx, image := range updateListingImagesRequest.Images {
-		err = listings.UpdateImageRank(s.db, listingID, image.ID, idx)
-		if err != nil {
-			return errors.Wrapf(err, "(api.DeleteListingImage) updating image %d for listing %d", image.ID, listingID)
-		}
+	imageIDs := make([]int64, len(updateListingImagesRequest.Images))
+	for i, image := range updateListingImagesRequest.Images {
+		imageIDs[i] = image.ID
+	}
+
+	err = listings.ReorderImages(s.db, listingID, imageIDs)
+	if err != nil {
+		return errors.Wrapf(err, "(api.UpdateListingImages) reordering images for listing %d", listingID)
 	}
 
 	listingDetails, err := listings.LoadDetailsByIDAndUser(
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/views"
+)
+
+type UpdateListingTranslationRequest = input.ListingTranslation
+
+func (s ApiService) UpdateListingTranslation(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.UpdateListingTranslation) missing listing ID from UpdateListingTranslation request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateListingTranslation)")
+	}
+
+	locale, ok := vars["locale"]
+	if !ok {
+		return errors.Newf("(api.UpdateListingTranslation) missing locale from UpdateListingTranslation request URL: %s", r.URL.RequestURI())
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var updateListingTranslationRequest UpdateListingTranslationRequest
+	err = decoder.Decode(&updateListingTranslationRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateListingTranslation) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(updateListingTranslationRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateListingTranslation) validating request")
+	}
+
+	listing, err := s.loadHostedListing(auth.User, listingID)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateListingTranslation)")
+	}
+
+	translation, err := listings.UpdateTranslation(s.db, *listing, locale, updateListingTranslationRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateListingTranslation) updating translation")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertTranslation(*translation))
+}
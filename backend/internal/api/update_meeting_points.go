@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/repositories/listings"
+	"go.coaster.io/server/common/repositories/meeting_points"
+)
+
+type UpdateMeetingPointsRequest = []input.MeetingPoint
+
+func (s ApiService) UpdateMeetingPoints(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strListingId, ok := vars["listingID"]
+	if !ok {
+		return errors.Newf("(api.UpdateMeetingPoints) missing listing ID from UpdateMeetingPoints request URL: %s", r.URL.RequestURI())
+	}
+
+	listingID, err := strconv.ParseInt(strListingId, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateMeetingPoints)")
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var updateMeetingPointsRequest UpdateMeetingPointsRequest
+	err = decoder.Decode(&updateMeetingPointsRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateMeetingPoints) decoding request")
+	}
+
+	// An empty list is allowed so hosts can remove all meeting points
+	validate := validator.New()
+	err = validate.Var(updateMeetingPointsRequest, "dive")
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateMeetingPoints) validating request")
+	}
+
+	// Make sure this user has ownership of this listing or is an admin
+	_, err = listings.LoadByIDAndUser(s.db, listingID, auth.User)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateMeetingPoints) validating ownership of listing")
+	}
+
+	meetingPoints, err := meeting_points.UpdateMeetingPoints(
+		s.db,
+		listingID,
+		updateMeetingPointsRequest,
+	)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateMeetingPoints) updating meeting points")
+	}
+
+	return json.NewEncoder(w).Encode(meetingPoints)
+}
@@ -1,23 +1,14 @@
 package api
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
-	"image"
 	"io"
 	"net/http"
 
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
-
-	_ "golang.org/x/image/webp"
-
-	"cloud.google.com/go/storage"
 	"github.com/google/uuid"
 	"go.coaster.io/server/common/auth"
 	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/images"
 	"go.coaster.io/server/common/repositories/users"
 	"go.coaster.io/server/common/views"
 )
@@ -39,42 +30,29 @@ func (s ApiService) UpdateProfilePicture(auth auth.Authentication, w http.Respon
 		return errors.NewBadRequestf("Unsupported image type: %s", contentType)
 	}
 
-	imageCfg, _, err := image.DecodeConfig(file)
+	// Use the dimensions of the upright image, since photos from phones are often stored sideways with an
+	// EXIF orientation and browsers display them rotated
+	img, err := images.Decode(file)
 	if err != nil {
-		return errors.Wrap(err, "(api.AddListingImage) decoding image config")
+		return errors.Wrap(err, "(api.UpdateProfilePicture) decoding image")
 	}
 
-	file.Seek(0, io.SeekStart)
+	placeholder := images.GetPlaceholder(img)
 
-	client, err := storage.NewClient(context.TODO())
-	if err != nil {
-		return errors.Wrap(err, "(api.UpdateProfilePicture) opening storage client")
-	}
-	defer client.Close()
+	file.Seek(0, io.SeekStart)
 
-	userImagesBucket := getUserImageBucket()
 	storageID := uuid.New().String()
-
-	o := client.Bucket(userImagesBucket).Object(storageID)
-	o = o.If(storage.Conditions{DoesNotExist: true}) // TODO: figure out the right conditions here
-	wc := o.NewWriter(context.TODO())
-	wc.ContentType = contentType
-	if _, err = io.Copy(wc, file); err != nil {
-		return errors.Wrap(err, "(api.UpdateProfilePicture) copying file to storage")
-	}
-	if err := wc.Close(); err != nil {
-		return errors.Wrap(err, "(api.UpdateProfilePicture) closing file")
+	err = s.blobStore.Upload(r.Context(), storageID, contentType, file)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateProfilePicture) uploading file")
 	}
 
-	// TODO: do this transactionally or figure out something to handle failures
-	user, err := users.UpdateProfilePicture(s.db, auth.User, getProfilePictureURL(storageID), imageCfg.Width, imageCfg.Height)
+	// The previous picture is left in place and cleaned up by the blob reconciliation job
+	user, err := users.UpdateProfilePicture(s.db, auth.User, images.GetImageUrl(storageID), img.Bounds().Dx(), img.Bounds().Dy(), placeholder)
 	if err != nil {
+		s.deleteBlobs([]string{storageID})
 		return errors.Wrap(err, "(api.UpdateProfilePicture) saving listing image details to DB")
 	}
 
 	return json.NewEncoder(w).Encode(views.ConvertUser(*user))
 }
-
-func getProfilePictureURL(storageID string) string {
-	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", getUserImageBucket(), storageID)
-}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/input"
+	"go.coaster.io/server/common/repositories/wishlists"
+	"go.coaster.io/server/common/views"
+)
+
+type UpdateWishlistRequest = input.WishlistUpdates
+
+func (s ApiService) UpdateWishlist(auth auth.Authentication, w http.ResponseWriter, r *http.Request) error {
+	vars := mux.Vars(r)
+	strWishlistID, ok := vars["wishlistID"]
+	if !ok {
+		return errors.Newf("(api.UpdateWishlist) missing wishlist ID from UpdateWishlist request URL: %s", r.URL.RequestURI())
+	}
+
+	wishlistID, err := strconv.ParseInt(strWishlistID, 10, 64)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateWishlist) parsing wishlist ID")
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	var updateWishlistRequest UpdateWishlistRequest
+	err = decoder.Decode(&updateWishlistRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateWishlist) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(updateWishlistRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateWishlist) validating request")
+	}
+
+	wishlist, err := wishlists.LoadByIDAndUser(s.db, wishlistID, auth.User.ID)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NotFound
+		} else {
+			return errors.Wrap(err, "(api.UpdateWishlist) loading wishlist")
+		}
+	}
+
+	wishlist, err = wishlists.UpdateWishlist(s.db, wishlist, updateWishlistRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateWishlist) updating wishlist")
+	}
+
+	wishlistDetails, err := wishlists.LoadDetails(s.db, *wishlist)
+	if err != nil {
+		return errors.Wrap(err, "(api.UpdateWishlist) loading wishlist details")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertWishlist(*wishlistDetails))
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/email_verification_tokens"
+	"go.coaster.io/server/common/repositories/users"
+	"go.coaster.io/server/common/views"
+)
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// Doesn't need a session, since the link may be opened on a different device than the one used to sign up
+func (s ApiService) VerifyEmail(w http.ResponseWriter, r *http.Request) error {
+	decoder := json.NewDecoder(r.Body)
+	var verifyEmailRequest VerifyEmailRequest
+	err := decoder.Decode(&verifyEmailRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyEmail) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(verifyEmailRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyEmail) validating request")
+	}
+
+	verificationToken, err := email_verification_tokens.LoadValidByToken(s.db, verifyEmailRequest.Token)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NewBadRequest("This link is invalid or has expired")
+		}
+
+		return errors.Wrap(err, "(api.VerifyEmail) loading token")
+	}
+
+	user, err := users.LoadUserByID(s.db, verificationToken.UserID)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyEmail) loading user")
+	}
+
+	if verificationToken.Email != user.Email {
+		return errors.NewBadRequest("This link is invalid or has expired")
+	}
+
+	err = users.SetEmailVerified(s.db, user)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyEmail) verifying email")
+	}
+
+	err = email_verification_tokens.DeactivateAllForUser(s.db, user.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyEmail) deactivating tokens")
+	}
+
+	return json.NewEncoder(w).Encode(views.ConvertUser(*user))
+}
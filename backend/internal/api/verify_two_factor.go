@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-playground/validator"
+	"go.coaster.io/server/common/auth"
+	"go.coaster.io/server/common/errors"
+	"go.coaster.io/server/common/repositories/sessions"
+	"go.coaster.io/server/common/repositories/two_factor_challenges"
+	"go.coaster.io/server/common/repositories/users"
+	"go.coaster.io/server/common/views"
+)
+
+type VerifyTwoFactorRequest struct {
+	Challenge    string `json:"challenge" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// The second login step, after EmailLogin, MagicLinkLogin, ResetPassword or OAuthLogin returned a challenge
+func (s ApiService) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) error {
+	decoder := json.NewDecoder(r.Body)
+	var verifyTwoFactorRequest VerifyTwoFactorRequest
+	err := decoder.Decode(&verifyTwoFactorRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyTwoFactor) decoding request")
+	}
+
+	validate := validator.New()
+	err = validate.Struct(verifyTwoFactorRequest)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyTwoFactor) validating request")
+	}
+
+	challenge, err := two_factor_challenges.LoadValidByToken(s.db, verifyTwoFactorRequest.Challenge)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NewBadRequest("Your login has expired, please log in again")
+		}
+
+		return errors.Wrap(err, "(api.VerifyTwoFactor) loading challenge")
+	}
+
+	user, err := users.LoadUserByID(s.db, challenge.UserID)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyTwoFactor) loading user")
+	}
+
+	if user.Blocked {
+		return errors.Wrap(errors.Forbidden, "(api.VerifyTwoFactor)")
+	}
+
+	err = two_factor_challenges.ReserveAttempt(s.db, challenge)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NewBadRequest("Your login has expired, please log in again")
+		}
+
+		return errors.Wrap(err, "(api.VerifyTwoFactor) counting attempt")
+	}
+
+	valid, err := s.checkSecondFactor(r.Context(), user, verifyTwoFactorRequest.Code, verifyTwoFactorRequest.RecoveryCode)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyTwoFactor)")
+	}
+
+	if !valid {
+		return errors.NewBadRequest("Invalid code")
+	}
+
+	err = two_factor_challenges.Consume(s.db, challenge)
+	if err != nil {
+		if errors.IsRecordNotFound(err) {
+			return errors.NewBadRequest("Your login has expired, please log in again")
+		}
+
+		return errors.Wrap(err, "(api.VerifyTwoFactor) using challenge")
+	}
+
+	sessionToken, err := sessions.CreateWithSecondFactor(s.db, user.ID)
+	if err != nil {
+		return errors.Wrap(err, "(api.VerifyTwoFactor) could not create session")
+	}
+
+	auth.AddSessionCookie(w, *sessionToken)
+
+	userView := views.ConvertUser(*user)
+	return json.NewEncoder(w).Encode(EmailLoginResponse{
+		User: &userView,
+	})
+}
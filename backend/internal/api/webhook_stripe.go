@@ -131,7 +131,7 @@ func sendConfirmationEmail(listing *listings.ListingDetails, booking *models.Boo
 		domain = "http://localhost:3000"
 	}
 
-	listingImageURL := images.GetGcsImageUrl(listing.Images[0].StorageID)
+	listingImageURL := images.GetVariantUrl(listing.Images[0], models.ImageVariantCard, models.ImageFormatJPEG)
 	startDateString := getStartDateString(booking.StartDate.ToTime(), booking.StartTime.ToTimePtr(), listing.AvailabilityType)
 	durationString := getDurationString(*listing.DurationMinutes)
 
@@ -40,6 +40,8 @@ type AuthenticatedRoute struct {
 	Method      Method
 	Pattern     string
 	HandlerFunc AuthenticatedHandlerFunc
+	// Reachable by admins who haven't used two-factor yet, so they can set it up
+	AllowWithoutTwoFactor bool
 }
 
 type UnauthenticatedRoute struct {
@@ -62,7 +62,7 @@ func (r Router) RegisterRoutes(service ApiService) {
 	}
 
 	for _, route := range service.AuthenticatedRoutes() {
-		wrapped := r.wrapAuthenticatedRoute(route.HandlerFunc)
+		wrapped := r.wrapAuthenticatedRoute(route)
 		r.router.Handle(route.Pattern, wrapped).Methods(route.Method.String())
 	}
 
@@ -78,8 +78,8 @@ func (r Router) RegisterRoutes(service ApiService) {
 	}
 }
 
-func (r Router) wrapAuthenticatedRoute(handler AuthenticatedHandlerFunc) http.Handler {
-	withAuth := r.wrapWithAuth(handler)
+func (r Router) wrapAuthenticatedRoute(route AuthenticatedRoute) http.Handler {
+	withAuth := r.wrapWithAuth(route.HandlerFunc, route.AllowWithoutTwoFactor)
 	withError := r.wrapWithErrorHandling(withAuth)
 	return withError
 }
@@ -89,7 +89,7 @@ func (r Router) wrapUnauthenticatedRoute(handler ErrorHandlerFunc) http.Handler
 	return withError
 }
 
-func (r Router) wrapWithAuth(handler AuthenticatedHandlerFunc) ErrorHandlerFunc {
+func (r Router) wrapWithAuth(handler AuthenticatedHandlerFunc, allowWithoutTwoFactor bool) ErrorHandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) error {
 		auth, err := r.authService.GetAuthentication(req)
 		if err != nil {
@@ -101,6 +101,11 @@ func (r Router) wrapWithAuth(handler AuthenticatedHandlerFunc) ErrorHandlerFunc
 			return nil
 		}
 
+		if auth.NeedsTwoFactor() && !allowWithoutTwoFactor {
+			http.Error(w, errors.TwoFactorRequired.Error(), errors.TwoFactorRequired.Code())
+			return nil
+		}
+
 		return handler(*auth, w, req)
 	}
 }
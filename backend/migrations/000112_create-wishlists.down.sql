@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS wishlist_listings;
+DROP TABLE IF EXISTS wishlists;
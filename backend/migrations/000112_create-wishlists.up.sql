@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS wishlists (
+  id             BIGSERIAL PRIMARY KEY,
+  user_id        BIGINT NOT NULL REFERENCES users(id),
+  name           VARCHAR(128) NOT NULL,
+  share_token    VARCHAR(64) UNIQUE,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS wishlist_listings (
+  id             BIGSERIAL PRIMARY KEY,
+  wishlist_id    BIGINT NOT NULL REFERENCES wishlists(id),
+  listing_id     BIGINT NOT NULL REFERENCES listings(id),
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX wishlists_user_id_idx ON wishlists(user_id);
+CREATE INDEX wishlist_listings_wishlist_id_idx ON wishlist_listings(wishlist_id);
+CREATE INDEX wishlist_listings_listing_id_idx ON wishlist_listings(listing_id);
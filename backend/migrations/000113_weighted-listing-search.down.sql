@@ -0,0 +1,13 @@
+DROP TRIGGER IF EXISTS listing_categories_search_update_trigger ON listing_categories;
+DROP FUNCTION IF EXISTS listing_categories_search_update;
+DROP TRIGGER IF EXISTS listings_search_update_trigger ON listings;
+DROP FUNCTION IF EXISTS listings_search_update;
+
+DROP INDEX IF EXISTS listings_search_text_trgm_idx;
+DROP INDEX IF EXISTS listings_ts_idx;
+ALTER TABLE listings DROP COLUMN IF EXISTS search_text;
+ALTER TABLE listings DROP COLUMN IF EXISTS ts;
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS ts tsvector GENERATED ALWAYS AS (to_tsvector('english', name || ' ' || description || ' ' || location)) STORED;
+CREATE INDEX ts_idx ON listings USING GIN (ts);
+
+DROP TEXT SEARCH CONFIGURATION IF EXISTS english_unaccent;
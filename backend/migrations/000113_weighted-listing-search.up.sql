@@ -0,0 +1,62 @@
+CREATE EXTENSION IF NOT EXISTS unaccent;
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Same as the english configuration, but matches regardless of accents (e.g. "Tulum" and "Túlum")
+CREATE TEXT SEARCH CONFIGURATION english_unaccent (COPY = english);
+ALTER TEXT SEARCH CONFIGURATION english_unaccent
+  ALTER MAPPING FOR hword, hword_part, word WITH unaccent, english_stem;
+
+DROP INDEX IF EXISTS ts_idx;
+ALTER TABLE listings DROP COLUMN IF EXISTS ts;
+ALTER TABLE listings ADD COLUMN ts tsvector;
+-- Unaccented, lowercased name and location used for typo-tolerant trigram matching
+ALTER TABLE listings ADD COLUMN search_text TEXT;
+
+-- The search columns depend on the listing's categories, so they can't be generated columns
+CREATE OR REPLACE FUNCTION listings_search_update() RETURNS trigger AS $$
+DECLARE
+  categories TEXT;
+BEGIN
+  SELECT string_agg(replace(listing_categories.category, '_', ' '), ' ') INTO categories
+  FROM listing_categories
+  WHERE listing_categories.listing_id = NEW.id
+  AND listing_categories.deactivated_at IS NULL;
+
+  NEW.ts :=
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.name, '')), 'A') ||
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.short_description, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.city, '') || ' ' || coalesce(NEW.region, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', coalesce(categories, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', array_to_string(NEW.highlights, ' ')), 'C') ||
+    setweight(to_tsvector('english_unaccent', regexp_replace(coalesce(NEW.description, ''), '<[^>]*>', ' ', 'g')), 'D');
+  NEW.search_text := lower(unaccent(concat_ws(' ', NEW.name, NEW.city, NEW.region)));
+
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER listings_search_update_trigger
+  BEFORE INSERT OR UPDATE ON listings
+  FOR EACH ROW EXECUTE FUNCTION listings_search_update();
+
+-- Touch the listing when its categories change so the trigger above recomputes the search columns
+CREATE OR REPLACE FUNCTION listing_categories_search_update() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'DELETE' THEN
+    UPDATE listings SET id = id WHERE id = OLD.listing_id;
+  ELSE
+    UPDATE listings SET id = id WHERE id = NEW.listing_id;
+  END IF;
+
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER listing_categories_search_update_trigger
+  AFTER INSERT OR UPDATE OR DELETE ON listing_categories
+  FOR EACH ROW EXECUTE FUNCTION listing_categories_search_update();
+
+UPDATE listings SET id = id;
+
+CREATE INDEX listings_ts_idx ON listings USING GIN (ts);
+CREATE INDEX listings_search_text_trgm_idx ON listings USING GIN (search_text gin_trgm_ops);
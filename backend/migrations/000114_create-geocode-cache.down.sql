@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS place_details_cache_entries;
+DROP TABLE IF EXISTS geocode_cache_entries;
@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS geocode_cache_entries (
+  id             BIGSERIAL PRIMARY KEY,
+  query          VARCHAR(256) NOT NULL UNIQUE,
+  place_id       VARCHAR(256) NOT NULL,
+  name           VARCHAR(256) NOT NULL,
+  coordinates    geography(POINT) NOT NULL,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE IF NOT EXISTS place_details_cache_entries (
+  id             BIGSERIAL PRIMARY KEY,
+  place_id       VARCHAR(256) NOT NULL UNIQUE,
+  city           VARCHAR(256) NOT NULL,
+  region         VARCHAR(256) NOT NULL,
+  country        VARCHAR(256) NOT NULL,
+  postal_code    VARCHAR(256),
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS listings_service_area_idx;
+ALTER TABLE listings DROP COLUMN IF EXISTS service_area;
+DROP TABLE IF EXISTS meeting_points;
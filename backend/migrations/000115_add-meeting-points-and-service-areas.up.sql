@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS meeting_points (
+  id             BIGSERIAL PRIMARY KEY,
+  listing_id     BIGINT NOT NULL REFERENCES listings(id),
+  name           VARCHAR(128) NOT NULL,
+  instructions   TEXT,
+  coordinates    geography(POINT) NOT NULL,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX meeting_points_listing_id_idx ON meeting_points(listing_id);
+CREATE INDEX meeting_points_coordinates_idx ON meeting_points USING GIST (coordinates);
+
+ALTER TABLE listings ADD COLUMN IF NOT EXISTS service_area geography(POLYGON);
+CREATE INDEX listings_service_area_idx ON listings USING GIST (service_area);
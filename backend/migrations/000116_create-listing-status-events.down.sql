@@ -0,0 +1 @@
+DROP TABLE IF EXISTS listing_status_events;
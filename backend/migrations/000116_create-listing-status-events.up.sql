@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS listing_status_events (
+  id             BIGSERIAL PRIMARY KEY,
+  listing_id     BIGINT NOT NULL REFERENCES listings(id),
+  actor_id       BIGINT NOT NULL REFERENCES users(id),
+  from_status    VARCHAR(32) NOT NULL,
+  to_status      VARCHAR(32) NOT NULL,
+  reasons        VARCHAR(64)[] NOT NULL DEFAULT '{}',
+  feedback       TEXT,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX listing_status_events_listing_id_idx ON listing_status_events(listing_id);
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS listing_revisions (
+  id             BIGSERIAL PRIMARY KEY,
+  listing_id     BIGINT NOT NULL REFERENCES listings(id),
+  author_id      BIGINT NOT NULL REFERENCES users(id),
+  reviewer_id    BIGINT REFERENCES users(id),
+  status         VARCHAR(32) NOT NULL,
+  content        JSONB NOT NULL,
+  reasons        VARCHAR(64)[] NOT NULL DEFAULT '{}',
+  feedback       TEXT,
+  submitted_at   TIMESTAMP WITH TIME ZONE,
+  published_at   TIMESTAMP WITH TIME ZONE,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX listing_revisions_listing_id_idx ON listing_revisions(listing_id);
+CREATE INDEX listing_revisions_status_idx ON listing_revisions(status) WHERE deactivated_at IS NULL;
+
+-- Each listing can only have one revision being worked on at a time
+CREATE UNIQUE INDEX listing_revisions_open_idx ON listing_revisions(listing_id)
+  WHERE status IN ('pending', 'review', 'rejected') AND deactivated_at IS NULL;
@@ -0,0 +1,30 @@
+DROP TRIGGER IF EXISTS listing_translations_search_update_trigger ON listing_translations;
+
+CREATE OR REPLACE FUNCTION listings_search_update() RETURNS trigger AS $$
+DECLARE
+  categories TEXT;
+BEGIN
+  SELECT string_agg(replace(listing_categories.category, '_', ' '), ' ') INTO categories
+  FROM listing_categories
+  WHERE listing_categories.listing_id = NEW.id
+  AND listing_categories.deactivated_at IS NULL;
+
+  NEW.ts :=
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.name, '')), 'A') ||
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.short_description, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.city, '') || ' ' || coalesce(NEW.region, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', coalesce(categories, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', array_to_string(NEW.highlights, ' ')), 'C') ||
+    setweight(to_tsvector('english_unaccent', regexp_replace(coalesce(NEW.description, ''), '<[^>]*>', ' ', 'g')), 'D');
+  NEW.search_text := lower(unaccent(concat_ws(' ', NEW.name, NEW.city, NEW.region)));
+
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TABLE IF EXISTS itinerary_step_translations;
+DROP TABLE IF EXISTS listing_translations;
+
+ALTER TABLE listings DROP COLUMN IF EXISTS source_locale;
+
+UPDATE listings SET id = id;
@@ -0,0 +1,74 @@
+ALTER TABLE listings ADD COLUMN source_locale VARCHAR(35) NOT NULL DEFAULT 'en';
+
+CREATE TABLE IF NOT EXISTS listing_translations (
+  id                BIGSERIAL PRIMARY KEY,
+  listing_id        BIGINT NOT NULL REFERENCES listings(id),
+  locale            VARCHAR(35) NOT NULL,
+  name              TEXT,
+  description       TEXT,
+  short_description TEXT,
+  highlights        VARCHAR(160)[],
+
+  created_at        TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at        TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX listing_translations_listing_id_locale_idx ON listing_translations(listing_id, locale) WHERE deactivated_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS itinerary_step_translations (
+  id                BIGSERIAL PRIMARY KEY,
+  itinerary_step_id BIGINT NOT NULL REFERENCES itinerary_steps(id),
+  locale            VARCHAR(35) NOT NULL,
+  title             TEXT,
+  description       TEXT,
+  step_label        TEXT,
+
+  created_at        TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at        TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX itinerary_step_translations_step_id_locale_idx ON itinerary_step_translations(itinerary_step_id, locale) WHERE deactivated_at IS NULL;
+
+-- Same as before, but translated text is indexed alongside the source so searches match in any language
+CREATE OR REPLACE FUNCTION listings_search_update() RETURNS trigger AS $$
+DECLARE
+  categories TEXT;
+  translated_names TEXT;
+  translated_short_descriptions TEXT;
+  translated_highlights TEXT;
+  translated_descriptions TEXT;
+BEGIN
+  SELECT string_agg(replace(listing_categories.category, '_', ' '), ' ') INTO categories
+  FROM listing_categories
+  WHERE listing_categories.listing_id = NEW.id
+  AND listing_categories.deactivated_at IS NULL;
+
+  SELECT
+    string_agg(listing_translations.name, ' '),
+    string_agg(listing_translations.short_description, ' '),
+    string_agg(array_to_string(listing_translations.highlights, ' '), ' '),
+    string_agg(listing_translations.description, ' ')
+  INTO translated_names, translated_short_descriptions, translated_highlights, translated_descriptions
+  FROM listing_translations
+  WHERE listing_translations.listing_id = NEW.id
+  AND listing_translations.deactivated_at IS NULL;
+
+  NEW.ts :=
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.name, '') || ' ' || coalesce(translated_names, '')), 'A') ||
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.short_description, '') || ' ' || coalesce(translated_short_descriptions, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', coalesce(NEW.city, '') || ' ' || coalesce(NEW.region, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', coalesce(categories, '')), 'B') ||
+    setweight(to_tsvector('english_unaccent', array_to_string(NEW.highlights, ' ') || ' ' || coalesce(translated_highlights, '')), 'C') ||
+    setweight(to_tsvector('english_unaccent', regexp_replace(coalesce(NEW.description, '') || ' ' || coalesce(translated_descriptions, ''), '<[^>]*>', ' ', 'g')), 'D');
+  NEW.search_text := lower(unaccent(concat_ws(' ', NEW.name, translated_names, NEW.city, NEW.region)));
+
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+-- Reuses the categories function since it only needs the listing_id column to touch the listing
+CREATE TRIGGER listing_translations_search_update_trigger
+  AFTER INSERT OR UPDATE OR DELETE ON listing_translations
+  FOR EACH ROW EXECUTE FUNCTION listing_categories_search_update();
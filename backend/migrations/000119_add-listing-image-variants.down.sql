@@ -0,0 +1 @@
+ALTER TABLE listing_images DROP COLUMN IF EXISTS variants;
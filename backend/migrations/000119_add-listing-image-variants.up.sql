@@ -0,0 +1 @@
+ALTER TABLE listing_images ADD COLUMN variants JSONB NOT NULL DEFAULT '[]';
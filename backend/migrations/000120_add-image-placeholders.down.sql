@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN IF EXISTS profile_picture_dominant_color;
+ALTER TABLE users DROP COLUMN IF EXISTS profile_picture_blurhash;
+
+ALTER TABLE listing_images DROP COLUMN IF EXISTS dominant_color;
+ALTER TABLE listing_images DROP COLUMN IF EXISTS blurhash;
@@ -0,0 +1,5 @@
+ALTER TABLE listing_images ADD COLUMN blurhash TEXT;
+ALTER TABLE listing_images ADD COLUMN dominant_color TEXT;
+
+ALTER TABLE users ADD COLUMN profile_picture_blurhash TEXT;
+ALTER TABLE users ADD COLUMN profile_picture_dominant_color TEXT;
@@ -0,0 +1 @@
+ALTER TABLE listing_images ADD COLUMN missing_since TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,21 @@
+ALTER TABLE itinerary_steps ADD COLUMN step_order_int INTEGER;
+UPDATE itinerary_steps SET step_order_int = ranked.step_order_int
+FROM (
+  SELECT id, (row_number() OVER (PARTITION BY listing_id ORDER BY step_order, id)) - 1 AS step_order_int
+  FROM itinerary_steps
+) ranked
+WHERE itinerary_steps.id = ranked.id;
+ALTER TABLE itinerary_steps DROP COLUMN step_order;
+ALTER TABLE itinerary_steps RENAME COLUMN step_order_int TO step_order;
+ALTER TABLE itinerary_steps ALTER COLUMN step_order SET NOT NULL;
+
+ALTER TABLE listing_images ADD COLUMN rank_int SMALLINT;
+UPDATE listing_images SET rank_int = ranked.rank_int
+FROM (
+  SELECT id, row_number() OVER (PARTITION BY listing_id ORDER BY rank, id) AS rank_int
+  FROM listing_images
+) ranked
+WHERE listing_images.id = ranked.id;
+ALTER TABLE listing_images DROP COLUMN rank;
+ALTER TABLE listing_images RENAME COLUMN rank_int TO rank;
+ALTER TABLE listing_images ALTER COLUMN rank SET NOT NULL;
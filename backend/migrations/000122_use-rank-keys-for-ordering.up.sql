@@ -0,0 +1,24 @@
+-- Ranks become base 36 fractional keys (see common/ranks), compared byte by byte. Existing rows get
+-- zero padded positions ending in "i", since keys can't end in "0". They're respaced the next time
+-- their set needs rebalancing.
+ALTER TABLE listing_images ADD COLUMN rank_key VARCHAR(64) COLLATE "C";
+UPDATE listing_images SET rank_key = ranked.rank_key
+FROM (
+  SELECT id, lpad((row_number() OVER (PARTITION BY listing_id ORDER BY rank, id))::text, 4, '0') || 'i' AS rank_key
+  FROM listing_images
+) ranked
+WHERE listing_images.id = ranked.id;
+ALTER TABLE listing_images DROP COLUMN rank;
+ALTER TABLE listing_images RENAME COLUMN rank_key TO rank;
+ALTER TABLE listing_images ALTER COLUMN rank SET NOT NULL;
+
+ALTER TABLE itinerary_steps ADD COLUMN step_order_key VARCHAR(64) COLLATE "C";
+UPDATE itinerary_steps SET step_order_key = ranked.step_order_key
+FROM (
+  SELECT id, lpad((row_number() OVER (PARTITION BY listing_id ORDER BY step_order, id))::text, 4, '0') || 'i' AS step_order_key
+  FROM itinerary_steps
+) ranked
+WHERE itinerary_steps.id = ranked.id;
+ALTER TABLE itinerary_steps DROP COLUMN step_order;
+ALTER TABLE itinerary_steps RENAME COLUMN step_order_key TO step_order;
+ALTER TABLE itinerary_steps ALTER COLUMN step_order SET NOT NULL;
@@ -0,0 +1,7 @@
+DELETE FROM listing_images WHERE media_type = 'video';
+
+ALTER TABLE listing_images DROP COLUMN IF EXISTS poster_storage_id;
+ALTER TABLE listing_images DROP COLUMN IF EXISTS duration_ms;
+ALTER TABLE listing_images DROP COLUMN IF EXISTS content_type;
+ALTER TABLE listing_images DROP COLUMN IF EXISTS projection;
+ALTER TABLE listing_images DROP COLUMN IF EXISTS media_type;
@@ -0,0 +1,6 @@
+-- Videos share listing_images with photos so the whole gallery is ordered by one set of ranks
+ALTER TABLE listing_images ADD COLUMN media_type VARCHAR(16) NOT NULL DEFAULT 'image';
+ALTER TABLE listing_images ADD COLUMN projection VARCHAR(32) NOT NULL DEFAULT 'flat';
+ALTER TABLE listing_images ADD COLUMN content_type VARCHAR(64);
+ALTER TABLE listing_images ADD COLUMN duration_ms INTEGER;
+ALTER TABLE listing_images ADD COLUMN poster_storage_id VARCHAR(128);
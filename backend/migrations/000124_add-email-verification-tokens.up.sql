@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS email_verification_tokens (
+  id         BIGSERIAL PRIMARY KEY,
+  user_id    BIGINT NOT NULL REFERENCES users(id),
+  email      VARCHAR(256) NOT NULL,
+  token      VARCHAR(256) NOT NULL,
+  expiration TIMESTAMP WITH TIME ZONE NOT NULL,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens(user_id);
+CREATE UNIQUE INDEX email_verification_tokens_token_idx ON email_verification_tokens(token);
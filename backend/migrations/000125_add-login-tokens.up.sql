@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS login_tokens (
+  id         BIGSERIAL PRIMARY KEY,
+  user_id    BIGINT NOT NULL REFERENCES users(id),
+  token      VARCHAR(256) NOT NULL,
+  origin     VARCHAR(256) NOT NULL,
+  expiration TIMESTAMP WITH TIME ZONE NOT NULL,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX login_tokens_user_id_idx ON login_tokens(user_id);
+CREATE UNIQUE INDEX login_tokens_token_idx ON login_tokens(token);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS two_factor_challenges;
+DROP TABLE IF EXISTS recovery_codes;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS second_factor_at;
+ALTER TABLE sessions DROP COLUMN IF EXISTS authenticated_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_used_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
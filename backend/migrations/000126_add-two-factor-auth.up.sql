@@ -0,0 +1,38 @@
+-- The secret is set when enrollment starts and only takes effect once totp_enabled_at is set
+ALTER TABLE users ADD COLUMN totp_secret TEXT;
+ALTER TABLE users ADD COLUMN totp_enabled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN totp_last_used_step BIGINT;
+
+-- Existing sessions count as authenticated when they were created, and as not having used a second factor
+ALTER TABLE sessions ADD COLUMN authenticated_at TIMESTAMP WITH TIME ZONE;
+UPDATE sessions SET authenticated_at = created_at;
+ALTER TABLE sessions ALTER COLUMN authenticated_at SET NOT NULL;
+ALTER TABLE sessions ADD COLUMN second_factor_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+  id        BIGSERIAL PRIMARY KEY,
+  user_id   BIGINT NOT NULL REFERENCES users(id),
+  code_hash VARCHAR(256) NOT NULL,
+  used_at   TIMESTAMP WITH TIME ZONE,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes(user_id);
+
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+  id              BIGSERIAL PRIMARY KEY,
+  user_id         BIGINT NOT NULL REFERENCES users(id),
+  token           VARCHAR(256) NOT NULL,
+  expiration      TIMESTAMP WITH TIME ZONE NOT NULL,
+  failed_attempts INT NOT NULL DEFAULT 0,
+
+  created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  updated_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+  deactivated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX two_factor_challenges_user_id_idx ON two_factor_challenges(user_id);
+CREATE UNIQUE INDEX two_factor_challenges_token_idx ON two_factor_challenges(token);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS external_profiles_provider_external_id_idx;
+CREATE INDEX external_profiles_external_id_idx ON external_profiles(external_id);
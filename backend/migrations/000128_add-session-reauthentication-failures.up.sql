@@ -0,0 +1 @@
+ALTER TABLE sessions ADD COLUMN reauthentication_failures INT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN IF EXISTS two_factor_attempts_since;
+ALTER TABLE users DROP COLUMN IF EXISTS two_factor_attempts;
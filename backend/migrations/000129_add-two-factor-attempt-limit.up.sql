@@ -0,0 +1,3 @@
+-- Code checks are limited per user, not just per challenge, so logging in again doesn't give more guesses
+ALTER TABLE users ADD COLUMN two_factor_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN two_factor_attempts_since TIMESTAMP WITH TIME ZONE;
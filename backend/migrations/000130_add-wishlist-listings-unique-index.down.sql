@@ -0,0 +1 @@
+DROP INDEX IF EXISTS wishlist_listings_wishlist_id_listing_id_idx;
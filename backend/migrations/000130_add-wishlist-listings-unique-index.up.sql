@@ -0,0 +1,10 @@
+-- Concurrent saves could insert the same listing twice, so keep the oldest copy of any duplicates
+UPDATE wishlist_listings SET deactivated_at = NOW()
+WHERE deactivated_at IS NULL
+  AND id NOT IN (
+    SELECT MIN(id) FROM wishlist_listings
+    WHERE deactivated_at IS NULL
+    GROUP BY wishlist_id, listing_id
+  );
+
+CREATE UNIQUE INDEX wishlist_listings_wishlist_id_listing_id_idx ON wishlist_listings(wishlist_id, listing_id) WHERE deactivated_at IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE login_tokens DROP COLUMN IF EXISTS device_nonce;
@@ -0,0 +1,2 @@
+-- Hash of a cookie set in the browser that asked for the link, which has to be sent back to use it
+ALTER TABLE login_tokens ADD COLUMN device_nonce VARCHAR(256);
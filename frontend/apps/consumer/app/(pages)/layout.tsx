@@ -21,6 +21,9 @@ const PUBLIC_PATHS = [
   "/careers",
   "/reset-password",
   "/create-password",
+  "/magic-login",
+  "/two-factor",
+  "/verify-email",
   "/unauthorized",
   "/oauth-callback",
   "/blog",
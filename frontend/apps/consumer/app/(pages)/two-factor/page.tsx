@@ -0,0 +1,5 @@
+import { TwoFactor } from "@coaster/components/pages/TwoFactor";
+
+export default function TwoFactorPage() {
+  return <TwoFactor />;
+}
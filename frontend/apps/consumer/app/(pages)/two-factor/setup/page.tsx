@@ -0,0 +1,5 @@
+import { TwoFactorSetup } from "@coaster/components/pages/TwoFactorSetup";
+
+export default function TwoFactorSetupPage() {
+  return <TwoFactorSetup />;
+}
@@ -0,0 +1,5 @@
+import { VerifyEmail } from "@coaster/components/pages/VerifyEmail";
+
+export default function VerifyEmailPage() {
+  return <VerifyEmail />;
+}
@@ -1,4 +1,4 @@
-import { SearchListings, sendRequest } from "@coaster/rpc/common";
+import { GetListingFeed, sendRequest } from "@coaster/rpc/common";
 import { convert } from "html-to-text";
 import { NextRequest, NextResponse } from "next/server";
 
@@ -23,8 +23,8 @@ export async function GET(req: NextRequest) {
     return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
   }
 
-  // TODO: authenticate request and get all listings
-  const listings = await sendRequest(SearchListings, { revalidate: 3600 });
+  // TODO: authenticate request
+  const listings = await sendRequest(GetListingFeed, { revalidate: 3600 });
   const listingRows = await Promise.all(
     listings.map(async (listing) => {
       if (
@@ -12,6 +12,8 @@ const PUBLIC_PATHS = [
   "/about",
   "/reset-password",
   "/create-password",
+  "/magic-login",
+  "/two-factor",
   "/unauthorized",
   "/oauth-callback",
 ];
@@ -0,0 +1,5 @@
+import { MagicLogin } from "@coaster/components/pages/MagicLogin";
+
+export default function MagicLoginPage() {
+  return <MagicLogin />;
+}
@@ -42,7 +42,14 @@ export const LoginPage: React.FC<{ create?: boolean }> = ({ create }) => {
       loginContent = <EmailSignup email={email} reset={reset} />;
       break;
     case LoginStep.EmailLogin:
-      loginContent = <EmailLoginForm email={email} reset={reset} forgotPassword={() => setStep(LoginStep.SendReset)} />;
+      loginContent = (
+        <EmailLoginForm
+          email={email}
+          destination={destination}
+          reset={reset}
+          forgotPassword={() => setStep(LoginStep.SendReset)}
+        />
+      );
       break;
     case LoginStep.GoogleLogin:
       loginContent = <GoogleLogin email={email} reset={reset} />;
@@ -14,6 +14,7 @@ import {
 import { LoginMethod, OAuthProvider } from "@coaster/types";
 import { mergeClasses } from "@coaster/utils/common";
 import { zodResolver } from "@hookform/resolvers/zod";
+import { useRouter } from "next/navigation";
 import { useCallback, useState } from "react";
 import { useForm } from "react-hook-form";
 import { z } from "zod";
@@ -24,6 +25,7 @@ import { Input } from "../input/Input";
 import { NavLink } from "../link/Link";
 import { Loading } from "../loading/Loading";
 import { LoginMessage, MessageType } from "./message";
+import { getTwoFactorPath } from "./two-factor";
 
 export enum LoginStep {
   Start = "start",
@@ -205,8 +207,10 @@ export const EmailLoginForm: React.FC<{
   reset: () => void;
   forgotPassword: () => void;
   email?: string;
+  destination?: string;
   closeModal?: () => void;
-}> = ({ reset, forgotPassword, email, closeModal }) => {
+}> = ({ reset, forgotPassword, email, destination, closeModal }) => {
+  const router = useRouter();
   const onLoginSuccess = useOnLoginSuccess();
 
   const {
@@ -234,7 +238,11 @@ export const EmailLoginForm: React.FC<{
               password: values.password,
             },
           });
-          onLoginSuccess(result.user);
+          if (result.two_factor_challenge) {
+            router.push(getTwoFactorPath(result.two_factor_challenge, destination));
+          } else if (result.user) {
+            onLoginSuccess(result.user);
+          }
           closeModal && closeModal();
         })}
       >
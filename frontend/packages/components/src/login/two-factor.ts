@@ -0,0 +1,9 @@
+// Users with two-factor get a challenge from the first login step and finish on this page with their code
+export const getTwoFactorPath = (challenge: string, destination?: string) => {
+  const params = new URLSearchParams({ challenge });
+  if (destination) {
+    params.set("destination", destination);
+  }
+
+  return `/two-factor?${params.toString()}`;
+};
@@ -12,6 +12,7 @@ import { Button } from "../button/Button";
 import { FormError } from "../error/FormError";
 import { Input } from "../input/Input";
 import { Loading } from "../loading/Loading";
+import { getTwoFactorPath } from "../login/two-factor";
 
 const CreatePasswordFormSchema = z
   .object({
@@ -36,7 +37,7 @@ export const CreatePassword: React.FC = () => {
   const searchParams = useSearchParams();
   const destination = searchParams.get("destination") ?? "";
   const token = searchParams.get("token");
-  const { mutate: mutatePassword, isLoading, error: submitError } = useResetPassword();
+  const { mutate: mutatePassword, isLoading, error: submitError, data } = useResetPassword();
   const {
     watch,
     handleSubmit,
@@ -59,6 +60,12 @@ export const CreatePassword: React.FC = () => {
     };
   }, [router, destination, user]);
 
+  useEffect(() => {
+    if (data?.two_factor_challenge) {
+      router.push(getTwoFactorPath(data.two_factor_challenge, destination));
+    }
+  }, [router, data]);
+
   if (!token) {
     return redirect("/login");
   }
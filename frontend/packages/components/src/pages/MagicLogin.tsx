@@ -0,0 +1,70 @@
+"use client";
+
+import LongLogo from "@coaster/assets/long-logo.svg";
+import { useOnLoginSuccess } from "@coaster/rpc/client";
+import { MagicLinkLogin, sendRequest } from "@coaster/rpc/common";
+import Image from "next/image";
+import { redirect, useRouter, useSearchParams } from "next/navigation";
+import { useEffect, useRef, useState } from "react";
+import { NavLink } from "../link/Link";
+import { Loading } from "../loading/Loading";
+import { getTwoFactorPath } from "../login/two-factor";
+
+export const MagicLogin: React.FC = () => {
+  const router = useRouter();
+  const searchParams = useSearchParams();
+  const token = searchParams?.get("token");
+  const onLoginSuccess = useOnLoginSuccess();
+  const [failed, setFailed] = useState(false);
+
+  // Links can only be used once, so make sure the request isn't sent twice in development
+  const sent = useRef(false);
+  useEffect(() => {
+    if (!token || sent.current) {
+      return;
+    }
+
+    sent.current = true;
+    sendRequest(MagicLinkLogin, { payload: { token } })
+      .then(async (result) => {
+        if (result.two_factor_challenge) {
+          router.push(getTwoFactorPath(result.two_factor_challenge));
+          return;
+        }
+
+        if (result.user) {
+          await onLoginSuccess(result.user);
+        }
+        router.push("/");
+      })
+      .catch(() => setFailed(true));
+  }, [token]);
+
+  if (!token) {
+    return redirect("/login");
+  }
+
+  return (
+    <div className="tw-flex tw-flex-row tw-h-full tw-w-full tw-bg-slate-100">
+      <div className="tw-mt-20 sm:tw-mt-32 tw-mb-auto tw-mx-auto tw-w-[400px]">
+        <div className="tw-flex tw-flex-col tw-pt-12 tw-pb-10 tw-px-8 tw-rounded-lg sm:tw-shadow-md sm:tw-bg-white tw-items-center">
+          <Image src={LongLogo} width={200} height={32} className="tw-select-none tw-mb-4" alt="coaster logo" />
+          {failed ? (
+            <>
+              <div className="tw-text-xl tw-font-semibold tw-font-heading tw-text-center tw-mb-2">Link expired</div>
+              <div className="tw-text-center">This link is invalid, has expired, or was opened in a different browser than the one you requested it from.</div>
+              <NavLink className="tw-mt-4 tw-text-blue-500" href="/login">
+                Back to login
+              </NavLink>
+            </>
+          ) : (
+            <div className="tw-flex tw-flex-col tw-items-center">
+              <div className="tw-mb-4">Logging you in...</div>
+              <Loading />
+            </div>
+          )}
+        </div>
+      </div>
+    </div>
+  );
+};
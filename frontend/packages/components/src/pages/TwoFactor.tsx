@@ -0,0 +1,115 @@
+"use client";
+
+import LongLogo from "@coaster/assets/long-logo.svg";
+import { useOnLoginSuccess } from "@coaster/rpc/client";
+import { VerifyTwoFactor, sendRequest } from "@coaster/rpc/common";
+import { forceErrorMessage } from "@coaster/utils/common";
+import { zodResolver } from "@hookform/resolvers/zod";
+import Image from "next/image";
+import { redirect, useRouter, useSearchParams } from "next/navigation";
+import { useState } from "react";
+import { useForm } from "react-hook-form";
+import { z } from "zod";
+import { Button } from "../button/Button";
+import { FormError } from "../error/FormError";
+import { Input } from "../input/Input";
+import { Loading } from "../loading/Loading";
+import { MessageType } from "../login/message";
+
+const TwoFactorFormSchema = z.object({
+  code: z.string().min(1, { message: "Enter your code" }),
+});
+
+type TwoFactorFormSchemaType = z.infer<typeof TwoFactorFormSchema>;
+
+export const TwoFactor: React.FC = () => {
+  const router = useRouter();
+  const searchParams = useSearchParams();
+  const challenge = searchParams?.get("challenge");
+  const destination = searchParams?.get("destination") ?? "";
+  const onLoginSuccess = useOnLoginSuccess();
+  const [useRecoveryCode, setUseRecoveryCode] = useState(false);
+  const [isLoading, setIsLoading] = useState(false);
+  const [submitError, setSubmitError] = useState<string | undefined>();
+  const {
+    watch,
+    handleSubmit,
+    register,
+    reset,
+    formState: { errors },
+  } = useForm<TwoFactorFormSchemaType>({
+    mode: "onBlur",
+    resolver: zodResolver(TwoFactorFormSchema),
+  });
+
+  if (!challenge) {
+    return redirect("/login");
+  }
+
+  const onSubmit = async (data: TwoFactorFormSchemaType) => {
+    setIsLoading(true);
+    try {
+      const result = await sendRequest(VerifyTwoFactor, {
+        payload: useRecoveryCode ? { challenge, recovery_code: data.code } : { challenge, code: data.code },
+      });
+
+      // Google logins happen in a popup, so hand back to the window that opened it
+      if (window.opener) {
+        window.opener.postMessage({ type: MessageType.Done });
+        window.close();
+        return;
+      }
+
+      if (result.user) {
+        await onLoginSuccess(result.user);
+      }
+      router.push("/" + decodeURIComponent(destination));
+    } catch (e) {
+      setSubmitError(forceErrorMessage(e));
+      setIsLoading(false);
+    }
+  };
+
+  return (
+    <div className="tw-flex tw-flex-row tw-h-full tw-w-full tw-bg-slate-100">
+      <div className="tw-mt-20 sm:tw-mt-32 tw-mb-auto tw-mx-auto tw-w-[400px]">
+        <div className="tw-flex tw-flex-col tw-pt-12 tw-pb-10 tw-px-8 tw-rounded-lg sm:tw-shadow-md sm:tw-bg-white tw-items-center">
+          <Image src={LongLogo} width={200} height={32} className="tw-select-none tw-mb-4" alt="coaster logo" />
+          <form className="tw-flex tw-flex-col tw-items-center tw-my-2 tw-w-full" onSubmit={handleSubmit(onSubmit)}>
+            <div className="tw-text-xl tw-font-semibold tw-font-heading tw-text-center tw-mb-2">
+              Two-factor authentication
+            </div>
+            <div className="tw-text-center tw-mb-2">
+              {useRecoveryCode
+                ? "Enter one of the recovery codes you saved when setting up two-factor."
+                : "Enter the code from your authenticator app."}
+            </div>
+            <Input
+              autoComplete="one-time-code"
+              inputMode={useRecoveryCode ? "text" : "numeric"}
+              className="tw-w-full tw-my-1"
+              label={useRecoveryCode ? "Recovery code" : "Code"}
+              {...register("code")}
+              value={watch("code")}
+            />
+            <FormError message={errors.code?.message} />
+            <Button type="submit" className="tw-w-full tw-bg-[#3673aa] hover:tw-bg-[#396082] tw-px-10 tw-h-12 tw-mt-4">
+              {isLoading ? <Loading /> : "Continue"}
+            </Button>
+            <FormError className="tw-mt-2" message={submitError} />
+          </form>
+          <div
+            className="tw-mt-4 tw-select-none tw-text-blue-500 tw-cursor-pointer"
+            onClick={() => {
+              setUseRecoveryCode(!useRecoveryCode);
+              setSubmitError(undefined);
+              reset();
+            }}
+          >
+            {useRecoveryCode ? "Use your authenticator app" : "Use a recovery code"}
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+};
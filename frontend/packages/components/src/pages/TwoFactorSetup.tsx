@@ -0,0 +1,175 @@
+"use client";
+
+import LongLogo from "@coaster/assets/long-logo.svg";
+import { useAuthContext, useLogout } from "@coaster/rpc/client";
+import { CheckSession, ConfirmTwoFactor, EnrollTwoFactor, Reauthenticate, sendRequest } from "@coaster/rpc/common";
+import { EnrollTwoFactorResponse } from "@coaster/types";
+import { HttpError, forceErrorMessage } from "@coaster/utils/common";
+import Image from "next/image";
+import { useRouter } from "next/navigation";
+import { useEffect, useRef, useState } from "react";
+import { mutate } from "swr";
+import { Button } from "../button/Button";
+import { FormError } from "../error/FormError";
+import { Input } from "../input/Input";
+import { Loading } from "../loading/Loading";
+
+const isReauthenticationRequired = (e: unknown) =>
+  e instanceof HttpError && e.code === 403 && e.message.includes("Reauthentication required");
+
+export const TwoFactorSetup: React.FC = () => {
+  const router = useRouter();
+  const { user } = useAuthContext();
+  const logout = useLogout();
+  const [enrollment, setEnrollment] = useState<EnrollTwoFactorResponse | undefined>();
+  const [needsPassword, setNeedsPassword] = useState(false);
+  const [recoveryCodes, setRecoveryCodes] = useState<string[] | undefined>();
+  const [input, setInput] = useState("");
+  const [isLoading, setIsLoading] = useState(false);
+  const [submitError, setSubmitError] = useState<string | undefined>();
+
+  const enroll = async () => {
+    try {
+      setEnrollment(await sendRequest(EnrollTwoFactor));
+      setNeedsPassword(false);
+    } catch (e) {
+      if (isReauthenticationRequired(e)) {
+        setNeedsPassword(true);
+      } else {
+        setSubmitError(forceErrorMessage(e));
+      }
+    }
+  };
+
+  // Each enrollment replaces the secret, so make sure it only happens once in development
+  const started = useRef(false);
+  useEffect(() => {
+    if (user && !user.two_factor_enabled && !started.current) {
+      started.current = true;
+      enroll();
+    }
+  }, [user]);
+
+  const submit = async () => {
+    setIsLoading(true);
+    setSubmitError(undefined);
+    try {
+      if (needsPassword) {
+        await sendRequest(Reauthenticate, { payload: { password: input } });
+        await enroll();
+      } else {
+        const result = await sendRequest(ConfirmTwoFactor, { payload: { code: input } });
+        setRecoveryCodes(result.recovery_codes);
+      }
+      setInput("");
+    } catch (e) {
+      setSubmitError(forceErrorMessage(e));
+    }
+    setIsLoading(false);
+  };
+
+  const finish = async () => {
+    await mutate({ CheckSession });
+    router.push("/");
+  };
+
+  let content: React.ReactNode;
+  if (!user) {
+    content = <Loading />;
+  } else if (recoveryCodes) {
+    content = (
+      <>
+        <div className="tw-text-center tw-mb-2">
+          Two-factor authentication is on. Save these recovery codes somewhere safe. Each one can be used once if you
+          lose your authenticator, and they won&apos;t be shown again.
+        </div>
+        <div className="tw-grid tw-grid-cols-2 tw-gap-2 tw-font-mono tw-my-2">
+          {recoveryCodes.map((code) => (
+            <div key={code}>{code}</div>
+          ))}
+        </div>
+        <Button className="tw-w-full tw-bg-[#3673aa] hover:tw-bg-[#396082] tw-h-12 tw-mt-4" onClick={finish}>
+          I&apos;ve saved my codes
+        </Button>
+      </>
+    );
+  } else if (user.two_factor_enabled) {
+    // Sessions from before two-factor was turned on never used it, so the user has to log in again
+    content = (
+      <>
+        <div className="tw-text-center">Please log in again with your authenticator app to continue.</div>
+        <Button
+          className="tw-w-full tw-bg-[#3673aa] hover:tw-bg-[#396082] tw-h-12 tw-mt-4"
+          onClick={async () => {
+            await logout();
+            router.push("/login");
+          }}
+        >
+          Log in again
+        </Button>
+      </>
+    );
+  } else if (needsPassword || enrollment) {
+    content = (
+      <form
+        className="tw-flex tw-flex-col tw-items-center tw-w-full"
+        onSubmit={(e) => {
+          e.preventDefault();
+          submit();
+        }}
+      >
+        {needsPassword ? (
+          <div className="tw-text-center tw-mb-2">Enter your password to start setting up two-factor.</div>
+        ) : (
+          enrollment && (
+            <>
+              <div className="tw-text-center tw-mb-2">
+                Add Coaster to your authenticator app, then enter the code it shows.
+              </div>
+              <a className="tw-text-blue-500 tw-mb-2" href={enrollment.otpauth_uri}>
+                Open in authenticator app
+              </a>
+              <div className="tw-text-center tw-text-sm tw-mb-1">Or enter this key manually:</div>
+              <div className="tw-font-mono tw-text-center tw-break-all tw-mb-2">
+                {enrollment.secret.match(/.{1,4}/g)?.join(" ")}
+              </div>
+            </>
+          )
+        )}
+        <Input
+          autoComplete={needsPassword ? "current-password" : "one-time-code"}
+          type={needsPassword ? "password" : "text"}
+          inputMode={needsPassword ? "text" : "numeric"}
+          className="tw-w-full tw-my-1"
+          label={needsPassword ? "Password" : "Code"}
+          value={input}
+          onChange={(e) => setInput(e.target.value)}
+        />
+        <Button
+          type="submit"
+          disabled={!input}
+          className="tw-w-full tw-bg-[#3673aa] hover:tw-bg-[#396082] tw-h-12 tw-mt-4"
+        >
+          {isLoading ? <Loading /> : "Continue"}
+        </Button>
+        <FormError className="tw-mt-2" message={submitError} />
+      </form>
+    );
+  } else {
+    content = submitError ? <FormError message={submitError} /> : <Loading />;
+  }
+
+  return (
+    <div className="tw-flex tw-flex-row tw-h-full tw-w-full tw-bg-slate-100">
+      <div className="tw-mt-20 sm:tw-mt-32 tw-mb-auto tw-mx-auto tw-w-[400px]">
+        <div className="tw-flex tw-flex-col tw-pt-12 tw-pb-10 tw-px-8 tw-rounded-lg sm:tw-shadow-md sm:tw-bg-white tw-items-center">
+          <Image src={LongLogo} width={200} height={32} className="tw-select-none tw-mb-4" alt="coaster logo" />
+          <div className="tw-text-xl tw-font-semibold tw-font-heading tw-text-center tw-mb-2">
+            Set up two-factor authentication
+          </div>
+          {content}
+        </div>
+      </div>
+    </div>
+  );
+};
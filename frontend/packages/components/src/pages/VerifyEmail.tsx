@@ -0,0 +1,78 @@
+"use client";
+
+import LongLogo from "@coaster/assets/long-logo.svg";
+import { useAuthContext, useSendEmailVerification, useVerifyEmail } from "@coaster/rpc/client";
+import Image from "next/image";
+import { redirect, useSearchParams } from "next/navigation";
+import { useEffect, useRef } from "react";
+import { Button } from "../button/Button";
+import { NavLink } from "../link/Link";
+import { Loading } from "../loading/Loading";
+
+export const VerifyEmail: React.FC = () => {
+  const { user } = useAuthContext();
+  const searchParams = useSearchParams();
+  const token = searchParams?.get("token");
+  const { mutate: verifyEmail, isSuccess, isFailed } = useVerifyEmail();
+  const { mutate: resendEmail, isLoading: isResending, isSuccess: isResent } = useSendEmailVerification();
+
+  // Tokens can only be used once, so make sure the request isn't sent twice in development
+  const sent = useRef(false);
+  useEffect(() => {
+    if (token && !sent.current) {
+      sent.current = true;
+      verifyEmail({ token });
+    }
+  }, [token]);
+
+  if (!token) {
+    return redirect("/");
+  }
+
+  let content: React.ReactNode;
+  if (isSuccess) {
+    content = (
+      <>
+        <div className="tw-text-xl tw-font-semibold tw-font-heading tw-text-center tw-mb-2">Email verified</div>
+        <div className="tw-text-center">Thanks for confirming your email address.</div>
+        <NavLink className="tw-mt-4 tw-text-blue-500" href="/">
+          Continue to Coaster
+        </NavLink>
+      </>
+    );
+  } else if (isFailed) {
+    content = (
+      <>
+        <div className="tw-text-xl tw-font-semibold tw-font-heading tw-text-center tw-mb-2">Link expired</div>
+        <div className="tw-text-center">This link is invalid or has expired.</div>
+        {user && !user.email_verified && (
+          <Button
+            className="tw-w-full tw-bg-[#3673aa] hover:tw-bg-[#396082] tw-h-12 tw-mt-4"
+            disabled={isResending || isResent}
+            onClick={() => resendEmail()}
+          >
+            {isResending ? <Loading /> : isResent ? "Email sent" : "Send a new link"}
+          </Button>
+        )}
+      </>
+    );
+  } else {
+    content = (
+      <div className="tw-flex tw-flex-col tw-items-center">
+        <div className="tw-mb-4">Verifying your email...</div>
+        <Loading />
+      </div>
+    );
+  }
+
+  return (
+    <div className="tw-flex tw-flex-row tw-h-full tw-w-full tw-bg-slate-100">
+      <div className="tw-mt-20 sm:tw-mt-32 tw-mb-auto tw-mx-auto tw-w-[400px]">
+        <div className="tw-flex tw-flex-col tw-pt-12 tw-pb-10 tw-px-8 tw-rounded-lg sm:tw-shadow-md sm:tw-bg-white tw-items-center">
+          <Image src={LongLogo} width={200} height={32} className="tw-select-none tw-mb-4" alt="coaster logo" />
+          {content}
+        </div>
+      </div>
+    </div>
+  );
+};
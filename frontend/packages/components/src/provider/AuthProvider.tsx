@@ -10,12 +10,17 @@ import { pathToRegexp } from "path-to-regexp";
 import { useCallback, useMemo, useState } from "react";
 import useSWR, { Fetcher } from "swr";
 
+// Admins are sent here until they've set up two-factor, since nothing else works for them without it
+const TWO_FACTOR_SETUP_PATH = "/two-factor/setup";
+
 // Not exported - everywhere else should use useAuthContext
 function useUser() {
+  const [twoFactorRequired, setTwoFactorRequired] = useState(false);
   const fetcher: Fetcher<User | undefined, {}> = async () => {
     try {
       const response = await sendRequest(CheckSession);
       identifyUser(response.user);
+      setTwoFactorRequired(response.two_factor_required);
       return response.user;
     } catch (e) {
       if (e instanceof HttpError) {
@@ -33,7 +38,7 @@ function useUser() {
   };
 
   const { data, mutate, error, isLoading, isValidating } = useSWR({ CheckSession }, fetcher);
-  return { user: data, mutate, error, loading: isLoading || isValidating };
+  return { user: data, twoFactorRequired, mutate, error, loading: isLoading || isValidating };
 }
 
 export const AuthProvider: React.FC<{ children: React.ReactNode; publicPaths: string[]; noRedirect?: boolean }> = ({
@@ -41,7 +46,7 @@ export const AuthProvider: React.FC<{ children: React.ReactNode; publicPaths: st
   publicPaths,
   noRedirect,
 }) => {
-  const { user, loading } = useUser();
+  const { user, twoFactorRequired, loading } = useUser();
   const [loginOpen, setModalOpen] = useState(false);
   const [create, setCreate] = useState(false);
   const pathname = usePathname();
@@ -73,6 +78,10 @@ export const AuthProvider: React.FC<{ children: React.ReactNode; publicPaths: st
     return redirect("/login");
   }
 
+  if (user && twoFactorRequired && pathname !== TWO_FACTOR_SETUP_PATH && !noRedirect) {
+    return redirect(TWO_FACTOR_SETUP_PATH);
+  }
+
   return <AuthContext.Provider value={contextObject}>{children}</AuthContext.Provider>;
 };
 
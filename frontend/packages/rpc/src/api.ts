@@ -5,11 +5,13 @@ import {
   AvailabilityRuleUpdates,
   Booking,
   CheckSessionResponse,
+  ConfirmTwoFactorRequest,
   CreateCheckoutLinkRequest,
   CreateUserRequest,
   CreateUserResponse,
   EmailLoginRequest,
   EmailLoginResponse,
+  EnrollTwoFactorResponse,
   Image,
   ItineraryStep,
   ItineraryStepInput,
@@ -19,15 +21,21 @@ import {
   LoginMethod,
   LoginRequest,
   LoginResponse,
+  MagicLinkLoginRequest,
   OAuthProvider,
   PayoutMethod,
+  ReauthenticateRequest,
+  RecoveryCodesResponse,
   ResetPasswordRequest,
+  SearchListingsResponse,
   SearchParams,
   SendInviteRequest,
   SendResetRequest,
   Tag,
   User,
   UserUpdates,
+  VerifyEmailRequest,
+  VerifyTwoFactorRequest,
 } from "@coaster/types";
 
 export interface IEndpoint<RequestType, ResponseType, PathParams = {}, QueryParams = {}> {
@@ -42,12 +50,19 @@ export const CheckSession: IEndpoint<undefined, CheckSessionResponse> = {
   path: "/check_session",
 };
 
-export const SearchListings: IEndpoint<undefined, Listing[], undefined, SearchParams> = {
+export const SearchListings: IEndpoint<undefined, SearchListingsResponse, undefined, SearchParams> = {
   name: "Search listings",
   method: "GET",
   path: "/listings",
 };
 
+// Every published listing, unpaginated, for the commerce feeds
+export const GetListingFeed: IEndpoint<undefined, Listing[]> = {
+  name: "Get listing feed",
+  method: "GET",
+  path: "/listing_feed",
+};
+
 export const GetListing: IEndpoint<undefined, Listing, { listingID: number }> = {
   name: "Get listing",
   method: "GET",
@@ -246,18 +261,60 @@ export const EmailLogin: IEndpoint<EmailLoginRequest, EmailLoginResponse> = {
   path: "/login",
 };
 
+export const MagicLinkLogin: IEndpoint<MagicLinkLoginRequest, EmailLoginResponse> = {
+  name: "Magic link login",
+  method: "POST",
+  path: "/login/link",
+};
+
+export const VerifyTwoFactor: IEndpoint<VerifyTwoFactorRequest, EmailLoginResponse> = {
+  name: "Verify two-factor",
+  method: "POST",
+  path: "/login/two_factor",
+};
+
+export const Reauthenticate: IEndpoint<ReauthenticateRequest, undefined> = {
+  name: "Reauthenticate",
+  method: "POST",
+  path: "/user/reauthenticate",
+};
+
+export const EnrollTwoFactor: IEndpoint<undefined, EnrollTwoFactorResponse> = {
+  name: "Enroll two-factor",
+  method: "POST",
+  path: "/user/two_factor",
+};
+
+export const ConfirmTwoFactor: IEndpoint<ConfirmTwoFactorRequest, RecoveryCodesResponse> = {
+  name: "Confirm two-factor",
+  method: "POST",
+  path: "/user/two_factor/confirm",
+};
+
 export const SendReset: IEndpoint<SendResetRequest, undefined> = {
   name: "Send reset",
   method: "POST",
   path: "/send_reset",
 };
 
-export const ResetPassword: IEndpoint<ResetPasswordRequest, User> = {
+export const ResetPassword: IEndpoint<ResetPasswordRequest, User | EmailLoginResponse> = {
   name: "Reset password",
   method: "POST",
   path: "/reset_password",
 };
 
+export const VerifyEmail: IEndpoint<VerifyEmailRequest, User> = {
+  name: "Verify email",
+  method: "POST",
+  path: "/verify_email",
+};
+
+export const SendEmailVerification: IEndpoint<undefined, undefined> = {
+  name: "Send email verification",
+  method: "POST",
+  path: "/user/send_verification",
+};
+
 export const SendInvite: IEndpoint<SendInviteRequest, undefined> = {
   name: "SendInvite",
   method: "POST",
@@ -6,6 +6,7 @@ import {
   AvailabilityRuleInput,
   AvailabilityRuleUpdates,
   CreateCheckoutLinkRequest,
+  EmailLoginResponse,
   Image,
   ItineraryStep,
   ItineraryStepInput,
@@ -15,6 +16,7 @@ import {
   ResetPasswordRequest,
   User,
   UserUpdates,
+  VerifyEmailRequest,
 } from "@coaster/types";
 import { Mutation, MutationOpts, useMutation } from "@coaster/utils/client";
 import { HttpError, forceErrorMessage, isProd } from "@coaster/utils/common";
@@ -37,8 +39,10 @@ import {
   Logout,
   ResetPassword,
   SearchListings,
+  SendEmailVerification,
   UpdateProfilePicture,
   UpdateUser,
+  VerifyEmail,
 } from "./api";
 import {
   addListingImagesServerAction,
@@ -160,7 +164,7 @@ export function useSearch(location?: string, categories?: string) {
       queryParams.categories = categories;
     }
 
-    return sendRequest(SearchListings, { queryParams });
+    return sendRequest(SearchListings, { queryParams }).then((response) => response.listings);
   };
   const { data, mutate, error, isLoading, isValidating } = useSWR(
     shouldFetch ? { SearchListings, location, categories } : null,
@@ -334,19 +338,42 @@ export function useUpdateProfilePicture(): Mutation<File> {
   );
 }
 
+// Users with two-factor get a challenge back instead of being logged in, which is left in data for the page to handle
 export function useResetPassword(): Mutation<ResetPasswordRequest> {
-  return useMutation<User, ResetPasswordRequest>(
+  return useMutation<User | EmailLoginResponse, ResetPasswordRequest>(
     async (request: ResetPasswordRequest) => {
       return await sendRequest(ResetPassword, { payload: request });
     },
     {
-      onSuccess: (user: User) => {
-        mutate({ CheckSession }, user);
+      onSuccess: (result: User | EmailLoginResponse) => {
+        if (!("two_factor_challenge" in result)) {
+          mutate({ CheckSession }, result);
+        }
       },
     },
   );
 }
 
+export function useVerifyEmail(): Mutation<VerifyEmailRequest> {
+  return useMutation<User, VerifyEmailRequest>(
+    async (request: VerifyEmailRequest) => {
+      return await sendRequest(VerifyEmail, { payload: request });
+    },
+    {
+      // The link may be opened while logged in as someone else, so refetch the session instead of replacing it
+      onSuccess: () => {
+        mutate({ CheckSession });
+      },
+    },
+  );
+}
+
+export function useSendEmailVerification(opts?: MutationOpts<undefined>): Mutation<void> {
+  return useMutation<undefined, void>(async () => {
+    return await sendRequest(SendEmailVerification);
+  }, opts);
+}
+
 export function useOnLoginSuccess() {
   return useCallback(async (user: User) => {
     mutate({ CheckSession }, user);
@@ -50,7 +50,8 @@ export async function getTagServer(slug: string, cookieString?: string): Promise
 }
 
 export async function search(queryParams: SearchParams): Promise<Listing[]> {
-  return sendRequest(SearchListings, { queryParams, revalidate: 600 });
+  const response = await sendRequest(SearchListings, { queryParams, revalidate: 600 });
+  return response.listings;
 }
 
 export async function getHostedListingsServer() {
@@ -13,10 +13,54 @@ export interface LoginResponse {
 
 export interface CheckSessionResponse {
   user: User;
+  two_factor_required: boolean;
 }
 
 export interface SearchListingsResponse {
   listings: Listing[];
+  total_count: number;
+  next_cursor: string | null;
+  facets?: SearchFacets;
+  clusters?: SearchCluster[];
+}
+
+export interface SearchFacets {
+  categories: FacetCount[];
+  countries: FacetCount[];
+  regions: RegionFacetCount[];
+  price_buckets: RangeFacetCount[];
+  duration_buckets: RangeFacetCount[];
+}
+
+export interface FacetCount {
+  value: string;
+  count: number;
+}
+
+export interface RegionFacetCount {
+  country: string;
+  region: string;
+  count: number;
+}
+
+export interface RangeFacetCount {
+  min: number;
+  max: number | null;
+  count: number;
+}
+
+export interface SearchCluster {
+  longitude: number;
+  latitude: number;
+  count: number;
+  bounds: BoundingBox;
+}
+
+export interface BoundingBox {
+  west: number;
+  south: number;
+  east: number;
+  north: number;
 }
 
 export type PayoutMethod =
@@ -267,6 +311,8 @@ export interface User {
   profile_picture_url?: string;
   about?: string;
   is_host: boolean;
+  email_verified: boolean;
+  two_factor_enabled: boolean;
   stripe_account_status: StripeAccountStatus;
 }
 
@@ -309,12 +355,47 @@ export interface ResetPasswordRequest {
   token: string;
 }
 
+export interface MagicLinkLoginRequest {
+  token: string;
+}
+
+export interface VerifyEmailRequest {
+  token: string;
+}
+
+export interface ReauthenticateRequest {
+  password?: string;
+  code?: string;
+  recovery_code?: string;
+}
+
+export interface EnrollTwoFactorResponse {
+  secret: string;
+  otpauth_uri: string;
+}
+
+export interface ConfirmTwoFactorRequest {
+  code: string;
+}
+
+export interface RecoveryCodesResponse {
+  recovery_codes: string[];
+}
+
+export interface VerifyTwoFactorRequest {
+  challenge: string;
+  code?: string;
+  recovery_code?: string;
+}
+
 export interface SendInviteRequest {
   emails: string[];
 }
 
+// Users with two-factor get a challenge instead of a user, to send to VerifyTwoFactor with their code
 export interface EmailLoginResponse {
-  user: User;
+  user?: User;
+  two_factor_challenge?: string;
 }
 
 export interface ListingMetadata {